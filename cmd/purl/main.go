@@ -1,13 +1,29 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	stderrors "errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/aleister1102/purl/internal/benchmark"
+	"github.com/aleister1102/purl/internal/cache"
 	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/cookies"
 	"github.com/aleister1102/purl/internal/errors"
+	"github.com/aleister1102/purl/internal/har"
 	"github.com/aleister1102/purl/internal/output"
 	"github.com/aleister1102/purl/internal/protocol"
 	"github.com/aleister1102/purl/internal/request"
@@ -23,82 +39,834 @@ func main() {
 		os.Exit(errors.MapErrorToExitCode(err))
 	}
 
+	// Dump the effective configuration for debugging
+	if opts.TraceConfig {
+		if err := cli.WriteTraceConfig(os.Stderr, opts); err != nil {
+			printError(err)
+		}
+	}
+
+	// Cancel in-flight work on Ctrl-C or SIGTERM so output is left in a clean state
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
 	// Execute the main workflow
-	exitCode := run(opts)
+	exitCode := run(ctx, opts)
+	stop()
 	os.Exit(exitCode)
 }
 
-// run executes the main workflow: parse target → detect protocol → build request → execute → output
-func run(opts *cli.Options) int {
-	// Step 1: Parse target URL
-	parsedTarget, err := target.ParseTarget(opts.Target)
-	if err != nil {
+// run executes each request chained with --next in order, stopping at the first failure
+// Cancelling ctx aborts the request and exits with ExitInterrupted
+func run(ctx context.Context, opts *cli.Options) (exitCode int) {
+	// Explain a failing exit code with the error that caused it
+	lastError = nil
+	if opts.ExplainExit {
+		defer func() {
+			if exitCode != errors.ExitSuccess {
+				printExitExplanation(exitCode)
+			}
+		}()
+	}
+
+	// Bound the whole run by an absolute deadline
+	if !opts.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, opts.Deadline)
+		defer cancel()
+	}
+
+	// Replay the requests recorded in a HAR file instead of the target
+	if opts.ReplayFromHAR != "" {
+		replay, err := har.ReplayOptions(opts.ReplayFromHAR, opts)
+		if err != nil {
+			printError(err)
+			return errors.MapErrorToExitCode(err)
+		}
+		opts = replay
+	}
+
+	// Upload each listed file as its own request
+	if opts.DataFileList != "" {
+		uploads, err := request.DataFileListOptions(opts.DataFileList, opts)
+		if err != nil {
+			printError(err)
+			return errors.MapErrorToExitCode(err)
+		}
+		opts = uploads
+	}
+
+	// -s hides error messages unless -S asks for them back
+	silenceErrors = opts.Silent && !opts.ShowError
+
+	// Read --targets-file lists up front so they count toward --max-total-targets
+	targetLists := map[*cli.Options][]*target.ParsedTarget{}
+	listed := map[*cli.Options]int{}
+	for current := opts; current != nil; current = current.Next {
+		if current.TargetsFile == "" {
+			continue
+		}
+		targets, err := request.ReadTargetsFile(current, os.Stdin)
+		if err != nil {
+			printError(err)
+			return errors.MapErrorToExitCode(err)
+		}
+		targetLists[current] = targets
+		listed[current] = len(targets)
+	}
+
+	// Refuse runaway expansions before anything is sent
+	if err := request.CheckTargetCount(opts, listed); err != nil {
 		printError(err)
 		return errors.MapErrorToExitCode(err)
 	}
 
+	// Share TLS sessions across every request of the run
+	var tlsSessions tls.ClientSessionCache
+	if opts.TLSResumption {
+		tlsSessions = tls.NewLRUClientSessionCache(0)
+	}
+
+	sess := &session{
+		vars:       request.Vars{},
+		varsMu:     &sync.Mutex{},
+		budget:     request.NewRetryBudget(opts.RetryBudget),
+		transports: transport.NewCache(tlsSessions),
+		metrics:    output.NewMetrics(),
+	}
+	defer writeMetrics(sess)
+
+	// Dump the bytes of every connection of the run
+	if opts.Trace != "" || opts.TraceASCII != "" {
+		closeTrace, err := openTrace(opts, sess.transports)
+		if err != nil {
+			printError(err)
+			return errors.MapErrorToExitCode(err)
+		}
+		defer closeTrace()
+	}
+
+	// Keep cookies across redirects and requests, seeded from -b FILE
+	if opts.CookieFile != "" || opts.CookieJar != "" {
+		sess.jar = cookies.NewJar()
+		if err := loadCookies(opts.CookieFile, sess.jar); err != nil {
+			printError(err)
+			return errors.MapErrorToExitCode(err)
+		}
+	}
+
+	// Vary fingerprints across every request of the run from one seed
+	if opts.RandomizeHeaders {
+		sess.random = request.NewRandomizer(opts.RandomizeSeed)
+	}
+
+	// Record every exchange of the run into one HAR file
+	if opts.OutputHAR != "" {
+		sess.recorder = har.NewRecorder()
+		defer writeHAR(opts.OutputHAR, sess.recorder)
+	}
+
+	for current := opts; current != nil; current = current.Next {
+		if targets, ok := targetLists[current]; ok {
+			if exitCode := runTargets(ctx, current, targets, sess); exitCode != errors.ExitSuccess {
+				return exitCode
+			}
+			continue
+		}
+		for i := 0; i < max(current.Repeat, 1); i++ {
+			if exitCode := runOne(ctx, current, nil, sess); exitCode != errors.ExitSuccess {
+				return exitCode
+			}
+		}
+	}
+
+	return errors.ExitSuccess
+}
+
+// runTargets sends the request to every target of a --targets-file, continuing past
+// failures, and returns the exit code of the first target that failed
+// Interrupts and an expired --deadline stop the remaining targets
+func runTargets(ctx context.Context, opts *cli.Options, targets []*target.ParsedTarget, sess *session) int {
+	if opts.Parallel > 1 {
+		return runTargetsParallel(ctx, opts, targets, sess)
+	}
+
+	exitCode := errors.ExitSuccess
+	for _, parsedTarget := range targets {
+		if code := runTarget(ctx, opts, parsedTarget, sess); code != errors.ExitSuccess && exitCode == errors.ExitSuccess {
+			exitCode = code
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return exitCode
+}
+
+// parallelLookahead bounds how many --parallel batches of targets may finish
+// ahead of the one being printed, and so how much output is buffered
+const parallelLookahead = 2
+
+// runTargetsParallel runs --parallel workers over the targets, buffering each target's
+// output and printing it in file order so the output matches a sequential run
+func runTargetsParallel(ctx context.Context, opts *cli.Options, targets []*target.ParsedTarget, sess *session) int {
+	type targetRun struct {
+		out, errOut bytes.Buffer
+		exitCode    int
+		done        chan struct{}
+	}
+
+	runs := make([]*targetRun, len(targets))
+	for i := range runs {
+		runs[i] = &targetRun{done: make(chan struct{})}
+	}
+
+	// A target is handed to a worker only once it is within the window of the
+	// next target to print; printing a target frees its slot
+	window := make(chan struct{}, opts.Parallel*parallelLookahead)
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range runs {
+			window <- struct{}{}
+			jobs <- i
+		}
+	}()
+
+	for range min(opts.Parallel, len(targets)) {
+		go func() {
+			for i := range jobs {
+				run := runs[i]
+				if ctx.Err() == nil {
+					// Share the session's state but not its output
+					worker := *sess
+					worker.out, worker.errOut = &run.out, &run.errOut
+					run.exitCode = runTarget(ctx, opts, targets[i], &worker)
+				}
+				close(run.done)
+			}
+		}()
+	}
+
+	exitCode := errors.ExitSuccess
+	for _, run := range runs {
+		<-run.done
+		io.Copy(sess.stdout(), &run.out)
+		io.Copy(sess.stderr(), &run.errOut)
+		<-window
+		if run.exitCode != errors.ExitSuccess && exitCode == errors.ExitSuccess {
+			exitCode = run.exitCode
+		}
+	}
+	return exitCode
+}
+
+// targetPlaceholder in -o is replaced with each --targets-file target, one file per target
+const targetPlaceholder = "%{target}"
+
+// runTarget sends the request --repeat times to one --targets-file target
+// and returns the exit code of the first attempt that failed
+func runTarget(ctx context.Context, opts *cli.Options, parsedTarget *target.ParsedTarget, sess *session) int {
+	if strings.Contains(opts.Output, targetPlaceholder) {
+		perTarget := *opts
+		perTarget.Output = strings.ReplaceAll(opts.Output, targetPlaceholder, targetFileName(parsedTarget.OriginalInput))
+		opts = &perTarget
+	}
+
+	exitCode := errors.ExitSuccess
+	for i := 0; i < max(opts.Repeat, 1); i++ {
+		// Each run rewrites the URL's scheme, so give it its own copy
+		run := *parsedTarget
+		u := *parsedTarget.URL
+		run.URL = &u
+
+		if code := runOne(ctx, opts, &run, sess); code != errors.ExitSuccess && exitCode == errors.ExitSuccess {
+			exitCode = code
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return exitCode
+}
+
+// targetFileName makes a target usable in a file name, replacing all but
+// letters, digits, '.', '-' and '_' with '_' (https://a.com:8443 → https___a.com_8443)
+func targetFileName(input string) string {
+	return strings.Map(func(r rune) rune {
+		if r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || r == '_') {
+			return r
+		}
+		return '_'
+	}, input)
+}
+
+// session holds state shared by every request of one invocation
+type session struct {
+	vars       request.Vars         // values extracted for later requests
+	varsMu     *sync.Mutex          // guards vars, shared by --parallel workers
+	budget     *request.RetryBudget // retries shared by all requests
+	recorder   *har.Recorder        // nil unless --output-har is set
+	random     *request.Randomizer  // nil unless --randomize-headers is set
+	transports *transport.Cache     // transports reused across requests
+	jar        *cookies.Jar         // nil unless -b FILE or -c is set
+	metrics    *output.Metrics      // --prometheus results, written once the run ends
+
+	// out and errOut receive the request's output; nil uses os.Stdout and os.Stderr
+	// A --parallel target buffers its output here until it is its turn to print
+	out    io.Writer
+	errOut io.Writer
+}
+
+// stdout returns the writer for the response body and status line
+func (s *session) stdout() io.Writer {
+	if s.out != nil {
+		return s.out
+	}
+	return os.Stdout
+}
+
+// stderr returns the writer for verbose and diagnostic output
+func (s *session) stderr() io.Writer {
+	if s.errOut != nil {
+		return s.errOut
+	}
+	return os.Stderr
+}
+
+// runOne executes a single request: parse target → detect protocol → build request → execute → output
+// parsedTarget is a --targets-file entry, or nil to parse opts.Target
+// Values extracted from the response are stored in the session for later requests
+func runOne(ctx context.Context, opts *cli.Options, parsedTarget *target.ParsedTarget, sess *session) int {
+	// Substitute values extracted by earlier requests
+	sess.varsMu.Lock()
+	opts, err := sess.vars.Apply(opts)
+	sess.varsMu.Unlock()
+	if err != nil {
+		sess.printError(err)
+		return errors.MapErrorToExitCode(err)
+	}
+
+	// Collect metrics for whatever result the request ends with
+	probeResult := &protocol.ProbeResult{}
+	if opts.Prometheus != "" {
+		defer func() {
+			sess.metrics.Add(opts.Prometheus, metricsTarget(opts, parsedTarget), probeResult)
+		}()
+	}
+
+	// Step 1: Parse target URL, unless it came from a targets file
+	if parsedTarget == nil {
+		parsedTarget, err = target.ParseTargetWithScheme(opts.Target, opts.DefaultScheme)
+		if err != nil {
+			probeResult.Error = err
+			sess.printError(err)
+			return errors.MapErrorToExitCode(err)
+		}
+	}
+	if opts.Path != "" {
+		target.ReplacePath(parsedTarget, opts.Path)
+	}
+
+	// Connect-only mode checks reachability without probing or sending a request
+	if opts.ConnectOnly {
+		scheme := parsedTarget.URL.Scheme
+		if opts.Proto == "http" || opts.Proto == "https" {
+			scheme = opts.Proto
+		}
+		if err := transport.Connect(ctx, opts, parsedTarget, scheme); err != nil {
+			probeResult.Error = err
+			return sess.fail(ctx, err)
+		}
+		return errors.ExitSuccess
+	}
+
+	// --max-time bounds protocol detection and the request together
+	reqCtx, cancel := context.WithTimeout(ctx, transport.ApplyTimeouts(opts))
+	defer cancel()
+
 	// Step 2: Detect protocol (auto or manual)
-	probeResult, err := protocol.DetectProtocol(parsedTarget, opts)
+	probeResult, err = protocol.DetectProtocol(reqCtx, parsedTarget, opts)
 	if err != nil {
-		printError(err)
+		sess.printError(err)
 		return errors.MapErrorToExitCode(err)
 	}
 
 	// If protocol detection failed, return the error
 	if probeResult.Error != nil {
-		printError(probeResult.Error)
-		return errors.MapErrorToExitCode(probeResult.Error)
+		return sess.fail(ctx, probeResult.Error)
 	}
 
 	// Step 3: Update the parsed target URL with the detected protocol
 	parsedTarget.URL.Scheme = probeResult.Protocol
 
-	// Step 4: Build the actual request (not just the probe)
-	ctx, cancel := context.WithTimeout(context.Background(), transport.ApplyTimeouts(opts))
-	defer cancel()
+	// Benchmark mode replaces the single request
+	if opts.Benchmark {
+		return runBenchmark(ctx, parsedTarget, opts, sess)
+	}
 
-	req, err := request.BuildRequest(ctx, parsedTarget, opts)
+	// Step 4: Build the actual request (not just the probe)
+	req, err := request.BuildRequest(reqCtx, parsedTarget, opts)
 	if err != nil {
-		printError(err)
+		sess.printError(err)
 		return errors.MapErrorToExitCode(err)
 	}
 
+	// Echo correlation IDs so server logs can be searched for them
+	if !opts.Silent {
+		if opts.GenerateRequestID || opts.RequestID != "" {
+			fmt.Fprintf(sess.stderr(), "* X-Request-ID: %s\n", req.Header.Get("X-Request-ID"))
+		}
+		if opts.Traceparent {
+			fmt.Fprintf(sess.stderr(), "* traceparent: %s\n", req.Header.Get("Traceparent"))
+		}
+	}
+
+	if sess.random != nil {
+		sess.random.Apply(req)
+	}
+
+	// Save the request for replay in editor REST clients
+	if opts.SaveRequest != "" {
+		if err := saveRequest(opts.SaveRequest, req); err != nil {
+			sess.printError(err)
+			return errors.MapErrorToExitCode(err)
+		}
+	}
+
+	// Show resolved addresses and the one the connection used
+	if opts.ShowDNS {
+		output.WriteDNS(sess.stderr(), req, opts)
+		req = output.TraceConnection(req, sess.stderr())
+	}
+
+	// Record the connection details -w and the JSON summary report
+	var reqTrace *output.RequestTrace
+	if opts.SummaryJSON || opts.WriteOut != "" {
+		req, reqTrace = output.NewRequestTrace(req)
+	}
+
+	// Capture connection timings for the HAR recording
+	var timer *har.Timer
+	if sess.recorder != nil {
+		req, timer = har.Trace(req)
+	}
+
 	// Step 5: Create transport and execute the request
-	tr, err := transport.NewTransport(opts, parsedTarget)
+	// Repeats of the same request reuse the transport and its keep-alive connections
+	tr, err := sess.transports.Get(opts, parsedTarget)
 	if err != nil {
-		printError(err)
+		sess.printError(err)
 		return errors.MapErrorToExitCode(err)
 	}
 
 	client := &http.Client{
-		Transport: tr,
-		Timeout:   transport.ApplyTimeouts(opts),
+		Transport:     tr,
+		Timeout:       transport.ApplyTimeouts(opts),
+		CheckRedirect: request.CheckRedirect(opts),
+	}
+	if sess.jar != nil {
+		client.Jar = sess.jar
+	}
+
+	send := func(req *http.Request) (*http.Response, error) {
+		return request.Execute(client, req, opts, sess.budget)
+	}
+
+	// Answer Digest challenges, reusing a stored one when preemptive
+	if opts.Digest && opts.User != "" {
+		execute := send
+		send = func(req *http.Request) (*http.Response, error) {
+			return request.DoDigest(req, opts, execute)
+		}
+	}
+
+	// Ask for the advertised length before fetching the body
+	var headLength int64
+	if opts.HeadBodyCheck {
+		headLength, err = request.HeadContentLength(client, req)
+		if err != nil {
+			probeResult.Error = err
+			return sess.fail(ctx, err)
+		}
 	}
 
-	resp, err := client.Do(req)
+	// Revalidate against the on-disk cache when enabled
+	requestStart := time.Now()
+	var resp *http.Response
+	if opts.CacheDir != "" {
+		responseCache, cacheErr := cache.New(opts.CacheDir)
+		if cacheErr != nil {
+			sess.printError(cacheErr)
+			return errors.MapErrorToExitCode(cacheErr)
+		}
+		resp, err = responseCache.Do(req, send)
+	} else {
+		resp, err = send(req)
+	}
 	if err != nil {
-		printError(err)
-		return errors.MapErrorToExitCode(err)
+		probeResult.Error = err
+		return sess.fail(ctx, err)
+	}
+	// Outputs report this request's time, not the probe's (which --default-scheme skips)
+	probeResult.RequestTime = time.Since(requestStart)
+
+	// Reject redirects for strict endpoint checks
+	if err := request.CheckRedirectStatus(resp, opts); err != nil {
+		resp.Body.Close()
+		probeResult.Error = err
+		return sess.fail(ctx, err)
+	}
+
+	// Catch unexpected TLS downgrades
+	if err := output.CheckTLSVersion(resp, opts); err != nil {
+		resp.Body.Close()
+		probeResult.Error = err
+		return sess.fail(ctx, err)
+	}
+
+	// Reject weak TLS versions and cipher suites
+	if err := output.CheckWeakTLS(resp, opts); err != nil {
+		resp.Body.Close()
+		probeResult.Error = err
+		return sess.fail(ctx, err)
+	}
+
+	// Abandon unwanted responses before downloading their body
+	if err := request.CheckAbortHeader(resp, opts); err != nil {
+		probeResult.Error = err
+		return sess.fail(ctx, err)
+	}
+
+	// Follow refresh redirects that a 3xx-based redirect policy cannot see
+	if opts.FollowMetaRefresh {
+		resp, err = request.FollowRefresh(client, resp, opts)
+		if err != nil {
+			probeResult.Error = err
+			return sess.fail(ctx, err)
+		}
+		req = resp.Request
+	}
+
+	// Save the session's cookies for later curl/purl runs, even when the status fails
+	if opts.CookieJar != "" {
+		if err := saveCookies(opts.CookieJar, sess.jar); err != nil {
+			sess.printError(err)
+		}
+	}
+
+	// Decode a --compressed body before anything reads it; this is the only place it is decoded
+	if opts.Compressed {
+		if err := output.DecompressBody(resp); err != nil {
+			resp.Body.Close()
+			probeResult.Error = err
+			return sess.fail(ctx, err)
+		}
+	}
+
+	// -f: fail on HTTP errors without printing the body
+	if opts.Fail && resp.StatusCode >= 400 {
+		resp.Body.Close()
+		probeResult.StatusCode = resp.StatusCode
+		err := &errors.HTTPError{StatusCode: resp.StatusCode, URL: req.URL.String()}
+		probeResult.Error = err
+		return sess.fail(ctx, err)
 	}
 	defer resp.Body.Close()
 
+	// Extract values for later requests, keeping the body available for output
+	if len(opts.Extract) > 0 {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return sess.fail(ctx, err)
+		}
+		sess.varsMu.Lock()
+		err = sess.vars.Extract(body, opts.Extract)
+		sess.varsMu.Unlock()
+		if err != nil {
+			sess.printError(err)
+			return errors.MapErrorToExitCode(err)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
 	// Update probe result with actual response
 	probeResult.Response = resp
 	probeResult.StatusCode = resp.StatusCode
+	probeResult.HTTPVersion = resp.Proto
+
+	// Match the body as it streams to the output
+	var matcher *output.BodyMatcher
+	if opts.BodyRegex != "" {
+		matcher = output.NewBodyMatcher(regexp.MustCompile(opts.BodyRegex))
+		resp.Body = matcher.Wrap(resp.Body)
+	}
+
+	// Count the body as it streams for the HEAD length check, -w and the JSON summary
+	var counter *request.CountingBody
+	if opts.HeadBodyCheck || opts.SummaryJSON || opts.WriteOut != "" {
+		counter = &request.CountingBody{ReadCloser: resp.Body}
+		resp.Body = counter
+	}
 
 	// Step 6: Output the response
 	handler := output.NewHandler(opts)
+	handler.Stdout = sess.stdout()
+	handler.Stderr = sess.stderr()
+	if opts.TargetsFile != "" {
+		handler.Target = parsedTarget.OriginalInput
+	}
 	if err := handler.WriteResponse(req, probeResult); err != nil {
-		printError(err)
+		var sizeErr *errors.SizeLimitError
+		var decodeErr *errors.DecodeError
+		if ctx.Err() != nil || stderrors.As(err, &sizeErr) || stderrors.As(err, &decodeErr) {
+			return sess.fail(ctx, err)
+		}
+		sess.printError(err)
 		return errors.ExitConnectFailed
 	}
 
+	// Expand -w once the body has been written
+	if opts.WriteOut != "" {
+		vars := output.WriteOutVars(req, probeResult, counter.N, reqTrace)
+		fmt.Fprint(sess.stdout(), output.ExpandWriteOut(opts.WriteOut, vars))
+	}
+
+	// Report per-file status for --data-file-list uploads
+	if opts.DataFile != "" && !opts.Silent {
+		fmt.Fprintf(sess.stderr(), "%s: %d %s\n", opts.DataFile, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	// Record once the body is written so the receive phase is complete
+	if sess.recorder != nil {
+		sess.recorder.Add(req, resp, timer)
+	}
+
+	if opts.SummaryJSON {
+		summary := output.NewSummary(req, probeResult, counter.N, reqTrace)
+		if err := output.WriteSummaryJSON(sess.stderr(), summary); err != nil {
+			sess.printError(err)
+		}
+	}
+
+	if opts.HeadBodyCheck {
+		if err := request.CheckContentLength(headLength, counter.N); err != nil {
+			sess.printError(err)
+			return errors.MapErrorToExitCode(err)
+		}
+	}
+
+	if matcher != nil {
+		if err := matcher.Check(opts.BodyRegexAbsent); err != nil {
+			sess.printError(err)
+			return errors.MapErrorToExitCode(err)
+		}
+		if handler.VerboseLevel() >= 2 {
+			fmt.Fprintf(sess.stderr(), "* Body assertion passed: %q\n", opts.BodyRegex)
+		}
+	}
+
+	// Fail a response that met everything but the latency objective
+	// Measured to the response headers, so a slow reader of the output does not count
+	if elapsed := probeResult.RequestTime; opts.MaxLatency > 0 && elapsed > opts.MaxLatency {
+		err := &errors.LatencyError{URL: req.URL.String(), Elapsed: elapsed, Threshold: opts.MaxLatency}
+		sess.printError(err)
+		return errors.MapErrorToExitCode(err)
+	}
+
 	return errors.ExitSuccess
 }
 
+// runBenchmark fires requests continuously and prints a throughput and latency summary
+// Cancelling ctx stops the run early and still prints what was collected
+func runBenchmark(ctx context.Context, parsedTarget *target.ParsedTarget, opts *cli.Options, sess *session) int {
+	tr, err := transport.NewTransport(opts, parsedTarget)
+	if err != nil {
+		sess.printError(err)
+		return errors.MapErrorToExitCode(err)
+	}
+
+	// Keep one idle connection per worker so keep-alive is effective
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = benchmark.DefaultConcurrency
+	}
+	tr.MaxIdleConnsPerHost = concurrency
+
+	client := &http.Client{
+		Transport: tr,
+		Timeout:   transport.ApplyTimeouts(opts),
+	}
+
+	newRequest := func(ctx context.Context) (*http.Request, error) {
+		return request.BuildRequest(ctx, parsedTarget, opts)
+	}
+
+	summary := benchmark.Run(ctx, client, newRequest, concurrency, opts.Duration)
+	if err := summary.Write(sess.stdout()); err != nil {
+		sess.printError(err)
+		return errors.ExitConnectFailed
+	}
+
+	return errors.ExitSuccess
+}
+
+// writeMetrics writes every --prometheus file collected over the run
+func writeMetrics(sess *session) {
+	for _, path := range sess.metrics.Paths() {
+		file, err := os.Create(path)
+		if err != nil {
+			sess.printError(fmt.Errorf("failed to create metrics file: %w", err))
+			continue
+		}
+		if err := sess.metrics.Write(file, path); err != nil {
+			sess.printError(fmt.Errorf("failed to write metrics: %w", err))
+		}
+		file.Close()
+	}
+}
+
+// metricsTarget names the target a request's metrics are reported under
+func metricsTarget(opts *cli.Options, parsedTarget *target.ParsedTarget) string {
+	if parsedTarget != nil && parsedTarget.URL != nil {
+		return parsedTarget.URL.String()
+	}
+	return opts.Target
+}
+
+// loadCookies reads the Netscape cookie file at path into jar
+// A missing file starts an empty jar, so -b and -c can name the same file on the first run
+func loadCookies(path string, jar *cookies.Jar) error {
+	if path == "" {
+		return nil
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return &errors.FileReadError{Path: path, Cause: err}
+	}
+	defer file.Close()
+
+	if err := jar.Load(file); err != nil {
+		return &errors.FileReadError{Path: path, Cause: err}
+	}
+	return nil
+}
+
+// saveCookies writes the jar to path in Netscape format
+// The file is private to the user since it holds session cookies
+func saveCookies(path string, jar *cookies.Jar) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create cookie file: %w", err)
+	}
+	defer file.Close()
+
+	return jar.Write(file)
+}
+
+// openTrace starts --trace or --trace-ascii for connections made through transports
+// and returns a function closing the trace file
+func openTrace(opts *cli.Options, transports *transport.Cache) (func(), error) {
+	path, ascii := opts.Trace, false
+	if opts.TraceASCII != "" {
+		path, ascii = opts.TraceASCII, true
+	}
+
+	w, closeTrace := io.Writer(os.Stderr), func() {}
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create trace file: %w", err)
+		}
+		w, closeTrace = file, func() { file.Close() }
+	}
+
+	transports.WrapConns(output.NewTraceWriter(w, ascii).WrapConn)
+	return closeTrace, nil
+}
+
+// writeHAR writes the recorded exchanges to path
+func writeHAR(path string, recorder *har.Recorder) {
+	file, err := os.Create(path)
+	if err != nil {
+		printError(fmt.Errorf("failed to create HAR file: %w", err))
+		return
+	}
+	defer file.Close()
+
+	if err := recorder.Write(file); err != nil {
+		printError(err)
+	}
+}
+
+// saveRequest writes the request to path in .http format
+func saveRequest(path string, req *http.Request) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create request file: %w", err)
+	}
+	defer file.Close()
+
+	if err := output.WriteHTTPFile(file, req); err != nil {
+		return fmt.Errorf("failed to write request file: %w", err)
+	}
+	return nil
+}
+
+// fail prints the error and returns its exit code
+// Errors caused by an interrupt are reported with ExitInterrupted, a passed --deadline with ExitTimeout
+func (s *session) fail(ctx context.Context, err error) int {
+	switch ctx.Err() {
+	case context.Canceled:
+		err = &errors.InterruptedError{Cause: ctx.Err()}
+	case context.DeadlineExceeded:
+		err = &errors.TimeoutError{Phase: "deadline"}
+	}
+	s.printError(err)
+	return errors.MapErrorToExitCode(err)
+}
+
+// silenceErrors suppresses printError output for -s without -S
+var silenceErrors bool
+
+// lastError is the most recent error passed to printError, explained by --explain-exit
+// It is guarded by lastErrorMu since --parallel targets report errors concurrently
+var (
+	lastErrorMu sync.Mutex
+	lastError   error
+)
+
 // printError prints an error message to stderr
 func printError(err error) {
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "purl: %v\n", err)
+	writeError(os.Stderr, err)
+}
+
+// printError prints an error message to the session's stderr
+func (s *session) printError(err error) {
+	writeError(s.stderr(), err)
+}
+
+// writeError records err for --explain-exit and prints it to w unless errors are silenced
+func writeError(w io.Writer, err error) {
+	if err == nil {
+		return
+	}
+	lastErrorMu.Lock()
+	lastError = err
+	lastErrorMu.Unlock()
+	if !silenceErrors {
+		fmt.Fprintf(w, "purl: %v\n", err)
+	}
+}
+
+// printExitExplanation prints what exitCode means to stderr, with the error
+// behind it when the last reported error maps to that code
+func printExitExplanation(exitCode int) {
+	if lastError != nil && errors.MapErrorToExitCode(lastError) == exitCode {
+		fmt.Fprintf(os.Stderr, "purl: %s\n", errors.ExplainExit(lastError))
+		return
 	}
+	fmt.Fprintf(os.Stderr, "purl: exit %d (%s)\n", exitCode, errors.ExitCodeMeaning(exitCode))
 }
@@ -0,0 +1,1679 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
+	"github.com/aleister1102/purl/internal/har"
+	"github.com/aleister1102/purl/internal/output"
+	"github.com/aleister1102/purl/internal/request"
+	"github.com/aleister1102/purl/internal/transport"
+)
+
+// captureOutput runs fn with stdout and stderr redirected and returns what was written
+func captureOutput(t *testing.T, fn func()) (string, string) {
+	t.Helper()
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	outR, outW, _ := os.Pipe()
+	errR, errW, _ := os.Pipe()
+	os.Stdout, os.Stderr = outW, errW
+
+	outCh := make(chan []byte)
+	errCh := make(chan []byte)
+	go func() { b, _ := io.ReadAll(outR); outCh <- b }()
+	go func() { b, _ := io.ReadAll(errR); errCh <- b }()
+
+	defer func() {
+		os.Stdout, os.Stderr = oldStdout, oldStderr
+	}()
+	fn()
+
+	outW.Close()
+	errW.Close()
+	return string(<-outCh), string(<-errCh)
+}
+
+func TestRun_InterruptMidStream(t *testing.T) {
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		// Send part of the body, then stall until the client goes away
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "body.txt")
+
+	opts := &cli.Options{
+		Target:  server.URL,
+		Proto:   "http",
+		Output:  outputPath,
+		Timeout: 10 * time.Second,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-started
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	var exitCode int
+	captureOutput(t, func() {
+		exitCode = run(ctx, opts)
+	})
+
+	if exitCode != errors.ExitInterrupted {
+		t.Errorf("exit code: got %d, want %d", exitCode, errors.ExitInterrupted)
+	}
+
+	// The target file must not exist and no temporary file may be left behind
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Errorf("expected output file to be absent, stat error = %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("expected no leftover files, got %d", len(entries))
+	}
+}
+
+func TestRun_InterruptKeepsExistingOutput(t *testing.T) {
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		w.Write([]byte("new content"))
+		w.(http.Flusher).Flush()
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	outputPath := filepath.Join(t.TempDir(), "body.txt")
+	if err := os.WriteFile(outputPath, []byte("previous"), 0o644); err != nil {
+		t.Fatalf("failed to seed output file: %v", err)
+	}
+
+	opts := &cli.Options{
+		Target:  server.URL,
+		Proto:   "http",
+		Output:  outputPath,
+		Timeout: 10 * time.Second,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	captureOutput(t, func() {
+		run(ctx, opts)
+	})
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("failed to read output file: %v", err)
+	}
+	if string(content) != "previous" {
+		t.Errorf("output file content = %q, want %q", string(content), "previous")
+	}
+}
+
+func TestRun_PrometheusMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	metricsPath := filepath.Join(t.TempDir(), "metrics.prom")
+	opts := &cli.Options{
+		Target:     server.URL,
+		Proto:      "http",
+		Prometheus: metricsPath,
+		Timeout:    5 * time.Second,
+	}
+
+	var exitCode int
+	captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+	if exitCode != errors.ExitSuccess {
+		t.Fatalf("exit code: got %d, want %d", exitCode, errors.ExitSuccess)
+	}
+
+	content, err := os.ReadFile(metricsPath)
+	if err != nil {
+		t.Fatalf("failed to read metrics file: %v", err)
+	}
+	for _, want := range []string{"probe_success 1\n", "probe_http_status_code 200\n", "probe_duration_seconds "} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("metrics missing %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestRun_PrometheusMetricsForEveryTarget(t *testing.T) {
+	newServer := func(status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+	}
+	ok, failing := newServer(http.StatusOK), newServer(http.StatusServiceUnavailable)
+	defer ok.Close()
+	defer failing.Close()
+
+	// Both requests, the first repeated, report into the same file
+	metricsPath := filepath.Join(t.TempDir(), "metrics.prom")
+	opts := &cli.Options{
+		Target:     ok.URL,
+		Proto:      "http",
+		Prometheus: metricsPath,
+		Timeout:    5 * time.Second,
+		Repeat:     2,
+		Next: &cli.Options{
+			Target:     failing.URL,
+			Proto:      "http",
+			Prometheus: metricsPath,
+			Timeout:    5 * time.Second,
+		},
+	}
+
+	captureOutput(t, func() {
+		run(context.Background(), opts)
+	})
+
+	content, err := os.ReadFile(metricsPath)
+	if err != nil {
+		t.Fatalf("failed to read metrics file: %v", err)
+	}
+	for _, want := range []string{
+		fmt.Sprintf("probe_http_status_code{target=%q} 200\n", ok.URL),
+		fmt.Sprintf("probe_http_status_code{target=%q} 503\n", failing.URL),
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("metrics missing %q:\n%s", want, content)
+		}
+	}
+	if n := strings.Count(string(content), "# TYPE probe_success gauge"); n != 1 {
+		t.Errorf("Expected one probe_success family, got %d:\n%s", n, content)
+	}
+	if n := strings.Count(string(content), "probe_success{"); n != 2 {
+		t.Errorf("Expected one probe_success sample per target, got %d:\n%s", n, content)
+	}
+}
+
+func TestRun_ExtractAndUseInNextRequest(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		switch r.URL.Path {
+		case "/login":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"auth":{"token":"s3cr3t"}}`))
+		case "/me":
+			gotAuth = r.Header.Get("Authorization")
+			w.Write([]byte("me"))
+		}
+	}))
+	defer server.Close()
+
+	opts, err := cli.ParseArgs([]string{
+		"purl",
+		"--extract", "token=$.auth.token",
+		server.URL + "/login",
+		"--next",
+		"--use", "token",
+		"-H", "Authorization: Bearer {{token}}",
+		server.URL + "/me",
+	})
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+
+	var exitCode int
+	stdout, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+
+	if exitCode != errors.ExitSuccess {
+		t.Fatalf("Expected exit code %d, got %d (stderr: %s)", errors.ExitSuccess, exitCode, stderr)
+	}
+	if gotAuth != "Bearer s3cr3t" {
+		t.Errorf("Expected extracted token in header, got %q", gotAuth)
+	}
+	// The extracting request still prints its body
+	if !strings.Contains(stdout, `{"auth":{"token":"s3cr3t"}}`) || !strings.HasSuffix(stdout, "me") {
+		t.Errorf("Unexpected output: %q", stdout)
+	}
+}
+
+func TestRun_DeadlineBoundsRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		// Stall well past the deadline
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{
+		Target:   server.URL,
+		Proto:    "http",
+		Timeout:  10 * time.Second,
+		Deadline: time.Now().Add(300 * time.Millisecond),
+	}
+
+	var exitCode int
+	start := time.Now()
+	_, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+	elapsed := time.Since(start)
+
+	if elapsed > 2*time.Second {
+		t.Errorf("Request was not bounded by the deadline, took %v", elapsed)
+	}
+	if exitCode != errors.ExitTimeout {
+		t.Errorf("Expected exit code %d, got %d (stderr: %s)", errors.ExitTimeout, exitCode, stderr)
+	}
+}
+
+func TestRun_ReplayFromHAR(t *testing.T) {
+	var gotMethod, gotPath, gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		gotMethod = r.Method
+		gotPath = r.URL.RequestURI()
+		gotHeader = r.Header.Get("X-Recorded")
+		w.Write([]byte("replayed"))
+	}))
+	defer server.Close()
+
+	harPath := filepath.Join(t.TempDir(), "session.har")
+	content := `{"log":{"version":"1.2","entries":[{"request":{"method":"GET","url":"` + server.URL +
+		`/page?id=7","headers":[{"name":"X-Recorded","value":"yes"}]},"response":{"status":200}}]}}`
+	if err := os.WriteFile(harPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write HAR: %v", err)
+	}
+
+	opts, err := cli.ParseArgs([]string{"purl", "--replay-from-har", harPath})
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+
+	var exitCode int
+	stdout, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+
+	if exitCode != errors.ExitSuccess {
+		t.Fatalf("Expected exit code %d, got %d (stderr: %s)", errors.ExitSuccess, exitCode, stderr)
+	}
+	if gotMethod != "GET" || gotPath != "/page?id=7" || gotHeader != "yes" {
+		t.Errorf("Replayed request = %s %s (X-Recorded %q)", gotMethod, gotPath, gotHeader)
+	}
+	if !strings.HasSuffix(stdout, "replayed") {
+		t.Errorf("Unexpected output: %q", stdout)
+	}
+}
+
+func TestRun_OutputHAR(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	harPath := filepath.Join(t.TempDir(), "run.har")
+	opts := &cli.Options{
+		Target:    server.URL + "/status",
+		Proto:     "http",
+		Timeout:   5 * time.Second,
+		OutputHAR: harPath,
+	}
+
+	var exitCode int
+	_, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+	if exitCode != errors.ExitSuccess {
+		t.Fatalf("Expected exit code %d, got %d (stderr: %s)", errors.ExitSuccess, exitCode, stderr)
+	}
+
+	file, err := os.Open(harPath)
+	if err != nil {
+		t.Fatalf("HAR file not written: %v", err)
+	}
+	defer file.Close()
+
+	archive, err := har.Read(file)
+	if err != nil {
+		t.Fatalf("HAR file is invalid: %v", err)
+	}
+	if len(archive.Log.Entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(archive.Log.Entries))
+	}
+
+	entry := archive.Log.Entries[0]
+	if entry.Request.Method != "GET" || entry.Request.URL != server.URL+"/status" {
+		t.Errorf("request section = %s %s", entry.Request.Method, entry.Request.URL)
+	}
+	if entry.Response.Status != 200 || entry.Response.Content.MimeType != "application/json" {
+		t.Errorf("response section = %d %q", entry.Response.Status, entry.Response.Content.MimeType)
+	}
+}
+
+func TestRun_FailIfRedirect(t *testing.T) {
+	var followed bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/new" {
+			followed = true
+			return
+		}
+		if r.Method == http.MethodHead {
+			return
+		}
+		http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{
+		Target:         server.URL + "/old",
+		Proto:          "http",
+		Timeout:        5 * time.Second,
+		FailIfRedirect: true,
+	}
+
+	var exitCode int
+	_, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+
+	if exitCode != errors.ExitTooManyRedirects {
+		t.Errorf("Expected exit code %d, got %d", errors.ExitTooManyRedirects, exitCode)
+	}
+	if followed {
+		t.Error("Redirect was followed")
+	}
+	if !strings.Contains(stderr, "unexpected redirect: 301") {
+		t.Errorf("Expected redirect error on stderr, got %q", stderr)
+	}
+}
+
+func TestRun_DataFileList(t *testing.T) {
+	type upload struct {
+		body        string
+		contentType string
+	}
+	var uploads []upload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		uploads = append(uploads, upload{body: string(body), contentType: r.Header.Get("Content-Type")})
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	first := filepath.Join(dir, "one.json")
+	second := filepath.Join(dir, "two.txt")
+	os.WriteFile(first, []byte(`{"n":1}`), 0644)
+	os.WriteFile(second, []byte("plain text"), 0644)
+	listPath := filepath.Join(dir, "list.txt")
+	os.WriteFile(listPath, []byte(first+"\n"+second+"\n"), 0644)
+
+	opts := &cli.Options{
+		Target:       server.URL + "/upload",
+		Proto:        "http",
+		Timeout:      5 * time.Second,
+		DataFileList: listPath,
+	}
+
+	var exitCode int
+	_, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+
+	if exitCode != errors.ExitSuccess {
+		t.Fatalf("Expected exit code %d, got %d (stderr: %s)", errors.ExitSuccess, exitCode, stderr)
+	}
+
+	expected := []upload{
+		{body: `{"n":1}`, contentType: "application/json"},
+		{body: "plain text", contentType: "text/plain; charset=utf-8"},
+	}
+	if len(uploads) != len(expected) {
+		t.Fatalf("Expected %d uploads, got %d", len(expected), len(uploads))
+	}
+	for i, want := range expected {
+		if uploads[i] != want {
+			t.Errorf("upload %d = %+v, want %+v", i, uploads[i], want)
+		}
+	}
+
+	for _, path := range []string{first, second} {
+		if !strings.Contains(stderr, path+": 201 Created") {
+			t.Errorf("Expected per-file status for %s, got %q", path, stderr)
+		}
+	}
+}
+
+func TestRun_AbortOnHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		w.Header().Set("X-Trigger", "yes")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+
+		// Only send the body once the client has had the chance to abort
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		w.Write([]byte("unwanted body"))
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{
+		Target:        server.URL,
+		Proto:         "http",
+		Timeout:       2 * time.Second,
+		AbortOnHeader: []cli.HeaderMatch{{Name: "X-Trigger", Value: "yes"}},
+	}
+
+	var exitCode int
+	stdout, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+
+	if exitCode != errors.ExitAborted {
+		t.Errorf("Expected exit code %d, got %d (stderr: %q)", errors.ExitAborted, exitCode, stderr)
+	}
+	if strings.Contains(stdout, "unwanted body") {
+		t.Errorf("Body was read: %q", stdout)
+	}
+	if !strings.Contains(stderr, "matched --abort-on-header") {
+		t.Errorf("Expected abort error on stderr, got %q", stderr)
+	}
+}
+
+func TestRun_MaxTotalTargets(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	var list strings.Builder
+	for i := 0; i < 5; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("body%d.txt", i))
+		os.WriteFile(path, []byte("body"), 0644)
+		list.WriteString(path + "\n")
+	}
+	listPath := filepath.Join(dir, "list.txt")
+	os.WriteFile(listPath, []byte(list.String()), 0644)
+
+	opts := &cli.Options{
+		Target:          server.URL + "/upload",
+		Proto:           "http",
+		Timeout:         5 * time.Second,
+		DataFileList:    listPath,
+		MaxTotalTargets: 3,
+	}
+
+	var exitCode int
+	_, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+
+	if exitCode != errors.ExitUnknownFlag {
+		t.Errorf("Expected exit code %d, got %d", errors.ExitUnknownFlag, exitCode)
+	}
+	if requests != 0 {
+		t.Errorf("Expected no requests before the pre-flight check, got %d", requests)
+	}
+	if !strings.Contains(stderr, "5 targets exceed --max-total-targets 3") {
+		t.Errorf("Expected cap error on stderr, got %q", stderr)
+	}
+}
+
+func TestRun_BodyRegex(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"OK"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		pattern      string
+		absent       bool
+		expectedCode int
+	}{
+		{"matching pattern succeeds", `"status":"OK"`, false, errors.ExitSuccess},
+		{"non-matching pattern fails", `"status":"DOWN"`, false, errors.ExitAssertionFailed},
+		{"absent pattern present fails", `OK`, true, errors.ExitAssertionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &cli.Options{
+				Target:          server.URL,
+				Proto:           "http",
+				Timeout:         5 * time.Second,
+				BodyRegex:       tt.pattern,
+				BodyRegexAbsent: tt.absent,
+			}
+
+			var exitCode int
+			stdout, stderr := captureOutput(t, func() {
+				exitCode = run(context.Background(), opts)
+			})
+
+			if exitCode != tt.expectedCode {
+				t.Errorf("Expected exit code %d, got %d (stderr: %q)", tt.expectedCode, exitCode, stderr)
+			}
+			if !strings.Contains(stdout, `{"status":"OK"}`) {
+				t.Errorf("Expected the body on stdout, got %q", stdout)
+			}
+		})
+	}
+}
+
+func TestRun_RepeatWithTLSResumption(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		resume   bool
+		expected []string
+	}{
+		{"resumption on", true, []string{"* TLS session resumed: no", "* TLS session resumed: yes"}},
+		{"resumption off", false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &cli.Options{
+				Target:        server.URL,
+				Proto:         "https",
+				Timeout:       5 * time.Second,
+				Repeat:        2,
+				TLSResumption: tt.resume,
+				Verbose:       true,
+			}
+
+			var exitCode int
+			stdout, stderr := captureOutput(t, func() {
+				exitCode = run(context.Background(), opts)
+			})
+
+			if exitCode != errors.ExitSuccess {
+				t.Fatalf("Expected exit code %d, got %d (stderr: %s)", errors.ExitSuccess, exitCode, stderr)
+			}
+			if strings.Count(stdout, "ok") != 2 {
+				t.Errorf("Expected two responses, got %q", stdout)
+			}
+
+			var reports []string
+			for _, line := range strings.Split(stderr, "\n") {
+				if strings.HasPrefix(line, "* TLS session resumed:") {
+					reports = append(reports, line)
+				}
+			}
+			if strings.Join(reports, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("Expected resumption reports %q, got %q", tt.expected, reports)
+			}
+		})
+	}
+}
+
+func TestRun_SilentShowError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("quiet body"))
+	}))
+	defer server.Close()
+
+	stdout, stderr := captureOutput(t, func() {
+		run(context.Background(), &cli.Options{Target: server.URL, Proto: "http", Timeout: 5 * time.Second, Silent: true})
+	})
+	if stdout != "quiet body" {
+		t.Errorf("Expected only the body on stdout, got %q", stdout)
+	}
+	if stderr != "" {
+		t.Errorf("Expected nothing on stderr, got %q", stderr)
+	}
+
+	// A closed listener gives a refused connection
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	refused := "http://" + listener.Addr().String()
+	listener.Close()
+
+	tests := []struct {
+		name      string
+		showError bool
+		wantError bool
+	}{
+		{"silent hides errors", false, false},
+		{"show-error keeps errors", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &cli.Options{
+				Target:    refused,
+				Proto:     "http",
+				Timeout:   2 * time.Second,
+				Silent:    true,
+				ShowError: tt.showError,
+			}
+
+			var exitCode int
+			_, stderr := captureOutput(t, func() {
+				exitCode = run(context.Background(), opts)
+			})
+
+			if exitCode != errors.ExitConnectFailed {
+				t.Errorf("Expected exit code %d, got %d", errors.ExitConnectFailed, exitCode)
+			}
+			if got := strings.Contains(stderr, "purl:"); got != tt.wantError {
+				t.Errorf("Error printed = %v, want %v (stderr: %q)", got, tt.wantError, stderr)
+			}
+		})
+	}
+}
+
+func TestRun_Fail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte("page body"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+		wantBody bool
+	}{
+		{"4xx fails without body", "/missing", errors.ExitHTTPError, false},
+		{"2xx prints body", "/", errors.ExitSuccess, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &cli.Options{
+				Target:  server.URL + tt.path,
+				Proto:   "http",
+				Timeout: 5 * time.Second,
+				Fail:    true,
+			}
+
+			var exitCode int
+			stdout, stderr := captureOutput(t, func() {
+				exitCode = run(context.Background(), opts)
+			})
+
+			if exitCode != tt.wantCode {
+				t.Errorf("Expected exit code %d, got %d (stderr: %q)", tt.wantCode, exitCode, stderr)
+			}
+			if got := strings.Contains(stdout, "page body"); got != tt.wantBody {
+				t.Errorf("Body printed = %v, want %v (stdout: %q)", got, tt.wantBody, stdout)
+			}
+			if !tt.wantBody && !strings.Contains(stderr, "returned error: 404") {
+				t.Errorf("Expected HTTP error on stderr, got %q", stderr)
+			}
+		})
+	}
+}
+
+func TestRun_FailOnWeakTLSVersion(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("legacy"))
+	}))
+	server.TLS = &tls.Config{MinVersion: tls.VersionTLS10, MaxVersion: tls.VersionTLS11}
+	server.StartTLS()
+	defer server.Close()
+
+	opts := &cli.Options{
+		Target:        server.URL,
+		Proto:         "https",
+		Timeout:       5 * time.Second,
+		FailOnWeakTLS: true,
+	}
+
+	var exitCode int
+	stdout, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+
+	if exitCode != errors.ExitTLSError {
+		t.Errorf("Expected exit code %d, got %d (stderr: %q)", errors.ExitTLSError, exitCode, stderr)
+	}
+	if !strings.Contains(stderr, "weak TLS version TLS 1.1") {
+		t.Errorf("Expected the offending version on stderr, got %q", stderr)
+	}
+	if strings.Contains(stdout, "legacy") {
+		t.Errorf("Body was printed: %q", stdout)
+	}
+}
+
+func TestRun_WriteOut(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{
+		Target:   server.URL,
+		Proto:    "http",
+		Timeout:  5 * time.Second,
+		Silent:   true,
+		WriteOut: `%{http_code} %{size_download}\n`,
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		run(context.Background(), opts)
+	})
+
+	if stdout != "body201 4\n" {
+		t.Errorf("stdout = %q, want the body followed by the write-out line", stdout)
+	}
+}
+
+func TestRun_ProbePath(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{
+		Target:    server.URL + "/api/items",
+		Proto:     "http",
+		Timeout:   5 * time.Second,
+		ProbePath: "/",
+	}
+
+	captureOutput(t, func() {
+		run(context.Background(), opts)
+	})
+
+	want := []string{"HEAD /", "GET /api/items"}
+	if strings.Join(requests, ",") != strings.Join(want, ",") {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
+
+func TestRun_ExplainExit(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// The test server's certificate is not trusted for localhost
+	u, _ := url.Parse(server.URL)
+	tlsTarget := "https://localhost:" + u.Port()
+
+	tests := []struct {
+		name        string
+		explain     bool
+		wantExplain bool
+	}{
+		{"explain-exit prints the code and cause", true, true},
+		{"no explanation by default", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &cli.Options{Target: tlsTarget, Proto: "https", Timeout: 5 * time.Second, ExplainExit: tt.explain}
+
+			var exitCode int
+			_, stderr := captureOutput(t, func() {
+				exitCode = run(context.Background(), opts)
+			})
+
+			if exitCode != errors.ExitTLSError {
+				t.Fatalf("Expected exit code %d, got %d (stderr: %q)", errors.ExitTLSError, exitCode, stderr)
+			}
+			hasExplain := strings.Contains(stderr, "purl: exit 35 (TLS/SSL error): ")
+			if hasExplain != tt.wantExplain {
+				t.Errorf("Explanation printed = %v, want %v (stderr: %q)", hasExplain, tt.wantExplain, stderr)
+			}
+			if tt.wantExplain && !strings.Contains(stderr, "certificate") {
+				t.Errorf("Expected the TLS cause in the explanation, got %q", stderr)
+			}
+		})
+	}
+}
+
+func TestRun_CookieJarAcrossRedirects(t *testing.T) {
+	var homeCookies string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1", Path: "/"})
+			http.Redirect(w, r, "/home", http.StatusFound)
+		case "/home":
+			homeCookies = r.Header.Get("Cookie")
+			w.Write([]byte("welcome"))
+		}
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	jarFile := filepath.Join(t.TempDir(), "cookies.txt")
+	seed := "# Netscape HTTP Cookie File\n" + u.Hostname() + "\tFALSE\t/\tFALSE\t0\tlang\ten\n"
+	if err := os.WriteFile(jarFile, []byte(seed), 0600); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	opts := &cli.Options{
+		Target:     server.URL + "/login",
+		Proto:      "http",
+		Timeout:    5 * time.Second,
+		CookieFile: jarFile,
+		CookieJar:  jarFile,
+	}
+
+	var exitCode int
+	stdout, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+	if exitCode != errors.ExitSuccess {
+		t.Fatalf("Expected exit 0, got %d (stderr: %q)", exitCode, stderr)
+	}
+	if !strings.Contains(stdout, "welcome") {
+		t.Errorf("Expected the redirected body, got %q", stdout)
+	}
+
+	// The loaded cookie and the one set before the redirect both reach /home
+	if !strings.Contains(homeCookies, "lang=en") || !strings.Contains(homeCookies, "session=s1") {
+		t.Errorf("Expected lang and session cookies after the redirect, got %q", homeCookies)
+	}
+
+	saved, err := os.ReadFile(jarFile)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	for _, want := range []string{"\tlang\ten\n", "\tsession\ts1\n"} {
+		if !strings.Contains(string(saved), want) {
+			t.Errorf("Expected %q in the saved jar, got:\n%s", want, saved)
+		}
+	}
+}
+
+func TestRun_Compressed(t *testing.T) {
+	var acceptEncoding string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Encoding", "gzip")
+		gw := gzip.NewWriter(w)
+		gw.Write([]byte("decoded body"))
+		gw.Close()
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{
+		Target:           server.URL,
+		Proto:            "http",
+		Timeout:          5 * time.Second,
+		Compressed:       true,
+		StatusLineStderr: true,
+	}
+
+	var exitCode int
+	stdout, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+	if exitCode != errors.ExitSuccess {
+		t.Fatalf("Expected exit 0, got %d (stderr: %q)", exitCode, stderr)
+	}
+	if acceptEncoding != request.AcceptEncoding {
+		t.Errorf("Accept-Encoding = %q, want %q", acceptEncoding, request.AcceptEncoding)
+	}
+	if stdout != "decoded body" {
+		t.Errorf("Expected the decompressed body on stdout, got %q", stdout)
+	}
+}
+
+func TestRun_MaxLatency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("slow but fine"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		maxLatency time.Duration
+		wantExit   int
+	}{
+		{"slower than the threshold fails", 20 * time.Millisecond, errors.ExitLatencyExceeded},
+		{"within the threshold passes", 5 * time.Second, errors.ExitSuccess},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &cli.Options{Target: server.URL, Proto: "http", Timeout: 5 * time.Second, MaxLatency: tt.maxLatency}
+
+			var exitCode int
+			stdout, stderr := captureOutput(t, func() {
+				exitCode = run(context.Background(), opts)
+			})
+			if exitCode != tt.wantExit {
+				t.Fatalf("Expected exit %d, got %d (stderr: %q)", tt.wantExit, exitCode, stderr)
+			}
+			if !strings.Contains(stdout, "slow but fine") {
+				t.Errorf("Expected the 200 body to be printed, got %q", stdout)
+			}
+			if tt.wantExit == errors.ExitLatencyExceeded && !strings.Contains(stderr, "exceeding --max-latency 20ms") {
+				t.Errorf("Expected actual vs threshold on stderr, got %q", stderr)
+			}
+		})
+	}
+}
+
+func TestRun_RequestIDEcho(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			received = r.Header.Get("X-Request-ID")
+		}
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{Target: server.URL, Proto: "http", Timeout: 5 * time.Second, GenerateRequestID: true}
+
+	var exitCode int
+	_, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+	if exitCode != errors.ExitSuccess {
+		t.Fatalf("Expected exit 0, got %d (stderr: %q)", exitCode, stderr)
+	}
+	if len(received) != 36 {
+		t.Fatalf("Expected a UUID in X-Request-ID, got %q", received)
+	}
+	if !strings.Contains(stderr, "* X-Request-ID: "+received+"\n") {
+		t.Errorf("Expected the sent ID %q echoed on stderr, got %q", received, stderr)
+	}
+}
+
+func TestRun_HTTP2StatusLine(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	tests := []struct {
+		httpVersion string
+		wantStatus  string
+	}{
+		{"2", "[HTTPS/2] Status: 200"},
+		{"1.1", "[HTTPS] Status: 200"},
+	}
+
+	for _, tt := range tests {
+		opts := &cli.Options{Target: server.URL, Proto: "https", Timeout: 5 * time.Second, HTTPVersion: tt.httpVersion}
+
+		var exitCode int
+		stdout, stderr := captureOutput(t, func() {
+			exitCode = run(context.Background(), opts)
+		})
+		if exitCode != errors.ExitSuccess {
+			t.Fatalf("--http%s: expected exit 0, got %d (stderr: %q)", tt.httpVersion, exitCode, stderr)
+		}
+		if !strings.Contains(stdout, tt.wantStatus) {
+			t.Errorf("--http%s: expected %q in output, got %q", tt.httpVersion, tt.wantStatus, stdout)
+		}
+	}
+}
+
+func TestRun_MaxFileSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		w.Write([]byte(strings.Repeat("x", 2048)))
+	}))
+	defer server.Close()
+
+	output := filepath.Join(t.TempDir(), "body")
+	opts := &cli.Options{Target: server.URL, Proto: "http", Timeout: 5 * time.Second, MaxFileSize: 1024, Output: output}
+
+	var exitCode int
+	_, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+	if exitCode != errors.ExitFileSizeExceeded {
+		t.Fatalf("Expected exit %d, got %d (stderr: %q)", errors.ExitFileSizeExceeded, exitCode, stderr)
+	}
+	if !strings.Contains(stderr, "--max-filesize") {
+		t.Errorf("Expected the size limit in the error, got %q", stderr)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("Expected no output file for an aborted download, got %v", err)
+	}
+}
+
+func TestRun_TargetsFileContinuesPastFailures(t *testing.T) {
+	newServer := func() *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	}
+	first, second := newServer(), newServer()
+	defer first.Close()
+	defer second.Close()
+
+	// A port with nothing listening fails to connect
+	closed := newServer()
+	closedAddr := closed.Listener.Addr().String()
+	closed.Close()
+
+	firstAddr := first.Listener.Addr().String()
+	secondAddr := second.Listener.Addr().String()
+	list := strings.Join([]string{firstAddr, closedAddr, secondAddr}, "\n")
+	path := filepath.Join(t.TempDir(), "targets.txt")
+	if err := os.WriteFile(path, []byte(list), 0644); err != nil {
+		t.Fatalf("failed to write targets file: %v", err)
+	}
+
+	opts := &cli.Options{TargetsFile: path, Proto: "http", Timeout: 5 * time.Second}
+
+	var exitCode int
+	stdout, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+	if exitCode != errors.ExitConnectFailed {
+		t.Errorf("Expected exit %d for the failed target, got %d (stderr: %q)", errors.ExitConnectFailed, exitCode, stderr)
+	}
+	for _, addr := range []string{firstAddr, secondAddr} {
+		if !strings.Contains(stdout, addr+" [HTTP] Status: 200") {
+			t.Errorf("Expected a status line for %s, got %q", addr, stdout)
+		}
+	}
+	if strings.Contains(stdout, closedAddr+" [HTTP]") {
+		t.Errorf("Expected no status line for the unreachable target, got %q", stdout)
+	}
+}
+
+func TestRun_ParallelTargetsKeepFileOrder(t *testing.T) {
+	// The first target answers last, so a run that printed as results arrived would reorder the output
+	var probed atomic.Int32
+	newServer := func(delay time.Duration) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				return
+			}
+			time.Sleep(delay)
+			probed.Add(1)
+			io.WriteString(w, "body from "+r.Host)
+		}))
+	}
+	servers := []*httptest.Server{newServer(200 * time.Millisecond), newServer(0), newServer(0), newServer(0)}
+	var addrs []string
+	for _, server := range servers {
+		defer server.Close()
+		addrs = append(addrs, server.Listener.Addr().String())
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "targets.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(addrs, "\n")), 0644); err != nil {
+		t.Fatalf("failed to write targets file: %v", err)
+	}
+
+	opts := &cli.Options{
+		TargetsFile: path,
+		Parallel:    3,
+		Proto:       "http",
+		Timeout:     5 * time.Second,
+		Output:      filepath.Join(dir, "%{target}.out"),
+	}
+
+	var exitCode int
+	stdout, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+	if exitCode != errors.ExitSuccess {
+		t.Fatalf("Expected exit 0, got %d (stderr: %q)", exitCode, stderr)
+	}
+	if got := probed.Load(); got != int32(len(addrs)) {
+		t.Errorf("Expected %d targets requested, got %d", len(addrs), got)
+	}
+
+	last := -1
+	for _, addr := range addrs {
+		i := strings.Index(stdout, addr+" [HTTP] Status: 200")
+		if i < 0 {
+			t.Fatalf("Expected a status line for %s, got %q", addr, stdout)
+		}
+		if i < last {
+			t.Errorf("Expected status lines in file order, got %q", stdout)
+		}
+		last = i
+
+		body, err := os.ReadFile(filepath.Join(dir, targetFileName(addr)+".out"))
+		if err != nil {
+			t.Fatalf("Expected an output file for %s: %v", addr, err)
+		}
+		if string(body) != "body from "+addr {
+			t.Errorf("Expected %s's body in its output file, got %q", addr, body)
+		}
+	}
+}
+
+func TestTargetFileName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"example.com", "example.com"},
+		{"https://a.com:8443/x?y=1", "https___a.com_8443_x_y_1"},
+		{"[::1]:8080", "___1__8080"},
+		{"bücher.de", "b_cher.de"},
+	}
+	for _, tt := range tests {
+		if got := targetFileName(tt.input); got != tt.want {
+			t.Errorf("targetFileName(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRun_TraceASCIIWritesBothDirections(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "pong")
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "trace.txt")
+	opts := &cli.Options{
+		Target:     server.URL + "/ping",
+		Proto:      "http",
+		Timeout:    5 * time.Second,
+		TraceASCII: path,
+	}
+
+	var exitCode int
+	_, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+	if exitCode != errors.ExitSuccess {
+		t.Fatalf("Expected exit 0, got %d (stderr: %q)", exitCode, stderr)
+	}
+
+	trace, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read trace: %v", err)
+	}
+	for _, want := range []string{"=> Send", "GET /ping HTTP/1.1", "<= Recv", "HTTP/1.1 200 OK", "pong"} {
+		if !strings.Contains(string(trace), want) {
+			t.Errorf("Expected %q in the trace, got:\n%s", want, trace)
+		}
+	}
+}
+
+// Run with -race: parallel targets all store extracted values in the shared session
+func TestRun_ParallelTargetsWithExtract(t *testing.T) {
+	var tokens []string
+	var addrs []string
+	for i := range 4 {
+		token := fmt.Sprintf("token-%d", i)
+		tokens = append(tokens, token)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"token": %q}`, token)
+		}))
+		defer server.Close()
+		addrs = append(addrs, server.Listener.Addr().String())
+	}
+
+	var gotAuth string
+	consumer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			gotAuth = r.Header.Get("Authorization")
+		}
+	}))
+	defer consumer.Close()
+
+	path := filepath.Join(t.TempDir(), "targets.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(addrs, "\n")), 0644); err != nil {
+		t.Fatalf("failed to write targets file: %v", err)
+	}
+
+	opts := &cli.Options{
+		TargetsFile: path,
+		Parallel:    4,
+		Proto:       "http",
+		Timeout:     5 * time.Second,
+		Extract:     []cli.ExtractSpec{{Name: "token", Path: "$.token"}},
+		Next: &cli.Options{
+			Target:  consumer.URL,
+			Proto:   "http",
+			Timeout: 5 * time.Second,
+			Use:     []string{"token"},
+			Headers: []string{"Authorization: Bearer {{token}}"},
+		},
+	}
+
+	var exitCode int
+	_, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+	if exitCode != errors.ExitSuccess {
+		t.Fatalf("Expected exit 0, got %d (stderr: %q)", exitCode, stderr)
+	}
+
+	token, _ := strings.CutPrefix(gotAuth, "Bearer ")
+	if !slices.Contains(tokens, token) {
+		t.Errorf("Expected the next request to use one of the extracted tokens, got %q", gotAuth)
+	}
+}
+
+func TestRun_RandomizeHeadersWithCookieJar(t *testing.T) {
+	var cookieLines [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		var lines []string
+		for key, values := range r.Header {
+			if strings.EqualFold(key, "Cookie") {
+				lines = append(lines, values...)
+			}
+		}
+		cookieLines = append(cookieLines, lines)
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	jarFile := filepath.Join(t.TempDir(), "cookies.txt")
+	seed := "# Netscape HTTP Cookie File\n" + u.Hostname() + "\tFALSE\t/\tFALSE\t0\tlang\ten\n"
+	if err := os.WriteFile(jarFile, []byte(seed), 0600); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	// Several seeds, so any re-casing of Cookie would show up as a second line
+	for randomSeed := int64(1); randomSeed <= 6; randomSeed++ {
+		opts := &cli.Options{
+			Target:           server.URL,
+			Proto:            "http",
+			Timeout:          5 * time.Second,
+			Headers:          []string{"Cookie: explicit=1"},
+			CookieFile:       jarFile,
+			RandomizeHeaders: true,
+			RandomizeSeed:    randomSeed,
+		}
+		var exitCode int
+		_, stderr := captureOutput(t, func() {
+			exitCode = run(context.Background(), opts)
+		})
+		if exitCode != errors.ExitSuccess {
+			t.Fatalf("seed %d: expected exit 0, got %d (stderr: %q)", randomSeed, exitCode, stderr)
+		}
+	}
+
+	for i, lines := range cookieLines {
+		if len(lines) != 1 {
+			t.Errorf("request %d: expected one Cookie line, got %q", i, lines)
+			continue
+		}
+		if !strings.Contains(lines[0], "explicit=1") || !strings.Contains(lines[0], "lang=en") {
+			t.Errorf("request %d: expected the explicit and jar cookies together, got %q", i, lines[0])
+		}
+	}
+}
+
+func TestRun_DataFromStdinSentOnEveryRepeat(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+	}))
+	defer server.Close()
+
+	stdin, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatalf("CreateTemp failed: %v", err)
+	}
+	stdin.WriteString("hello\n")
+	stdin.Seek(0, io.SeekStart)
+	original := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = original }()
+
+	opts := &cli.Options{
+		Target:  server.URL,
+		Proto:   "http",
+		Timeout: 5 * time.Second,
+		Data:    "@-",
+		Repeat:  2,
+		Next:    &cli.Options{Target: server.URL, Proto: "http", Timeout: 5 * time.Second, Data: "@-"},
+	}
+
+	var exitCode int
+	_, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+	if exitCode != errors.ExitSuccess {
+		t.Fatalf("Expected exit 0, got %d (stderr: %q)", exitCode, stderr)
+	}
+	if want := []string{"hello", "hello", "hello"}; !slices.Equal(bodies, want) {
+		t.Errorf("Expected stdin sent with every request, got %q", bodies)
+	}
+}
+
+func TestRun_MaxFileSizeCompressed(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		limit    int64
+		wantExit int
+	}{
+		// The gzip stream is longer than the tiny body it decodes to
+		{"encoded length over the limit, decoded within", "tiny", 10, errors.ExitSuccess},
+		{"encoded length within the limit, decoded over", strings.Repeat("x", 2048), 1024, errors.ExitFileSizeExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var encoded bytes.Buffer
+			gw := gzip.NewWriter(&encoded)
+			gw.Write([]byte(tt.body))
+			gw.Close()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodHead {
+					return
+				}
+				w.Header().Set("Content-Encoding", "gzip")
+				w.Header().Set("Content-Length", strconv.Itoa(encoded.Len()))
+				w.Write(encoded.Bytes())
+			}))
+			defer server.Close()
+
+			opts := &cli.Options{
+				Target:           server.URL,
+				Proto:            "http",
+				Timeout:          5 * time.Second,
+				Compressed:       true,
+				MaxFileSize:      tt.limit,
+				StatusLineStderr: true,
+			}
+
+			var exitCode int
+			stdout, stderr := captureOutput(t, func() {
+				exitCode = run(context.Background(), opts)
+			})
+			if exitCode != tt.wantExit {
+				t.Fatalf("Expected exit %d, got %d (stderr: %q)", tt.wantExit, exitCode, stderr)
+			}
+			if tt.wantExit == errors.ExitSuccess && stdout != tt.body {
+				t.Errorf("Expected the decoded body on stdout, got %q", stdout)
+			}
+		})
+	}
+}
+
+func TestRun_WriteOutTimingsWithoutProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	// --default-scheme skips the probe, so the timings must come from the request itself
+	opts := &cli.Options{
+		Target:        strings.TrimPrefix(server.URL, "http://"),
+		DefaultScheme: "http",
+		Timeout:       5 * time.Second,
+		Silent:        true,
+		WriteOut:      `%{time_connect} %{time_starttransfer}`,
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		run(context.Background(), opts)
+	})
+
+	timings := strings.Fields(strings.TrimPrefix(stdout, "body"))
+	if len(timings) != 2 {
+		t.Fatalf("stdout = %q, want the body followed by two timings", stdout)
+	}
+	for _, timing := range timings {
+		if seconds, err := strconv.ParseFloat(timing, 64); err != nil || seconds <= 0 {
+			t.Errorf("stdout = %q, want non-zero timings", stdout)
+		}
+	}
+}
+
+func TestRun_StatusTimeWithoutProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	// --default-scheme skips the probe, so the time must come from the request itself
+	opts := &cli.Options{
+		Target:           strings.TrimPrefix(server.URL, "http://"),
+		DefaultScheme:    "http",
+		Timeout:          5 * time.Second,
+		StatusLineStderr: true,
+	}
+
+	_, stderr := captureOutput(t, func() {
+		run(context.Background(), opts)
+	})
+
+	if !strings.Contains(stderr, "Status: 200 Time: ") || !strings.Contains(stderr, "ms\n") {
+		t.Errorf("Expected a status line with the request's time in milliseconds, got %q", stderr)
+	}
+}
+
+func TestRun_CompressedCorruptBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write([]byte("not gzip at all"))
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{Target: server.URL, Proto: "http", Timeout: 5 * time.Second, Compressed: true}
+
+	var exitCode int
+	_, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+	if exitCode != errors.ExitBadEncoding {
+		t.Fatalf("Expected exit %d for a body that does not decode, got %d (stderr: %q)", errors.ExitBadEncoding, exitCode, stderr)
+	}
+	if !strings.Contains(stderr, "failed to decode gzip response") {
+		t.Errorf("Expected the decode failure on stderr, got %q", stderr)
+	}
+}
+
+func TestRun_ParallelTargetsBoundedLookahead(t *testing.T) {
+	// While the first target stalls, the workers may only run a bounded number of targets ahead of it
+	var ahead, stalled atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		if r.URL.Path == "/0" {
+			time.Sleep(300 * time.Millisecond)
+			stalled.Store(ahead.Load())
+			return
+		}
+		ahead.Add(1)
+	}))
+	defer server.Close()
+
+	var lines []string
+	for i := range 20 {
+		lines = append(lines, fmt.Sprintf("%s/%d", server.URL, i))
+	}
+	path := filepath.Join(t.TempDir(), "targets.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		t.Fatalf("failed to write targets file: %v", err)
+	}
+
+	opts := &cli.Options{
+		TargetsFile: path,
+		Parallel:    2,
+		Proto:       "http",
+		Timeout:     5 * time.Second,
+		Output:      os.DevNull,
+	}
+
+	var exitCode int
+	_, stderr := captureOutput(t, func() {
+		exitCode = run(context.Background(), opts)
+	})
+	if exitCode != errors.ExitSuccess {
+		t.Fatalf("Expected exit 0, got %d (stderr: %q)", exitCode, stderr)
+	}
+	if limit := int32(opts.Parallel*parallelLookahead - 1); stalled.Load() > limit {
+		t.Errorf("Expected at most %d targets run ahead of the stalled one, got %d", limit, stalled.Load())
+	}
+	if got := ahead.Load(); got != int32(len(lines)-1) {
+		t.Errorf("Expected every other target requested, got %d", got)
+	}
+}
+
+// slowWriter stands in for a consumer that reads the output slowly
+type slowWriter struct {
+	delay time.Duration
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(w.delay)
+	return len(p), nil
+}
+
+func TestRun_MaxLatencyIgnoresSlowConsumer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("fast response"))
+	}))
+	defer server.Close()
+
+	var errOut bytes.Buffer
+	sess := &session{
+		vars:       request.Vars{},
+		varsMu:     &sync.Mutex{},
+		budget:     request.NewRetryBudget(0),
+		transports: transport.NewCache(nil),
+		metrics:    output.NewMetrics(),
+		out:        &slowWriter{delay: 200 * time.Millisecond},
+		errOut:     &errOut,
+	}
+	opts := &cli.Options{Target: server.URL, Proto: "http", Timeout: 5 * time.Second, MaxLatency: 100 * time.Millisecond}
+
+	if exitCode := runOne(context.Background(), opts, nil, sess); exitCode != errors.ExitSuccess {
+		t.Fatalf("Expected exit 0 for a fast server behind a slow consumer, got %d (stderr: %q)", exitCode, errOut.String())
+	}
+}
+
+func TestRun_BodyAssertionVerbosity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"OK"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		verbose      bool
+		verboseLevel int
+		want         bool
+	}{
+		{"quiet", false, 0, false},
+		{"-v", true, 0, true},
+		{"--verbose-level 1", false, 1, false},
+		{"--verbose-level 1 overrides -v", true, 1, false},
+		{"--verbose-level 3", false, 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &cli.Options{
+				Target:       server.URL,
+				Proto:        "http",
+				Timeout:      5 * time.Second,
+				BodyRegex:    "OK",
+				Verbose:      tt.verbose,
+				VerboseLevel: tt.verboseLevel,
+			}
+
+			var exitCode int
+			_, stderr := captureOutput(t, func() {
+				exitCode = run(context.Background(), opts)
+			})
+			if exitCode != errors.ExitSuccess {
+				t.Fatalf("Expected exit 0, got %d (stderr: %q)", exitCode, stderr)
+			}
+			if got := strings.Contains(stderr, "* Body assertion passed"); got != tt.want {
+				t.Errorf("Body assertion line printed = %v, want %v (stderr: %q)", got, tt.want, stderr)
+			}
+		})
+	}
+}
@@ -3,8 +3,10 @@ module github.com/aleister1102/purl
 go 1.25.4
 
 require (
+	github.com/andybalholm/brotli v1.2.5
 	github.com/leanovate/gopter v0.2.11
 	github.com/urfave/cli/v2 v2.27.1
+	golang.org/x/text v0.21.0
 )
 
 require (
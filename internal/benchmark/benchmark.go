@@ -0,0 +1,164 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default settings used when the options leave them unset
+const (
+	DefaultConcurrency = 1
+	DefaultDuration    = 10 * time.Second
+)
+
+// RequestFunc builds a fresh request for each iteration
+type RequestFunc func(ctx context.Context) (*http.Request, error)
+
+// Summary holds the aggregated results of a benchmark run
+type Summary struct {
+	Requests    int
+	Errors      int
+	Elapsed     time.Duration
+	P50         time.Duration
+	P90         time.Duration
+	P99         time.Duration
+	Max         time.Duration
+	StatusCodes map[int]int
+}
+
+// RequestsPerSecond returns the completed request rate
+func (s *Summary) RequestsPerSecond() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Requests) / s.Elapsed.Seconds()
+}
+
+// ErrorRate returns the fraction of requests that failed
+func (s *Summary) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Requests)
+}
+
+// Run fires requests from concurrency workers until the duration elapses or ctx is cancelled
+// The client is shared by all workers so keep-alive connections are reused
+func Run(ctx context.Context, client *http.Client, newRequest RequestFunc, concurrency int, duration time.Duration) *Summary {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	if duration <= 0 {
+		duration = DefaultDuration
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		latencies []time.Duration
+	)
+	summary := &Summary{StatusCodes: make(map[int]int)}
+
+	startTime := time.Now()
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				statusCode, latency, err := doRequest(ctx, client, newRequest)
+				// Requests interrupted by the end of the run are not counted
+				if ctx.Err() != nil {
+					return
+				}
+
+				mu.Lock()
+				summary.Requests++
+				if err != nil {
+					summary.Errors++
+				} else {
+					summary.StatusCodes[statusCode]++
+					latencies = append(latencies, latency)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	summary.Elapsed = time.Since(startTime)
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	summary.P50 = percentile(latencies, 50)
+	summary.P90 = percentile(latencies, 90)
+	summary.P99 = percentile(latencies, 99)
+	if len(latencies) > 0 {
+		summary.Max = latencies[len(latencies)-1]
+	}
+
+	return summary
+}
+
+// doRequest executes a single request and drains the body so the connection can be reused
+func doRequest(ctx context.Context, client *http.Client, newRequest RequestFunc) (int, time.Duration, error) {
+	req, err := newRequest(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	startTime := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return 0, 0, err
+	}
+
+	return resp.StatusCode, time.Since(startTime), nil
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Write prints a human-readable summary
+func (s *Summary) Write(w io.Writer) error {
+	codes := make([]int, 0, len(s.StatusCodes))
+	for code := range s.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	fmt.Fprintf(w, "Requests:     %d\n", s.Requests)
+	fmt.Fprintf(w, "Duration:     %s\n", s.Elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "Requests/sec: %.2f\n", s.RequestsPerSecond())
+	fmt.Fprintf(w, "Errors:       %d (%.2f%%)\n", s.Errors, s.ErrorRate()*100)
+	fmt.Fprintf(w, "Latency p50:  %s\n", s.P50.Round(time.Microsecond))
+	fmt.Fprintf(w, "Latency p90:  %s\n", s.P90.Round(time.Microsecond))
+	fmt.Fprintf(w, "Latency p99:  %s\n", s.P99.Round(time.Microsecond))
+	fmt.Fprintf(w, "Latency max:  %s\n", s.Max.Round(time.Microsecond))
+	for _, code := range codes {
+		if _, err := fmt.Fprintf(w, "Status %d:   %d\n", code, s.StatusCodes[code]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
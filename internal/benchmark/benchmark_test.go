@@ -0,0 +1,113 @@
+package benchmark
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun_PopulatesSummary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	newRequest := func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	}
+
+	summary := Run(context.Background(), server.Client(), newRequest, 2, 200*time.Millisecond)
+
+	if summary.Requests == 0 {
+		t.Fatal("expected requests to be recorded")
+	}
+	if summary.Errors != 0 {
+		t.Errorf("expected no errors, got %d", summary.Errors)
+	}
+	if summary.StatusCodes[http.StatusOK] != summary.Requests {
+		t.Errorf("expected %d 200 responses, got %d", summary.Requests, summary.StatusCodes[http.StatusOK])
+	}
+	if summary.RequestsPerSecond() <= 0 {
+		t.Errorf("expected positive requests/sec, got %f", summary.RequestsPerSecond())
+	}
+	if summary.P50 <= 0 || summary.P50 > summary.P99 || summary.P99 > summary.Max {
+		t.Errorf("expected ordered latencies, got p50=%v p99=%v max=%v", summary.P50, summary.P99, summary.Max)
+	}
+	if summary.Elapsed < 200*time.Millisecond {
+		t.Errorf("expected run to last the full duration, got %v", summary.Elapsed)
+	}
+
+	var buf bytes.Buffer
+	if err := summary.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	for _, want := range []string{"Requests:", "Requests/sec:", "Errors:", "Latency p99:", "Status 200:"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("summary output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestRun_StopsOnCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	newRequest := func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	startTime := time.Now()
+	Run(ctx, server.Client(), newRequest, 1, 10*time.Second)
+	if elapsed := time.Since(startTime); elapsed > 2*time.Second {
+		t.Errorf("expected run to stop on cancel, took %v", elapsed)
+	}
+}
+
+func TestRun_CountsErrors(t *testing.T) {
+	newRequest := func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", "http://127.0.0.1:1/", nil)
+	}
+
+	summary := Run(context.Background(), http.DefaultClient, newRequest, 1, 100*time.Millisecond)
+
+	if summary.Requests == 0 || summary.Errors != summary.Requests {
+		t.Errorf("expected all requests to fail, got %d/%d", summary.Errors, summary.Requests)
+	}
+	if summary.ErrorRate() != 1 {
+		t.Errorf("expected error rate 1, got %f", summary.ErrorRate())
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	tests := []struct {
+		p        int
+		expected time.Duration
+	}{
+		{p: 50, expected: 5},
+		{p: 90, expected: 9},
+		{p: 99, expected: 10},
+	}
+
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.expected {
+			t.Errorf("percentile(%d) = %v, want %v", tt.p, got, tt.expected)
+		}
+	}
+
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile of empty slice = %v, want 0", got)
+	}
+}
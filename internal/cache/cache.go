@@ -0,0 +1,226 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Cache is a minimal on-disk HTTP cache for conditional GET requests
+// Entries are keyed by a hash of the request URL and its Accept-Encoding
+type Cache struct {
+	dir string
+}
+
+// Entry is a cached response with the validators used to revalidate it
+type Entry struct {
+	URL          string            `json:"url"`
+	StatusCode   int               `json:"status_code"`
+	Header       http.Header       `json:"header"`
+	ETag         string            `json:"etag,omitempty"`
+	LastModified string            `json:"last_modified,omitempty"`
+	Vary         map[string]string `json:"vary,omitempty"`
+	Body         []byte            `json:"body"`
+}
+
+// New creates a cache rooted at dir, creating the directory if needed
+func New(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create cache dir: %w", err)
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// Do sends a GET request conditionally, serving the cached body on 304 Not Modified
+// Responses carrying an ETag or Last-Modified validator are stored for the next run
+// Requests sent with credentials or cookies and no-store or private responses are not stored
+func (c *Cache) Do(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	if req.Method != http.MethodGet || credentialed(req) {
+		return send(req)
+	}
+
+	key := cacheKey(req)
+	entry, err := c.load(key)
+	if err != nil {
+		return nil, err
+	}
+	// An entry for another variant of the resource is a miss
+	if entry != nil && !entry.matches(req) {
+		entry = nil
+	}
+
+	// Revalidate the cached copy instead of downloading it again
+	if entry != nil {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := send(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		resp.Body.Close()
+		return entry.response(resp.Request), nil
+	}
+
+	// Digest auth and the cookie jar add credentials inside send, so check what went out
+	sent := resp.Request
+	if sent == nil {
+		sent = req
+	}
+
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	if resp.StatusCode != http.StatusOK || (etag == "" && lastModified == "") || !storable(resp.Header) || credentialed(sent) {
+		return resp, nil
+	}
+	vary, ok := varyValues(resp.Header, sent)
+	if !ok {
+		return resp, nil
+	}
+
+	// Buffer the body so it can be both cached and written out
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	err = c.store(key, &Entry{
+		URL:          req.URL.String(),
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header,
+		ETag:         etag,
+		LastModified: lastModified,
+		Vary:         vary,
+		Body:         body,
+	})
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// credentialed reports whether req carries credentials or cookies, making its
+// response specific to one user
+func credentialed(req *http.Request) bool {
+	return req.Header.Get("Authorization") != "" || req.Header.Get("Cookie") != ""
+}
+
+// storable reports whether Cache-Control allows the response to be written to disk
+func storable(header http.Header) bool {
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if strings.EqualFold(name, "no-store") || strings.EqualFold(name, "private") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// varyValues records the request's value for each header the response varies on
+// It reports false for Vary: *, which no later request can be known to match
+func varyValues(header http.Header, req *http.Request) (map[string]string, bool) {
+	var values map[string]string
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if name == "*" {
+				return nil, false
+			}
+			if values == nil {
+				values = map[string]string{}
+			}
+			values[name] = req.Header.Get(name)
+		}
+	}
+	return values, true
+}
+
+// matches reports whether req sends the same Vary'd headers the entry was stored for
+func (e *Entry) matches(req *http.Request) bool {
+	for name, value := range e.Vary {
+		if req.Header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// cacheKey identifies the stored variant for req: a --compressed run asks for
+// an encoded body, which must not be served to a run that expects it decoded
+func cacheKey(req *http.Request) string {
+	return req.URL.String() + "\n" + req.Header.Get("Accept-Encoding")
+}
+
+// path returns the file holding the entry for key
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// load reads the entry for key, returning nil when nothing is cached
+func (c *Cache) load(key string) (*Entry, error) {
+	data, err := os.ReadFile(c.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		// A corrupt entry is treated as a miss and overwritten
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// store writes the entry for key to disk, readable only by the current user
+func (c *Cache) store(key string, entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	if err := os.WriteFile(c.path(key), data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// response rebuilds the cached response for req
+func (e *Entry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,300 @@
+package cache
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestCacheDo_ServesCachedBodyOn304(t *testing.T) {
+	var hits int
+	var conditional string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		conditional = r.Header.Get("If-None-Match")
+		if conditional == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("static content"))
+	}))
+	defer server.Close()
+
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	fetch := func() string {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/asset.js", nil)
+		resp, err := c.Do(req, server.Client().Do)
+		if err != nil {
+			t.Fatalf("Do failed: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	if body := fetch(); body != "static content" {
+		t.Errorf("First run: expected body from server, got %q", body)
+	}
+	if conditional != "" {
+		t.Errorf("First run should not be conditional, sent If-None-Match %q", conditional)
+	}
+
+	if body := fetch(); body != "static content" {
+		t.Errorf("Second run: expected cached body, got %q", body)
+	}
+	if conditional != `"v1"` {
+		t.Errorf("Second run: expected If-None-Match \"v1\", got %q", conditional)
+	}
+	if hits != 2 {
+		t.Errorf("Expected 2 server hits, got %d", hits)
+	}
+}
+
+func TestCacheDo_LastModified(t *testing.T) {
+	const lastModified = "Mon, 01 Jan 2024 00:00:00 GMT"
+	var conditional string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = r.Header.Get("If-Modified-Since")
+		if conditional == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write([]byte("dated"))
+	}))
+	defer server.Close()
+
+	c, _ := New(t.TempDir())
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		resp, err := c.Do(req, server.Client().Do)
+		if err != nil {
+			t.Fatalf("Do failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "dated" {
+			t.Errorf("Run %d: expected body %q, got %q", i+1, "dated", string(body))
+		}
+	}
+
+	if conditional != lastModified {
+		t.Errorf("Expected If-Modified-Since %q, got %q", lastModified, conditional)
+	}
+}
+
+func TestCacheDo_SkipsUncacheable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("no validators"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	c, _ := New(dir)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := c.Do(req, server.Client().Do)
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	resp.Body.Close()
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("Expected no cache entries without validators, got %d", len(entries))
+	}
+}
+
+func TestCacheDo_RespectsPrivacy(t *testing.T) {
+	tests := []struct {
+		name          string
+		cacheControl  string
+		authorization string
+	}{
+		{name: "no-store", cacheControl: "no-store"},
+		{name: "private", cacheControl: "max-age=60, private"},
+		{name: "private with field names", cacheControl: `private="Set-Cookie"`},
+		{name: "authorization", authorization: "Bearer secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var conditional string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conditional = r.Header.Get("If-None-Match")
+				w.Header().Set("ETag", `"v1"`)
+				if tt.cacheControl != "" {
+					w.Header().Set("Cache-Control", tt.cacheControl)
+				}
+				w.Write([]byte("personal"))
+			}))
+			defer server.Close()
+
+			dir := t.TempDir()
+			c, _ := New(dir)
+			for i := 0; i < 2; i++ {
+				req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+				if tt.authorization != "" {
+					req.Header.Set("Authorization", tt.authorization)
+				}
+				resp, err := c.Do(req, server.Client().Do)
+				if err != nil {
+					t.Fatalf("Do failed: %v", err)
+				}
+				resp.Body.Close()
+			}
+
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != 0 {
+				t.Errorf("Expected no cache entries, got %d", len(entries))
+			}
+			if conditional != "" {
+				t.Errorf("Expected no revalidation, sent If-None-Match %q", conditional)
+			}
+		})
+	}
+}
+
+func TestCacheDo_EntryPermissions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("static content"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	c, _ := New(dir)
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := c.Do(req, server.Client().Do)
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	resp.Body.Close()
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 cache entry, got %d", len(entries))
+	}
+	info, err := entries[0].Info()
+	if err != nil {
+		t.Fatalf("Info failed: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("Expected entry mode 0600, got %o", perm)
+	}
+}
+
+func TestCacheDo_ChecksSentRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("for " + r.Header.Get("Authorization") + r.Header.Get("Cookie")))
+	}))
+	defer server.Close()
+
+	// Digest auth and the cookie jar set these inside send, after Do looked at req
+	tests := []struct {
+		name   string
+		header string
+		value  string
+	}{
+		{name: "digest credentials", header: "Authorization", value: `Digest username="alice"`},
+		{name: "jar cookie", header: "Cookie", value: "session=s1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			send := func(req *http.Request) (*http.Response, error) {
+				sent := req.Clone(req.Context())
+				sent.Header.Set(tt.header, tt.value)
+				return server.Client().Do(sent)
+			}
+
+			dir := t.TempDir()
+			c, _ := New(dir)
+			req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+			resp, err := c.Do(req, send)
+			if err != nil {
+				t.Fatalf("Do failed: %v", err)
+			}
+			resp.Body.Close()
+
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != 0 {
+				t.Errorf("Expected no cache entries, got %d", len(entries))
+			}
+		})
+	}
+}
+
+func TestCacheDo_Variants(t *testing.T) {
+	tests := []struct {
+		name   string
+		vary   string
+		header string
+		first  string
+		second string
+	}{
+		{name: "Accept-Encoding is part of the key", header: "Accept-Encoding", first: "gzip", second: "identity"},
+		{name: "Vary'd header", vary: "Accept-Language", header: "Accept-Language", first: "en", second: "fr"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var conditional string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conditional = r.Header.Get("If-None-Match")
+				if conditional != "" {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("ETag", `"v1"`)
+				if tt.vary != "" {
+					w.Header().Set("Vary", tt.vary)
+				}
+				w.Write([]byte("variant " + r.Header.Get(tt.header)))
+			}))
+			defer server.Close()
+
+			c, _ := New(t.TempDir())
+			fetch := func(value string) string {
+				req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+				if value != "" {
+					req.Header.Set(tt.header, value)
+				}
+				resp, err := c.Do(req, server.Client().Do)
+				if err != nil {
+					t.Fatalf("Do failed: %v", err)
+				}
+				defer resp.Body.Close()
+				body, _ := io.ReadAll(resp.Body)
+				return string(body)
+			}
+
+			fetch(tt.first)
+			if body := fetch(tt.second); body != "variant "+tt.second {
+				t.Errorf("Expected the %q variant from the server, got %q", tt.second, body)
+			}
+			if conditional != "" {
+				t.Errorf("Expected no revalidation of another variant, sent If-None-Match %q", conditional)
+			}
+
+			// The same variant is still revalidated and served from the cache
+			if body := fetch(tt.second); body != "variant "+tt.second {
+				t.Errorf("Expected the cached %q variant, got %q", tt.second, body)
+			}
+			if conditional != `"v1"` {
+				t.Errorf("Expected revalidation of the same variant, got If-None-Match %q", conditional)
+			}
+		})
+	}
+}
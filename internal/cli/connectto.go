@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ConnectTo is a parsed --connect-to entry
+// An empty Host or Port matches any value; an empty ToHost or ToPort keeps the original
+type ConnectTo struct {
+	Host, Port     string // connection to redirect, Host lowercased
+	ToHost, ToPort string // where to connect instead
+}
+
+// ParseConnectTo parses a curl-style --connect-to entry HOST1:PORT1:HOST2:PORT2
+// IPv6 hosts must be bracketed, e.g. example.com:443:[::1]:8443
+func ParseConnectTo(spec string) (ConnectTo, error) {
+	fields, err := splitConnectTo(spec)
+	if err != nil {
+		return ConnectTo{}, err
+	}
+	if len(fields) != 4 {
+		return ConnectTo{}, fmt.Errorf("expected HOST1:PORT1:HOST2:PORT2, got %q", spec)
+	}
+
+	for _, port := range []string{fields[1], fields[3]} {
+		if port == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return ConnectTo{}, fmt.Errorf("invalid port %q in %q", port, spec)
+		}
+	}
+	if fields[2] == "" && fields[3] == "" {
+		return ConnectTo{}, fmt.Errorf("no host or port to connect to in %q", spec)
+	}
+
+	return ConnectTo{
+		Host:   strings.ToLower(fields[0]),
+		Port:   fields[1],
+		ToHost: fields[2],
+		ToPort: fields[3],
+	}, nil
+}
+
+// splitConnectTo splits spec on colons outside brackets, unbracketing IPv6 hosts
+func splitConnectTo(spec string) ([]string, error) {
+	var fields []string
+	for {
+		if strings.HasPrefix(spec, "[") {
+			end := strings.Index(spec, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated '[' in %q", spec)
+			}
+			host, rest := spec[1:end], spec[end+1:]
+			if rest != "" && !strings.HasPrefix(rest, ":") {
+				return nil, fmt.Errorf("expected ':' after ']' in %q", spec)
+			}
+			fields = append(fields, host)
+			if rest == "" {
+				return fields, nil
+			}
+			spec = rest[1:]
+			continue
+		}
+
+		field, rest, found := strings.Cut(spec, ":")
+		fields = append(fields, field)
+		if !found {
+			return fields, nil
+		}
+		spec = rest
+	}
+}
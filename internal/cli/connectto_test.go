@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"testing"
+)
+
+func TestParseConnectTo(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    ConnectTo
+		wantErr bool
+	}{
+		{"example.com:443:127.0.0.1:8443", ConnectTo{"example.com", "443", "127.0.0.1", "8443"}, false},
+		{"Example.COM:80:backend:", ConnectTo{"example.com", "80", "backend", ""}, false},
+		{"::other.example:", ConnectTo{"", "", "other.example", ""}, false},
+		{"example.com:443:[::1]:8443", ConnectTo{"example.com", "443", "::1", "8443"}, false},
+		{"[2001:db8::1]:443:backend:443", ConnectTo{"2001:db8::1", "443", "backend", "443"}, false},
+		{"example.com:443:127.0.0.1", ConnectTo{}, true},
+		{"example.com:443:127.0.0.1:8443:9", ConnectTo{}, true},
+		{"example.com:https:127.0.0.1:443", ConnectTo{}, true},
+		{"example.com:443:127.0.0.1:70000", ConnectTo{}, true},
+		{"example.com:443::", ConnectTo{}, true},
+		{"example.com:443:[::1:8443", ConnectTo{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseConnectTo(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseConnectTo(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseConnectTo(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
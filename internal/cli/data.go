@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// dataSerializedPrefix marks the value urfave/cli copies between a flag's aliases
+const dataSerializedPrefix = "purl-data:"
+
+// dataValues collects repeated -d/--data values, joined with & like curl
+// A plain string slice flag would also split a single body on its commas
+type dataValues []string
+
+// Set appends one -d occurrence, or restores the values copied from an alias
+func (d *dataValues) Set(value string) error {
+	if serialized, ok := strings.CutPrefix(value, dataSerializedPrefix); ok {
+		return json.Unmarshal([]byte(serialized), (*[]string)(d))
+	}
+	*d = append(*d, value)
+	return nil
+}
+
+// String returns the values joined into one body
+func (d *dataValues) String() string {
+	if d == nil {
+		return ""
+	}
+	return strings.Join(*d, "&")
+}
+
+// Serialize encodes the values so copying them to an alias does not append them twice
+func (d *dataValues) Serialize() string {
+	encoded, _ := json.Marshal([]string(*d))
+	return dataSerializedPrefix + string(encoded)
+}
@@ -0,0 +1,24 @@
+package cli
+
+import "testing"
+
+func TestDataValues(t *testing.T) {
+	var d dataValues
+	for _, v := range []string{"q=test", `{"a":1,"b":2}`} {
+		if err := d.Set(v); err != nil {
+			t.Fatalf("Set(%q) failed: %v", v, err)
+		}
+	}
+	if got, want := d.String(), `q=test&{"a":1,"b":2}`; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	// Copying to an alias restores the values instead of appending them
+	var alias dataValues
+	if err := alias.Set(d.Serialize()); err != nil {
+		t.Fatalf("Set(serialized) failed: %v", err)
+	}
+	if alias.String() != d.String() {
+		t.Errorf("alias = %q, want %q", alias.String(), d.String())
+	}
+}
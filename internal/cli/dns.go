@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// defaultDNSPort is used for --dns-servers entries without a port
+const defaultDNSPort = "53"
+
+// ParseDNSServers parses a comma-separated list of DNS server IPs, each with an optional port
+// Returns host:port addresses ready to dial
+func ParseDNSServers(list string) ([]string, error) {
+	var servers []string
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		host, port := entry, defaultDNSPort
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			host, port = h, p
+		}
+		if net.ParseIP(host) == nil {
+			return nil, fmt.Errorf("%q is not an IP address", entry)
+		}
+		servers = append(servers, net.JoinHostPort(host, port))
+	}
+
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("no servers given")
+	}
+	return servers, nil
+}
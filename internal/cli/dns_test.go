@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDNSServers(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{"default port", "8.8.8.8,1.1.1.1", []string{"8.8.8.8:53", "1.1.1.1:53"}, false},
+		{"explicit port", "127.0.0.1:5353", []string{"127.0.0.1:5353"}, false},
+		{"ipv6", "2001:4860:4860::8888, [::1]:5353", []string{"[2001:4860:4860::8888]:53", "[::1]:5353"}, false},
+		{"hostname rejected", "dns.google", nil, true},
+		{"empty list", " , ", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDNSServers(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDNSServers(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDNSServers(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
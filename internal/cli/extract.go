@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExtractSpec names a value extracted from a JSON response via a JSONPath
+type ExtractSpec struct {
+	Name string
+	Path string
+}
+
+// ParseExtractSpec parses a "name=$.json.path" extraction specification
+func ParseExtractSpec(spec string) (ExtractSpec, error) {
+	name, path, found := strings.Cut(spec, "=")
+	name = strings.TrimSpace(name)
+	path = strings.TrimSpace(path)
+	if !found || name == "" {
+		return ExtractSpec{}, fmt.Errorf("expected name=$.path, got %q", spec)
+	}
+	if !strings.HasPrefix(path, "$") {
+		return ExtractSpec{}, fmt.Errorf("JSONPath must start with $: %q", path)
+	}
+	return ExtractSpec{Name: name, Path: path}, nil
+}
@@ -0,0 +1,28 @@
+package cli
+
+import "testing"
+
+func TestParseExtractSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    ExtractSpec
+		wantErr bool
+	}{
+		{"token=$.data.token", ExtractSpec{Name: "token", Path: "$.data.token"}, false},
+		{" id = $.items[0].id ", ExtractSpec{Name: "id", Path: "$.items[0].id"}, false},
+		{"token", ExtractSpec{}, true},
+		{"=$.token", ExtractSpec{}, true},
+		{"token=data.token", ExtractSpec{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseExtractSpec(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseExtractSpec(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseExtractSpec(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
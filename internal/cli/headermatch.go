@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// HeaderMatch matches a response header by name and, optionally, value
+type HeaderMatch struct {
+	Name  string
+	Value string // empty matches any value
+}
+
+// ParseHeaderMatch parses a "Name: value" or bare "Name" header match
+func ParseHeaderMatch(spec string) (HeaderMatch, error) {
+	name, value, _ := strings.Cut(spec, ":")
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return HeaderMatch{}, fmt.Errorf("expected 'Name: value', got %q", spec)
+	}
+	return HeaderMatch{Name: name, Value: strings.TrimSpace(value)}, nil
+}
+
+// Matches reports whether header contains the named header with a matching value
+// Values compare case-insensitively
+func (m HeaderMatch) Matches(header http.Header) bool {
+	for key, values := range header {
+		if !strings.EqualFold(key, m.Name) {
+			continue
+		}
+		if m.Value == "" {
+			return true
+		}
+		for _, value := range values {
+			if strings.EqualFold(strings.TrimSpace(value), m.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseHeaderMatch(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    HeaderMatch
+		wantErr bool
+	}{
+		{"X-Cache: HIT", HeaderMatch{Name: "X-Cache", Value: "HIT"}, false},
+		{" Content-Type :text/html ", HeaderMatch{Name: "Content-Type", Value: "text/html"}, false},
+		{"X-Debug", HeaderMatch{Name: "X-Debug"}, false},
+		{": value", HeaderMatch{}, true},
+		{"Bad Name: value", HeaderMatch{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseHeaderMatch(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseHeaderMatch(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseHeaderMatch(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestHeaderMatchMatches(t *testing.T) {
+	header := http.Header{
+		"X-Cache":      []string{"hit"},
+		"Content-Type": []string{"text/html"},
+	}
+
+	tests := []struct {
+		match HeaderMatch
+		want  bool
+	}{
+		{HeaderMatch{Name: "X-Cache", Value: "HIT"}, true},
+		{HeaderMatch{Name: "x-cache"}, true},
+		{HeaderMatch{Name: "X-Cache", Value: "MISS"}, false},
+		{HeaderMatch{Name: "X-Debug"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.match.Matches(header); got != tt.want {
+			t.Errorf("%+v.Matches() = %v, want %v", tt.match, got, tt.want)
+		}
+	}
+}
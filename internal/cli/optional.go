@@ -0,0 +1,25 @@
+package cli
+
+// optionalValue is a string flag whose value may be omitted: --flag or --flag=value
+// The flag package treats it as boolean, so a bare --flag sets "true"
+// A value must be attached with '='; "--flag value" leaves value as an argument
+type optionalValue string
+
+// Set records the attached value, or "true" for a bare flag
+func (o *optionalValue) Set(value string) error {
+	*o = optionalValue(value)
+	return nil
+}
+
+// String returns the recorded value
+func (o *optionalValue) String() string {
+	if o == nil {
+		return ""
+	}
+	return string(*o)
+}
+
+// IsBoolFlag lets the flag appear without a value
+func (o *optionalValue) IsBoolFlag() bool {
+	return true
+}
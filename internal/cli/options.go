@@ -7,32 +7,170 @@ type Options struct {
 	// Target
 	Target string
 	Proto  string // "auto", "http", "https"
+	Path   string // path replacing the target's own, empty keeps it
+
+	// Protocol detection
+	ProbeAcceptStatus StatusSpec    // statuses accepted from the HTTP probe in auto mode
+	ProbeRetries      int           // retries per protocol probe on errors or 5xx before moving on
+	DefaultScheme     string        // scheme for scheme-less targets, skipping detection ("http", "https"); empty probes
+	ProbePath         string        // path the protocol probe requests instead of the target's, empty uses the target's
+	ProbeMethod       string        // method of the protocol probe, empty means HEAD (falling back to GET on 405)
+	ProbeOrder        string        // "http-first" or "https-first", empty means http-first
+	ParallelProbe     bool          // probe HTTP and HTTPS at once in auto mode, taking the first to succeed
+	ProbeTimeout      time.Duration // timeout for each auto-mode probe, 0 uses 3s for HTTP and 7s for HTTPS
 
 	// Request
-	Method   string
-	Headers  []string
-	Data     string
-	DataRaw  string
-	User     string // user:password
-	Cookie   string
-	UserAgent string
-	Referer  string
+	Method        string
+	Headers       []string
+	HeaderEnv     []string // "Name=ENV_VAR" headers whose values are read from the environment
+	Query         []string // "key=value" params appended to every target's query string
+	Data          string
+	DataRaw       string
+	DataURLEncode []string // --data-urlencode values, "content" or "name=content" with content encoded
+	Get           bool     // send the data as query parameters of a GET instead of a POST body (-G)
+	DataFile      string   // file the body was read from (--data-file-list)
+	User          string   // user:password
+	Cookie        string
+	CookieFile    string // Netscape cookie file loaded into the session's cookie jar (-b FILE)
+	UserAgent     string
+	Referer       string
+
+	Digest         bool   // use HTTP Digest instead of Basic auth for --user
+	DigestState    string // file persisting the Digest challenge between runs
+	PreemptiveAuth bool   // answer a stored Digest challenge on the first request
+
+	RandomizeHeaders bool  // vary User-Agent, Accept and header order per request
+	RandomizeSeed    int64 // seed for --randomize-headers, 0 seeds from the clock
+
+	RequestID         string // X-Request-ID value, echoed to stderr
+	GenerateRequestID bool   // send a random UUID as X-Request-ID instead of RequestID
+	Traceparent       bool   // send a random W3C traceparent header, echoed to stderr
+
+	NoHostHeader  bool // strip the Host header from the request
+	RawHeaderCase bool // send -H names exactly as typed instead of canonicalizing them
+
+	MaxRedirs         int           // maximum redirects to follow, 0 means DefaultMaxRedirs
+	FollowMetaRefresh bool          // follow Refresh headers and HTML meta-refresh tags on 200 responses
+	FailIfRedirect    bool          // treat any 3xx response as a failure instead of following it
+	Fail              bool          // exit 22 without printing the body on a 4xx/5xx response
+	AbortOnHeader     []HeaderMatch // response headers that abort the transfer before the body is read
+
+	CompressRequest bool // gzip the request body
+	CompressLevel   int  // gzip level 1-9, 0 means default compression
+	Compressed      bool // request a gzip/deflate/br response and decode it before output
 
 	// Output
-	Verbose    bool
-	VerboseTLS bool
-	Output     string
-	Head       bool
-	JSON       bool
+	Verbose       bool
+	VerboseLevel  int // 1: request/response lines, 2: adds headers, 3: adds timing and connection details
+	VerboseTLS    bool
+	NoRedact      bool     // show Authorization, Cookie and other secret header values in verbose output
+	RedactHeaders []string // header names masked in verbose output besides the built-in ones
+	Output        string
+	Head          bool // send HEAD and print the response status line and headers to stdout
+	Include       bool // print the response status line and headers to stdout before the body
+	Silent        bool // suppress the status line, progress output and error messages
+	ShowError     bool // with Silent, still print error messages
+	JSON          bool
+	JSONStrict    bool // with --json, reject request bodies that are not valid JSON
+
+	StatusFormat      string // status line template with {proto}, {code}, {time} placeholders
+	WriteOut          string // -w format with %{variable} placeholders printed after the response
+	StatusLineStderr  bool   // print the status line to stderr so stdout carries only the body
+	BodyRegex         string // fail unless the body matches this pattern
+	BodyRegexAbsent   bool   // invert --body-regex: fail if the body matches
+	HeadBodyCheck     bool   // send a HEAD first and verify its Content-Length against the body
+	ShowDNS           bool   // print the target's resolved addresses and the one used to stderr
+	SummaryJSON       bool   // print a one-line JSON summary of the request to stderr
+	OutputFormat      string // "text" or "json" for the status line, empty means text
+	IncludeBody       bool   // with OutputFormat json, put the body in the JSON object instead of streaming it
+	JSONLines         bool   // stream the body as one JSON object per chunk (or SSE event)
+	TranscodeUTF8     bool   // decode non-UTF-8 bodies using the Content-Type charset
+	SSE               bool   // treat the body as a Server-Sent Events stream
+	PrettyJSON        bool   // indent JSON bodies written to stdout
+	AssumeContentType string // media type used instead of the response's Content-Type when processing the body
+	Prometheus        string // file to write Prometheus metrics to after the run
+	TraceConfig       bool   // print the resolved options as JSON to stderr before running
+	Trace             string // file for a hex and ASCII dump of every byte sent and received, "-" is stderr
+	TraceASCII        string // like Trace, but the dump shows text lines only
+	CacheDir          string // directory caching GET responses for conditional revalidation
+	SaveRequest       string // file to write the built request to in .http format
+	CookieJar         string // Netscape cookie file written with the session's cookies after each response (-c)
+	ExplainExit       bool   // on a non-zero exit, print what the exit code means and why to stderr
+
+	// Multi-request
+	Extract []ExtractSpec // values to extract from the JSON response
+	Use     []string      // extracted values substituted into {{name}} placeholders
+	Next    *Options      // request to run after this one (--next)
+
+	ReplayFromHAR  string // HAR file whose entries replace the target as the requests to send
+	OutputHAR      string // HAR file recording every request/response of the run
+	DataFileList   string // file listing paths to POST one request per file
+	TargetsFile    string // file listing one target per line to send the request to, "-" reads stdin
+	WeightedSample int    // draw this many targets from TargetsFile by weight, 0 uses every target once
+	SampleSeed     int64  // seed for WeightedSample, 0 uses the current time
+	Parallel       int    // TargetsFile targets requested at once, output still in file order; 0 or 1 is sequential
+
+	MaxTotalTargets int // cap on requests after expansion, 0 means DefaultMaxTotalTargets
+	Repeat          int // times to send each request, 0 means once
+
+	// Retry
+	Retry          int           // number of retries for transient failures
+	RetryBudget    int           // total retries allowed across the whole invocation, 0 means unlimited
+	RetryConnReset bool          // retry connection resets even without --retry
+	RetryMethods   []string      // methods retried besides GET, HEAD, OPTIONS and TRACE (e.g. POST)
+	RetryAllErrors bool          // retry any method and any 4xx/5xx response
+	RetryDelay     time.Duration // wait before the first retry, doubled for each later one; 0 means 1s
+	RetryMaxTime   time.Duration // no retry starts once this much time has passed, 0 means no limit
+
+	// TCP
+	TCPNagle bool // leave Nagle's algorithm on instead of setting TCP_NODELAY
+
+	// DNS
+	DNSServers []string // resolvers (host:port) used instead of the system configuration
+	DNSStrict  bool     // fail instead of falling back to system DNS when DNSServers fail
+	Resolve    []string // "host:port:address" entries dialed at address instead of resolving host
+	ConnectTo  []string // "host1:port1:host2:port2" entries connecting to host2:port2 for host1:port1
+
+	// Proxy
+	Proxy      string   // proxy URL (http://, https://, socks5://), empty uses HTTP_PROXY/HTTPS_PROXY
+	ProxyChain []string // proxy URLs traversed in order (http://, socks5://)
 
 	// TLS
 	Insecure  bool
-	CACert    string
+	CACerts   []string // CA certificate files, all trusted
 	Cert      string
 	Key       string
 	StrictSSL bool
 
+	ExpectTLSVersion   uint16   // fail unless this TLS version is negotiated, 0 means any
+	SkipHostnameVerify bool     // verify the certificate chain but not the hostname
+	TLSResumption      bool     // resume TLS sessions across requests of the run
+	TLSEarlyData       bool     // request TLS 1.3 0-RTT on resumed sessions (implies TLSResumption)
+	FailOnWeakTLS      bool     // fail if the response came over TLS < 1.2 or a weak cipher suite
+	WeakCiphers        []string // cipher suite names treated as weak, empty uses the insecure suites
+	Ciphers            string   // colon- or comma-separated cipher suite names to offer, empty uses Go's default
+	TLSMin             string   // lowest TLS version to negotiate (1.0-1.3), empty uses Go's default
+	TLSMax             string   // highest TLS version to negotiate (1.0-1.3), empty uses Go's default
+
+	// HTTP/2
+	HTTPVersion     string // "2" offers HTTP/2 over TLS, "1.1" refuses it, empty keeps Go's default
+	H2InitialWindow int    // initial per-stream flow-control window in bytes, 0 uses Go's default
+
 	// Timeouts
-	Timeout        time.Duration
-	ConnectTimeout time.Duration
+	Timeout             time.Duration
+	ConnectTimeout      time.Duration
+	TLSHandshakeTimeout time.Duration // defaults to ConnectTimeout when unset
+	Deadline            time.Time     // absolute time the run must finish by, zero means none
+	MaxLatency          time.Duration // fail if the response headers take longer, 0 means no limit
+	MaxFileSize         int64         // fail if the response body is larger than this many bytes, 0 means no limit
+
+	// Connect-only
+	ConnectOnly          bool          // open the TCP/TLS connection and exit without a request
+	ConnectRetryCount    int           // extra connection attempts for --connect-only
+	ConnectRetryInterval time.Duration // wait between attempts, 0 means DefaultConnectRetryInterval
+
+	// Benchmark
+	Benchmark   bool
+	Concurrency int
+	Duration    time.Duration
 }
@@ -1,7 +1,11 @@
 package cli
 
 import (
+	"crypto/tls"
 	"fmt"
+	"mime"
+	"os"
+	"regexp"
 	"strings"
 	"time"
 
@@ -9,8 +13,36 @@ import (
 	"github.com/aleister1102/purl/internal/errors"
 )
 
+// nextSeparator splits the command line into consecutive requests
+const nextSeparator = "--next"
+
+// maxH2Window is the largest flow-control window HTTP/2 allows (2^31-1)
+const maxH2Window = 1<<31 - 1
+
 // ParseArgs parses command-line arguments and returns Options
+// Requests separated by --next are chained through Options.Next
 func ParseArgs(args []string) (*Options, error) {
+	for i := 1; i < len(args); i++ {
+		if args[i] != nextSeparator {
+			continue
+		}
+
+		opts, err := parseRequestArgs(args[:i])
+		if err != nil {
+			return nil, err
+		}
+		opts.Next, err = ParseArgs(append([]string{args[0]}, args[i+1:]...))
+		if err != nil {
+			return nil, err
+		}
+		return opts, nil
+	}
+
+	return parseRequestArgs(args)
+}
+
+// parseRequestArgs parses the arguments of a single request
+func parseRequestArgs(args []string) (*Options, error) {
 	opts := &Options{
 		Proto:   "auto",
 		Timeout: 10 * time.Second,
@@ -20,9 +52,12 @@ func ParseArgs(args []string) (*Options, error) {
 		Name:  "purl",
 		Usage: "curl-compatible HTTP probe with auto protocol detection",
 		Flags: buildFlags(),
+		// Combined short flags such as -sS
+		UseShortOptionHandling: true,
 		Action: func(c *cli.Context) error {
 			// Extract target from positional arguments
-			if c.NArg() == 0 {
+			// A HAR replay or a targets file supplies its own targets
+			if c.NArg() == 0 && !c.IsSet("replay-from-har") && !c.IsSet("targets-file") {
 				return fmt.Errorf("target URL required")
 			}
 			opts.Target = c.Args().Get(0)
@@ -56,6 +91,11 @@ func buildFlags() []cli.Flag {
 			Aliases: []string{"X"},
 			Usage:   "Specify request method (GET, POST, etc.)",
 		},
+		&cli.StringFlag{
+			Name:    "path",
+			Aliases: []string{"replace-path"},
+			Usage:   "Replace the path of every target (e.g., /health)",
+		},
 
 		// Headers
 		&cli.StringSliceFlag{
@@ -65,15 +105,46 @@ func buildFlags() []cli.Flag {
 		},
 
 		// Data/Body
-		&cli.StringFlag{
+		&cli.GenericFlag{
 			Name:    "data",
 			Aliases: []string{"d"},
-			Usage:   "HTTP POST data",
+			Usage:   "HTTP POST data (@file reads it from a file, @- from stdin); repeated values are joined with &",
+			Value:   &dataValues{},
+		},
+		&cli.StringSliceFlag{
+			Name:  "data-urlencode",
+			Usage: "HTTP POST data URL-encoded: 'content' or 'name=content' (can be repeated)",
+		},
+		&cli.BoolFlag{
+			Name:    "get",
+			Aliases: []string{"G"},
+			Usage:   "Send -d/--data-urlencode data as query parameters of a GET request",
 		},
 		&cli.StringFlag{
 			Name:  "data-raw",
 			Usage: "HTTP POST data without special character interpretation",
 		},
+		&cli.GenericFlag{
+			Name:  "request-id",
+			Usage: "Send an X-Request-ID header and echo it to stderr: --request-id for a random UUID, --request-id=VALUE for a fixed one",
+			Value: new(optionalValue),
+		},
+		&cli.BoolFlag{
+			Name:  "traceparent",
+			Usage: "Send a random W3C traceparent header and echo it to stderr",
+		},
+		&cli.BoolFlag{
+			Name:  "compressed",
+			Usage: "Request a compressed response (gzip, deflate, br) and decompress it",
+		},
+		&cli.BoolFlag{
+			Name:  "compress-request",
+			Usage: "Gzip the request body and set Content-Encoding: gzip",
+		},
+		&cli.IntFlag{
+			Name:  "compress-level",
+			Usage: "Gzip compression level for --compress-request (1-9)",
+		},
 
 		// Authentication
 		&cli.StringFlag{
@@ -81,11 +152,32 @@ func buildFlags() []cli.Flag {
 			Aliases: []string{"u"},
 			Usage:   "Server user and password (user:password)",
 		},
+		&cli.BoolFlag{
+			Name:  "digest",
+			Usage: "Use HTTP Digest authentication with --user",
+		},
+		&cli.StringFlag{
+			Name:  "digest-state",
+			Usage: "File storing the Digest challenge between runs",
+		},
+		&cli.BoolFlag{
+			Name:  "preemptive-auth",
+			Usage: "Send Digest credentials from --digest-state on the first request",
+		},
 
 		// Cookies and headers
+		&cli.StringSliceFlag{
+			Name:  "header-env",
+			Usage: "Set header NAME from environment variable VAR (NAME=VAR) to keep secrets off the command line",
+		},
+		&cli.StringSliceFlag{
+			Name:  "query",
+			Usage: "Append key=value to the query string of every target (can be repeated)",
+		},
 		&cli.StringFlag{
-			Name:  "cookie",
-			Usage: "Send cookie(s) to server",
+			Name:    "cookie",
+			Aliases: []string{"b"},
+			Usage:   "Send cookie(s) to server ('name=value; ...'), or load them from a Netscape cookie FILE",
 		},
 		&cli.StringFlag{
 			Name:  "user-agent",
@@ -95,6 +187,43 @@ func buildFlags() []cli.Flag {
 			Name:  "referer",
 			Usage: "Send Referer header to server",
 		},
+		&cli.BoolFlag{
+			Name:  "no-host-header",
+			Usage: "Send the request without a Host header",
+		},
+		&cli.BoolFlag{
+			Name:  "raw-header-case",
+			Usage: "Send -H header names exactly as typed instead of canonicalizing them",
+		},
+		&cli.IntFlag{
+			Name:  "max-redirs",
+			Usage: "Maximum number of redirects to follow (default 10)",
+		},
+		&cli.BoolFlag{
+			Name:  "follow-meta-refresh",
+			Usage: "Follow Refresh headers and HTML meta-refresh tags like redirects",
+		},
+		&cli.BoolFlag{
+			Name:  "randomize-headers",
+			Usage: "Vary User-Agent, Accept and header order for each request",
+		},
+		&cli.Int64Flag{
+			Name:  "randomize-seed",
+			Usage: "Seed for --randomize-headers to reproduce a run",
+		},
+		&cli.BoolFlag{
+			Name:  "fail-if-redirect",
+			Usage: "Fail on any 3xx response instead of following it",
+		},
+		&cli.BoolFlag{
+			Name:    "fail",
+			Aliases: []string{"f"},
+			Usage:   "Fail with exit code 22 and no body output on HTTP 4xx/5xx responses",
+		},
+		&cli.StringSliceFlag{
+			Name:  "abort-on-header",
+			Usage: "Abort before reading the body when a response header matches 'Name: value' (can be repeated)",
+		},
 
 		// Output control
 		&cli.BoolFlag{
@@ -102,10 +231,22 @@ func buildFlags() []cli.Flag {
 			Aliases: []string{"v"},
 			Usage:   "Make the operation more talkative",
 		},
+		&cli.IntFlag{
+			Name:  "verbose-level",
+			Usage: "Verbosity level: 1 request/response lines, 2 adds headers (same as -v), 3 adds timing and connection details",
+		},
 		&cli.BoolFlag{
 			Name:  "verbose-tls",
 			Usage: "Print TLS handshake details",
 		},
+		&cli.BoolFlag{
+			Name:  "no-redact",
+			Usage: "Show Authorization, Cookie, Set-Cookie and Proxy-Authorization values in verbose output",
+		},
+		&cli.StringSliceFlag{
+			Name:  "redact-header",
+			Usage: "Also mask this header's value in verbose output (can be repeated)",
+		},
 		&cli.StringFlag{
 			Name:    "output",
 			Aliases: []string{"o"},
@@ -116,10 +257,120 @@ func buildFlags() []cli.Flag {
 			Aliases: []string{"I"},
 			Usage:   "HEAD request",
 		},
+		&cli.BoolFlag{
+			Name:    "include",
+			Aliases: []string{"i"},
+			Usage:   "Include the response status line and headers in the output",
+		},
+		&cli.BoolFlag{
+			Name:    "silent",
+			Aliases: []string{"s"},
+			Usage:   "Silent mode: print only the response body",
+		},
+		&cli.BoolFlag{
+			Name:    "show-error",
+			Aliases: []string{"S"},
+			Usage:   "With -s, still print error messages",
+		},
 		&cli.BoolFlag{
 			Name:  "json",
 			Usage: "Set Content-Type and Accept to application/json",
 		},
+		&cli.BoolFlag{
+			Name:  "json-strict",
+			Usage: "With --json, fail if the request body is not valid JSON",
+		},
+		&cli.BoolFlag{
+			Name:  "status-line-stderr",
+			Usage: "Print the status line to stderr, keeping stdout to the response body",
+		},
+		&cli.StringFlag{
+			Name:  "body-regex",
+			Usage: "Fail unless the response body matches PATTERN",
+		},
+		&cli.BoolFlag{
+			Name:  "body-regex-absent",
+			Usage: "Fail if the body matches --body-regex instead",
+		},
+		&cli.BoolFlag{
+			Name:  "head-body-check",
+			Usage: "Send a HEAD first and verify its Content-Length matches the body size",
+		},
+		&cli.BoolFlag{
+			Name:  "show-dns",
+			Usage: "Print the target's A/AAAA records and the address used to stderr",
+		},
+		&cli.BoolFlag{
+			Name:  "summary-json",
+			Usage: "Print a one-line JSON summary (url, status, protocol, TLS, time, size, IP) to stderr",
+		},
+		&cli.StringFlag{
+			Name:  "output-format",
+			Usage: "Status line format (text, json); json prints url, protocol, status, time and headers as one object",
+		},
+		&cli.BoolFlag{
+			Name:  "include-body",
+			Usage: "Include the body in the --output-format json object (base64 if not UTF-8) instead of streaming it",
+		},
+		&cli.BoolFlag{
+			Name:  "transcode-utf8",
+			Usage: "Transcode the body to UTF-8 from the charset in Content-Type",
+		},
+		&cli.BoolFlag{
+			Name:  "json-lines",
+			Usage: "Stream the body as one JSON line per chunk with a timestamp and byte offset",
+		},
+		&cli.BoolFlag{
+			Name:  "sse",
+			Usage: "Parse the body as Server-Sent Events (with --json-lines, one line per event)",
+		},
+		&cli.BoolFlag{
+			Name:  "pretty-json",
+			Usage: "Indent JSON response bodies written to stdout",
+		},
+		&cli.StringFlag{
+			Name:    "assume-content-type",
+			Aliases: []string{"content-type-override"},
+			Usage:   "Process the body as TYPE (JSON, charset, SSE) regardless of the response's Content-Type",
+		},
+		&cli.StringFlag{
+			Name:    "write-out",
+			Aliases: []string{"w"},
+			Usage:   "Print FORMAT after the response, expanding %{http_code}, %{time_total}, %{remote_ip}, etc.",
+		},
+		&cli.StringFlag{
+			Name:  "status-format",
+			Usage: "Status line template using {proto}, {code} and {time} placeholders",
+		},
+		&cli.StringFlag{
+			Name:  "prometheus",
+			Usage: "Write probe metrics in Prometheus text format to FILE",
+		},
+		&cli.StringFlag{
+			Name:    "cookie-jar",
+			Aliases: []string{"c", "export-cookies"},
+			Usage:   "Write the session's cookies to FILE in Netscape format after each response",
+		},
+		&cli.BoolFlag{
+			Name:  "trace-config",
+			Usage: "Print the effective configuration as JSON to stderr (secrets redacted)",
+		},
+		&cli.StringFlag{
+			Name:  "trace",
+			Usage: "Write a hex and ASCII dump of all data sent and received to FILE (\"-\" for stderr)",
+		},
+		&cli.StringFlag{
+			Name:  "trace-ascii",
+			Usage: "Like --trace, but dump the data as text only",
+		},
+		&cli.StringFlag{
+			Name:  "cache-dir",
+			Usage: "Cache GET responses in DIR and revalidate them with conditional requests",
+		},
+		&cli.StringFlag{
+			Name:  "save-request",
+			Usage: "Write the request to FILE in .http format for editor REST clients",
+		},
 
 		// TLS/SSL
 		&cli.BoolFlag{
@@ -127,9 +378,9 @@ func buildFlags() []cli.Flag {
 			Aliases: []string{"k"},
 			Usage:   "Allow insecure server connections when using SSL",
 		},
-		&cli.StringFlag{
+		&cli.StringSliceFlag{
 			Name:  "cacert",
-			Usage: "CA certificate to verify peer against",
+			Usage: "CA certificate to verify peer against (can be repeated)",
 		},
 		&cli.StringFlag{
 			Name:  "cert",
@@ -143,6 +394,190 @@ func buildFlags() []cli.Flag {
 			Name:  "strict-ssl",
 			Usage: "Enforce strict SSL certificate validation",
 		},
+		&cli.BoolFlag{
+			Name:  "skip-hostname-verify",
+			Usage: "Verify the certificate chain but not that it matches the hostname",
+		},
+		&cli.StringFlag{
+			Name:  "tls-resumption",
+			Usage: "Resume TLS sessions across requests (on, off)",
+			Value: "off",
+		},
+		&cli.BoolFlag{
+			Name:  "tls-early-data",
+			Usage: "Request TLS 1.3 0-RTT early data on resumed sessions (enables --tls-resumption)",
+		},
+		&cli.BoolFlag{
+			Name:    "fail-on-weak-tls",
+			Aliases: []string{"fail-on-tls-warning"},
+			Usage:   "Fail if the connection negotiates TLS below 1.2 or a weak cipher suite",
+		},
+		&cli.StringSliceFlag{
+			Name:  "weak-ciphers",
+			Usage: "Cipher suite names treated as weak by --fail-on-weak-tls (default: Go's insecure suites)",
+		},
+		&cli.StringFlag{
+			Name:  "expect-tls-version",
+			Usage: "Fail unless this TLS version is negotiated (1.0, 1.1, 1.2, 1.3)",
+		},
+		&cli.StringFlag{
+			Name:  "ciphers",
+			Usage: "Cipher suites to offer, separated by colons or commas (TLS 1.2 and earlier)",
+		},
+		&cli.BoolFlag{
+			Name:  "tlsv1.0",
+			Usage: "Use TLS 1.0 or later",
+		},
+		&cli.BoolFlag{
+			Name:  "tlsv1.1",
+			Usage: "Use TLS 1.1 or later",
+		},
+		&cli.BoolFlag{
+			Name:  "tlsv1.2",
+			Usage: "Use TLS 1.2 or later",
+		},
+		&cli.BoolFlag{
+			Name:  "tlsv1.3",
+			Usage: "Use TLS 1.3 or later",
+		},
+		&cli.StringFlag{
+			Name:  "tls-max",
+			Usage: "Highest TLS version to use (1.0, 1.1, 1.2, 1.3)",
+		},
+
+		// HTTP/2
+		&cli.BoolFlag{
+			Name:  "http2",
+			Usage: "Offer HTTP/2 over TLS via ALPN",
+		},
+		&cli.BoolFlag{
+			Name:  "http1.1",
+			Usage: "Use HTTP/1.1 only",
+		},
+		&cli.IntFlag{
+			Name:  "h2-max-streams",
+			Usage: "Not supported: Go's HTTP/2 client cannot advertise a stream limit",
+		},
+		&cli.IntFlag{
+			Name:  "h2-initial-window",
+			Usage: "Initial HTTP/2 per-stream flow-control window in bytes (enables HTTP/2 over TLS)",
+		},
+
+		// Multi-request
+		&cli.StringSliceFlag{
+			Name:  "extract",
+			Usage: "Extract a value from the JSON response (name=$.json.path)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "use",
+			Usage: "Substitute an extracted value into {{name}} placeholders in headers and data",
+		},
+		&cli.StringFlag{
+			Name:  "replay-from-har",
+			Usage: "Replay every request recorded in a HAR FILE",
+		},
+		&cli.StringFlag{
+			Name:  "output-har",
+			Usage: "Record every request and response with timings to a HAR FILE",
+		},
+		&cli.StringFlag{
+			Name:  "data-file-list",
+			Usage: "POST each file listed in FILE (one path per line) as a separate request",
+		},
+		&cli.StringFlag{
+			Name:  "targets-file",
+			Usage: "Send the request to each target listed in FILE (one per line, - for stdin), continuing past failures",
+		},
+		&cli.IntFlag{
+			Name:  "parallel",
+			Usage: "Request N --targets-file targets at once, printing their output in file order",
+		},
+		&cli.IntFlag{
+			Name:  "weighted-sample",
+			Usage: "Send to N targets drawn from --targets-file in proportion to their weights",
+		},
+		&cli.Int64Flag{
+			Name:  "sample-seed",
+			Usage: "Seed for --weighted-sample so the same targets are drawn again",
+		},
+		&cli.IntFlag{
+			Name:  "max-total-targets",
+			Usage: "Abort before sending if more than N requests would run (default 10000)",
+		},
+		&cli.IntFlag{
+			Name:  "repeat",
+			Usage: "Send the request N times",
+		},
+
+		// Retry
+		&cli.IntFlag{
+			Name:  "retry",
+			Usage: "Retry transient failures (connection errors, timeouts, 408, 429, 5xx) up to N times",
+		},
+		&cli.StringFlag{
+			Name:  "retry-delay",
+			Usage: "Wait before the first retry, doubled for each later one (e.g., 500ms, default 1s)",
+		},
+		&cli.StringFlag{
+			Name:  "retry-max-time",
+			Usage: "Stop retrying once this much time has passed since the first attempt (e.g., 30s)",
+		},
+		&cli.IntFlag{
+			Name:  "retry-budget",
+			Usage: "Maximum number of retries across all requests in one invocation",
+		},
+		&cli.BoolFlag{
+			Name:  "retry-connreset",
+			Usage: "Retry requests whose connection is reset by the server, even without --retry",
+		},
+		&cli.StringSliceFlag{
+			Name:  "retry-methods",
+			Usage: "Also retry these methods (e.g., POST,PUT); only GET, HEAD, OPTIONS and TRACE are retried by default",
+		},
+		&cli.BoolFlag{
+			Name:  "retry-all-errors",
+			Usage: "Retry every method and any 4xx/5xx response, not just transient failures of idempotent requests",
+		},
+
+		// Proxy
+		&cli.StringFlag{
+			Name:    "proxy",
+			Aliases: []string{"x"},
+			Usage:   "Use this proxy (http://, https://, socks5://, with optional user:pass@); defaults to HTTP_PROXY/HTTPS_PROXY",
+		},
+		&cli.StringFlag{
+			Name:  "proxy-chain",
+			Usage: "Comma-separated proxies to chain through in order (e.g., http://p1:8080,socks5://p2:1080)",
+		},
+
+		// TCP
+		&cli.BoolFlag{
+			Name:  "tcp-nodelay",
+			Usage: "Set TCP_NODELAY on connections (default on)",
+			Value: true,
+		},
+		&cli.BoolFlag{
+			Name:  "tcp-nagle",
+			Usage: "Leave Nagle's algorithm on (clears TCP_NODELAY)",
+		},
+
+		// DNS
+		&cli.StringFlag{
+			Name:  "dns-servers",
+			Usage: "Comma-separated DNS servers to resolve with instead of the system configuration (e.g., 8.8.8.8,1.1.1.1)",
+		},
+		&cli.BoolFlag{
+			Name:  "dns-strict",
+			Usage: "Fail instead of falling back to system DNS when --dns-servers cannot resolve",
+		},
+		&cli.StringSliceFlag{
+			Name:  "resolve",
+			Usage: "Connect to ADDRESS for HOST:PORT instead of resolving it (HOST:PORT:ADDRESS, can be repeated)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "connect-to",
+			Usage: "Connect to HOST2:PORT2 for HOST1:PORT1, keeping the Host header and SNI (HOST1:PORT1:HOST2:PORT2, can be repeated)",
+		},
 
 		// Protocol
 		&cli.StringFlag{
@@ -150,6 +585,38 @@ func buildFlags() []cli.Flag {
 			Usage: "Protocol to use (auto, http, https)",
 			Value: "auto",
 		},
+		&cli.StringFlag{
+			Name:  "probe-path",
+			Usage: "Path the protocol probe requests instead of the target's (e.g., / or /healthz)",
+		},
+		&cli.StringFlag{
+			Name:  "probe-accept-status",
+			Usage: "HTTP probe statuses accepted in auto mode (e.g., 2xx, 200-299, 200,204)",
+		},
+		&cli.IntFlag{
+			Name:  "probe-retries",
+			Usage: "Retry each protocol probe up to N times on errors or 5xx before moving on",
+		},
+		&cli.StringFlag{
+			Name:  "probe-method",
+			Usage: "Method of the protocol probe (HEAD, GET); a HEAD answered with 405 is retried as GET",
+		},
+		&cli.StringFlag{
+			Name:  "probe-order",
+			Usage: "Protocol probed first in auto mode (http-first, https-first)",
+		},
+		&cli.BoolFlag{
+			Name:  "parallel-probe",
+			Usage: "Probe HTTP and HTTPS at the same time in auto mode and use the first to succeed",
+		},
+		&cli.StringFlag{
+			Name:  "probe-timeout",
+			Usage: "Timeout for each protocol probe in auto mode (default: 3s for HTTP, 7s for HTTPS)",
+		},
+		&cli.StringFlag{
+			Name:  "default-scheme",
+			Usage: "Use this scheme for scheme-less targets without probing (http, https)",
+		},
 
 		// Timeouts
 		&cli.StringFlag{
@@ -160,10 +627,55 @@ func buildFlags() []cli.Flag {
 			Name:  "connect-timeout",
 			Usage: "Maximum time allowed for connection",
 		},
+		&cli.StringFlag{
+			Name:  "tls-handshake-timeout",
+			Usage: "Maximum time allowed for the TLS handshake (defaults to --connect-timeout)",
+		},
 		&cli.StringFlag{
 			Name:  "max-time",
 			Usage: "Maximum time allowed for the operation (alias for --timeout)",
 		},
+		&cli.StringFlag{
+			Name:  "deadline",
+			Usage: "Absolute RFC3339 time by which the whole run must finish",
+		},
+		&cli.StringFlag{
+			Name:    "max-latency",
+			Aliases: []string{"fail-if-slower-than"},
+			Usage:   "Fail (exit 50) if the response headers take longer than this to arrive (e.g., 500ms)",
+		},
+		&cli.StringFlag{
+			Name:  "max-filesize",
+			Usage: "Fail (exit 63) if the response body is larger than this (e.g., 500K, 10M)",
+		},
+
+		// Connect-only
+		&cli.BoolFlag{
+			Name:  "connect-only",
+			Usage: "Only open the TCP (and TLS) connection, then exit",
+		},
+		&cli.IntFlag{
+			Name:  "connect-retry-count",
+			Usage: "Retry the --connect-only connection up to N times",
+		},
+		&cli.StringFlag{
+			Name:  "connect-retry-interval",
+			Usage: "Wait between --connect-only attempts (e.g., 500ms, default 1s)",
+		},
+
+		// Benchmark
+		&cli.BoolFlag{
+			Name:  "benchmark",
+			Usage: "Fire requests continuously and report throughput and latency",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "Number of concurrent benchmark workers",
+		},
+		&cli.StringFlag{
+			Name:  "duration",
+			Usage: "How long the benchmark runs (e.g., 30s)",
+		},
 	}
 }
 
@@ -173,28 +685,85 @@ func parseFlags(c *cli.Context, opts *Options) error {
 	if c.IsSet("request") {
 		opts.Method = c.String("request")
 	}
+	if c.IsSet("path") {
+		path := c.String("path")
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("invalid path: %q (must start with /)", path)
+		}
+		opts.Path = path
+	}
 
 	// Headers
 	if c.IsSet("header") {
 		opts.Headers = c.StringSlice("header")
 	}
+	for _, mapping := range c.StringSlice("header-env") {
+		name, envVar, found := strings.Cut(mapping, "=")
+		if !found || strings.TrimSpace(name) == "" || strings.TrimSpace(envVar) == "" {
+			return fmt.Errorf("invalid header-env: %q (expected NAME=ENV_VAR)", mapping)
+		}
+		if _, ok := os.LookupEnv(strings.TrimSpace(envVar)); !ok {
+			return &errors.MissingEnvError{Variable: strings.TrimSpace(envVar), Header: strings.TrimSpace(name)}
+		}
+		opts.HeaderEnv = append(opts.HeaderEnv, mapping)
+	}
+	for _, param := range c.StringSlice("query") {
+		if key, _, found := strings.Cut(param, "="); !found || key == "" {
+			return fmt.Errorf("invalid query: %q (expected key=value)", param)
+		}
+		opts.Query = append(opts.Query, param)
+	}
 
 	// Data/Body
 	if c.IsSet("data") {
-		opts.Data = c.String("data")
+		opts.Data = c.Generic("data").(*dataValues).String()
 	}
+	opts.DataURLEncode = c.StringSlice("data-urlencode")
+	opts.Get = c.Bool("get")
 	if c.IsSet("data-raw") {
 		opts.DataRaw = c.String("data-raw")
 	}
+	opts.Compressed = c.Bool("compressed")
+	if c.IsSet("request-id") {
+		// A bare --request-id arrives as "true"
+		if value := c.Generic("request-id").(*optionalValue).String(); value == "true" {
+			opts.GenerateRequestID = true
+		} else if value == "" {
+			return fmt.Errorf("invalid request-id: value must not be empty")
+		} else {
+			opts.RequestID = value
+		}
+	}
+	opts.Traceparent = c.Bool("traceparent")
+	if c.IsSet("compress-request") {
+		opts.CompressRequest = c.Bool("compress-request")
+	}
+	if c.IsSet("compress-level") {
+		level := c.Int("compress-level")
+		if level < 1 || level > 9 {
+			return fmt.Errorf("invalid compress-level: %d (must be 1-9)", level)
+		}
+		opts.CompressLevel = level
+	}
 
 	// Authentication
 	if c.IsSet("user") {
 		opts.User = c.String("user")
 	}
+	opts.Digest = c.Bool("digest")
+	if c.IsSet("digest-state") {
+		opts.DigestState = c.String("digest-state")
+	}
+	opts.PreemptiveAuth = c.Bool("preemptive-auth")
 
 	// Cookies and headers
 	if c.IsSet("cookie") {
-		opts.Cookie = c.String("cookie")
+		// Like curl, a value without '=' names a cookie file
+		if value := c.String("cookie"); strings.Contains(value, "=") {
+			opts.Cookie = value
+		} else {
+			opts.CookieFile = value
+		}
 	}
 	if c.IsSet("user-agent") {
 		opts.UserAgent = c.String("user-agent")
@@ -202,30 +771,133 @@ func parseFlags(c *cli.Context, opts *Options) error {
 	if c.IsSet("referer") {
 		opts.Referer = c.String("referer")
 	}
+	if c.IsSet("no-host-header") {
+		opts.NoHostHeader = c.Bool("no-host-header")
+	}
+	opts.RawHeaderCase = c.Bool("raw-header-case")
+	if c.IsSet("max-redirs") {
+		maxRedirs := c.Int("max-redirs")
+		if maxRedirs < 1 {
+			return fmt.Errorf("invalid max-redirs: %d (must be at least 1)", maxRedirs)
+		}
+		opts.MaxRedirs = maxRedirs
+	}
+	opts.FollowMetaRefresh = c.Bool("follow-meta-refresh")
+	opts.RandomizeHeaders = c.Bool("randomize-headers")
+	if c.IsSet("randomize-seed") {
+		opts.RandomizeSeed = c.Int64("randomize-seed")
+	}
+	opts.FailIfRedirect = c.Bool("fail-if-redirect")
+	opts.Fail = c.Bool("fail")
+	for _, spec := range c.StringSlice("abort-on-header") {
+		match, err := ParseHeaderMatch(spec)
+		if err != nil {
+			return fmt.Errorf("invalid abort-on-header: %v", err)
+		}
+		opts.AbortOnHeader = append(opts.AbortOnHeader, match)
+	}
 
 	// Output control
 	if c.IsSet("verbose") {
 		opts.Verbose = c.Bool("verbose")
 	}
+	if c.IsSet("verbose-level") {
+		level := c.Int("verbose-level")
+		if level < 1 || level > 3 {
+			return fmt.Errorf("invalid verbose-level: %d (must be 1, 2, or 3)", level)
+		}
+		opts.VerboseLevel = level
+	}
 	if c.IsSet("verbose-tls") {
 		opts.VerboseTLS = c.Bool("verbose-tls")
 	}
+	opts.NoRedact = c.Bool("no-redact")
+	opts.RedactHeaders = c.StringSlice("redact-header")
+	if opts.NoRedact && len(opts.RedactHeaders) > 0 {
+		return fmt.Errorf("--no-redact cannot be combined with --redact-header")
+	}
 	if c.IsSet("output") {
 		opts.Output = c.String("output")
 	}
 	if c.IsSet("head") {
 		opts.Head = c.Bool("head")
 	}
+	opts.Include = c.Bool("include")
+	opts.Silent = c.Bool("silent")
+	opts.ShowError = c.Bool("show-error")
 	if c.IsSet("json") {
 		opts.JSON = c.Bool("json")
 	}
+	opts.JSONStrict = c.Bool("json-strict")
+	opts.StatusLineStderr = c.Bool("status-line-stderr")
+	if c.IsSet("body-regex") {
+		if _, err := regexp.Compile(c.String("body-regex")); err != nil {
+			return fmt.Errorf("invalid body-regex: %v", err)
+		}
+		opts.BodyRegex = c.String("body-regex")
+	}
+	opts.BodyRegexAbsent = c.Bool("body-regex-absent")
+	opts.HeadBodyCheck = c.Bool("head-body-check")
+	opts.ShowDNS = c.Bool("show-dns")
+	opts.SummaryJSON = c.Bool("summary-json")
+	if c.IsSet("output-format") {
+		format := c.String("output-format")
+		if format != "text" && format != "json" {
+			return fmt.Errorf("invalid output-format: %s (must be text or json)", format)
+		}
+		opts.OutputFormat = format
+	}
+	if c.Bool("include-body") {
+		if opts.OutputFormat != "json" {
+			return fmt.Errorf("--include-body requires --output-format json")
+		}
+		opts.IncludeBody = true
+	}
+	opts.JSONLines = c.Bool("json-lines")
+	opts.TranscodeUTF8 = c.Bool("transcode-utf8")
+	opts.SSE = c.Bool("sse")
+	opts.PrettyJSON = c.Bool("pretty-json")
+	if c.IsSet("assume-content-type") {
+		value := c.String("assume-content-type")
+		if _, _, err := mime.ParseMediaType(value); err != nil {
+			return fmt.Errorf("invalid assume-content-type: %q (%v)", value, err)
+		}
+		opts.AssumeContentType = value
+	}
+	if c.IsSet("write-out") {
+		opts.WriteOut = c.String("write-out")
+	}
+	if c.IsSet("status-format") {
+		opts.StatusFormat = c.String("status-format")
+	}
+	if c.IsSet("prometheus") {
+		opts.Prometheus = c.String("prometheus")
+	}
+	if c.IsSet("cookie-jar") {
+		opts.CookieJar = c.String("cookie-jar")
+	}
+	opts.TraceConfig = c.Bool("trace-config")
+	if c.IsSet("trace") && c.IsSet("trace-ascii") {
+		return fmt.Errorf("--trace cannot be combined with --trace-ascii")
+	}
+	if (c.IsSet("trace") || c.IsSet("trace-ascii")) && c.Bool("http2") {
+		return fmt.Errorf("--trace cannot be combined with --http2")
+	}
+	opts.Trace = c.String("trace")
+	opts.TraceASCII = c.String("trace-ascii")
+	if c.IsSet("cache-dir") {
+		opts.CacheDir = c.String("cache-dir")
+	}
+	if c.IsSet("save-request") {
+		opts.SaveRequest = c.String("save-request")
+	}
 
 	// TLS/SSL
 	if c.IsSet("insecure") {
 		opts.Insecure = c.Bool("insecure")
 	}
 	if c.IsSet("cacert") {
-		opts.CACert = c.String("cacert")
+		opts.CACerts = c.StringSlice("cacert")
 	}
 	if c.IsSet("cert") {
 		opts.Cert = c.String("cert")
@@ -236,6 +908,229 @@ func parseFlags(c *cli.Context, opts *Options) error {
 	if c.IsSet("strict-ssl") {
 		opts.StrictSSL = c.Bool("strict-ssl")
 	}
+	opts.SkipHostnameVerify = c.Bool("skip-hostname-verify")
+	if c.IsSet("tls-resumption") {
+		switch c.String("tls-resumption") {
+		case "on":
+			opts.TLSResumption = true
+		case "off":
+			opts.TLSResumption = false
+		default:
+			return fmt.Errorf("invalid tls-resumption: %s (must be on or off)", c.String("tls-resumption"))
+		}
+	}
+	opts.FailOnWeakTLS = c.Bool("fail-on-weak-tls")
+	for _, name := range c.StringSlice("weak-ciphers") {
+		opts.WeakCiphers = append(opts.WeakCiphers, strings.TrimSpace(name))
+	}
+	if c.Bool("tls-early-data") {
+		opts.TLSEarlyData = true
+		opts.TLSResumption = true
+	}
+	if c.IsSet("expect-tls-version") {
+		versions := map[string]uint16{
+			"1.0": tls.VersionTLS10,
+			"1.1": tls.VersionTLS11,
+			"1.2": tls.VersionTLS12,
+			"1.3": tls.VersionTLS13,
+		}
+		version, ok := versions[c.String("expect-tls-version")]
+		if !ok {
+			return fmt.Errorf("invalid expect-tls-version: %s (must be 1.0, 1.1, 1.2, or 1.3)", c.String("expect-tls-version"))
+		}
+		opts.ExpectTLSVersion = version
+	}
+	// The highest --tlsv1.x given wins
+	for _, version := range []string{"1.3", "1.2", "1.1", "1.0"} {
+		if c.Bool("tlsv" + version) {
+			opts.TLSMin = version
+			break
+		}
+	}
+	opts.TLSMax = c.String("tls-max")
+	opts.Ciphers = c.String("ciphers")
+
+	// HTTP/2
+	if c.Bool("http2") {
+		if c.Bool("http1.1") {
+			return fmt.Errorf("--http2 cannot be combined with --http1.1")
+		}
+		opts.HTTPVersion = "2"
+	}
+	if c.Bool("http1.1") {
+		if c.IsSet("h2-initial-window") {
+			return fmt.Errorf("--http1.1 cannot be combined with --h2-initial-window")
+		}
+		opts.HTTPVersion = "1.1"
+	}
+	// The client never sends SETTINGS_MAX_CONCURRENT_STREAMS, so there is nothing to set
+	if c.IsSet("h2-max-streams") {
+		return fmt.Errorf("--h2-max-streams is not supported: Go's HTTP/2 client cannot advertise a stream limit")
+	}
+	if c.IsSet("h2-initial-window") {
+		window := c.Int("h2-initial-window")
+		if window < 1 || window > maxH2Window {
+			return fmt.Errorf("invalid h2-initial-window: %d (must be between 1 and %d)", window, maxH2Window)
+		}
+		opts.H2InitialWindow = window
+	}
+
+	// Multi-request
+	for _, spec := range c.StringSlice("extract") {
+		extract, err := ParseExtractSpec(spec)
+		if err != nil {
+			return fmt.Errorf("invalid extract: %v", err)
+		}
+		opts.Extract = append(opts.Extract, extract)
+	}
+	if c.IsSet("use") {
+		opts.Use = c.StringSlice("use")
+	}
+	if c.IsSet("replay-from-har") {
+		opts.ReplayFromHAR = c.String("replay-from-har")
+	}
+	if c.IsSet("output-har") {
+		opts.OutputHAR = c.String("output-har")
+	}
+	if c.IsSet("data-file-list") {
+		opts.DataFileList = c.String("data-file-list")
+	}
+	if c.IsSet("targets-file") {
+		if c.NArg() > 0 {
+			return fmt.Errorf("--targets-file cannot be combined with a target argument")
+		}
+		opts.TargetsFile = c.String("targets-file")
+	}
+	if c.IsSet("weighted-sample") {
+		if opts.TargetsFile == "" {
+			return fmt.Errorf("--weighted-sample requires --targets-file")
+		}
+		n := c.Int("weighted-sample")
+		if n < 1 {
+			return fmt.Errorf("invalid weighted-sample: %d (must be at least 1)", n)
+		}
+		opts.WeightedSample = n
+	}
+	opts.SampleSeed = c.Int64("sample-seed")
+	if c.IsSet("parallel") {
+		if opts.TargetsFile == "" {
+			return fmt.Errorf("--parallel requires --targets-file")
+		}
+		parallel := c.Int("parallel")
+		if parallel < 1 {
+			return fmt.Errorf("invalid parallel: %d (must be at least 1)", parallel)
+		}
+		opts.Parallel = parallel
+	}
+	if c.IsSet("max-total-targets") {
+		max := c.Int("max-total-targets")
+		if max < 1 {
+			return fmt.Errorf("invalid max-total-targets: %d (must be at least 1)", max)
+		}
+		opts.MaxTotalTargets = max
+	}
+	if c.IsSet("repeat") {
+		repeat := c.Int("repeat")
+		if repeat < 1 {
+			return fmt.Errorf("invalid repeat: %d (must be at least 1)", repeat)
+		}
+		opts.Repeat = repeat
+	}
+
+	// Retry
+	if c.IsSet("retry") {
+		retry := c.Int("retry")
+		if retry < 0 {
+			return fmt.Errorf("invalid retry: %d (must not be negative)", retry)
+		}
+		opts.Retry = retry
+	}
+	if c.IsSet("retry-delay") {
+		duration, err := time.ParseDuration(c.String("retry-delay"))
+		if err != nil {
+			return fmt.Errorf("invalid retry-delay format: %v", err)
+		}
+		if duration <= 0 {
+			return fmt.Errorf("invalid retry-delay: %s (must be positive)", c.String("retry-delay"))
+		}
+		opts.RetryDelay = duration
+	}
+	if c.IsSet("retry-max-time") {
+		duration, err := time.ParseDuration(c.String("retry-max-time"))
+		if err != nil {
+			return fmt.Errorf("invalid retry-max-time format: %v", err)
+		}
+		if duration <= 0 {
+			return fmt.Errorf("invalid retry-max-time: %s (must be positive)", c.String("retry-max-time"))
+		}
+		opts.RetryMaxTime = duration
+	}
+	if c.IsSet("retry-budget") {
+		budget := c.Int("retry-budget")
+		if budget < 1 {
+			return fmt.Errorf("invalid retry-budget: %d (must be at least 1)", budget)
+		}
+		opts.RetryBudget = budget
+	}
+	opts.RetryConnReset = c.Bool("retry-connreset")
+	for _, method := range c.StringSlice("retry-methods") {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		if method == "" || strings.ContainsAny(method, " \t/") {
+			return fmt.Errorf("invalid retry-methods: %q", method)
+		}
+		opts.RetryMethods = append(opts.RetryMethods, method)
+	}
+	opts.RetryAllErrors = c.Bool("retry-all-errors")
+
+	// Proxy
+	if c.IsSet("proxy") {
+		if c.IsSet("proxy-chain") {
+			return fmt.Errorf("--proxy cannot be combined with --proxy-chain")
+		}
+		opts.Proxy = c.String("proxy")
+	}
+	if c.IsSet("proxy-chain") {
+		for _, proxy := range strings.Split(c.String("proxy-chain"), ",") {
+			if proxy = strings.TrimSpace(proxy); proxy != "" {
+				opts.ProxyChain = append(opts.ProxyChain, proxy)
+			}
+		}
+	}
+
+	// Host is only stripped from HTTP/1.1 written straight to the target
+	if opts.NoHostHeader {
+		if opts.Proxy != "" || len(opts.ProxyChain) > 0 {
+			return fmt.Errorf("--no-host-header cannot be combined with --proxy or --proxy-chain")
+		}
+		if opts.HTTPVersion == "2" {
+			return fmt.Errorf("--no-host-header cannot be combined with --http2")
+		}
+	}
+
+	// TCP
+	opts.TCPNagle = c.Bool("tcp-nagle") || !c.Bool("tcp-nodelay")
+
+	// DNS
+	if c.IsSet("dns-servers") {
+		servers, err := ParseDNSServers(c.String("dns-servers"))
+		if err != nil {
+			return fmt.Errorf("invalid dns-servers: %v", err)
+		}
+		opts.DNSServers = servers
+	}
+	opts.DNSStrict = c.Bool("dns-strict")
+	for _, spec := range c.StringSlice("resolve") {
+		if _, _, err := ParseResolve(spec); err != nil {
+			return fmt.Errorf("invalid resolve: %v", err)
+		}
+		opts.Resolve = append(opts.Resolve, spec)
+	}
+	for _, spec := range c.StringSlice("connect-to") {
+		if _, err := ParseConnectTo(spec); err != nil {
+			return fmt.Errorf("invalid connect-to: %v", err)
+		}
+		opts.ConnectTo = append(opts.ConnectTo, spec)
+	}
 
 	// Protocol
 	if c.IsSet("proto") {
@@ -245,6 +1140,59 @@ func parseFlags(c *cli.Context, opts *Options) error {
 		}
 		opts.Proto = proto
 	}
+	if c.IsSet("probe-path") {
+		probePath := c.String("probe-path")
+		if !strings.HasPrefix(probePath, "/") {
+			return fmt.Errorf("invalid probe-path: %q (must start with /)", probePath)
+		}
+		opts.ProbePath = probePath
+	}
+	if c.IsSet("probe-accept-status") {
+		spec, err := ParseStatusSpec(c.String("probe-accept-status"))
+		if err != nil {
+			return fmt.Errorf("invalid probe-accept-status: %v", err)
+		}
+		opts.ProbeAcceptStatus = spec
+	}
+	if c.IsSet("probe-retries") {
+		retries := c.Int("probe-retries")
+		if retries < 0 {
+			return fmt.Errorf("invalid probe-retries: %d (must not be negative)", retries)
+		}
+		opts.ProbeRetries = retries
+	}
+	if c.IsSet("probe-method") {
+		method := strings.ToUpper(c.String("probe-method"))
+		if method != "HEAD" && method != "GET" {
+			return fmt.Errorf("invalid probe-method: %s (must be HEAD or GET)", c.String("probe-method"))
+		}
+		opts.ProbeMethod = method
+	}
+	if c.IsSet("probe-order") {
+		order := c.String("probe-order")
+		if order != "http-first" && order != "https-first" {
+			return fmt.Errorf("invalid probe-order: %s (must be http-first or https-first)", order)
+		}
+		opts.ProbeOrder = order
+	}
+	opts.ParallelProbe = c.Bool("parallel-probe")
+	if c.IsSet("probe-timeout") {
+		duration, err := time.ParseDuration(c.String("probe-timeout"))
+		if err != nil {
+			return fmt.Errorf("invalid probe-timeout format: %v", err)
+		}
+		if duration <= 0 {
+			return fmt.Errorf("invalid probe-timeout: %s (must be positive)", c.String("probe-timeout"))
+		}
+		opts.ProbeTimeout = duration
+	}
+	if c.IsSet("default-scheme") {
+		scheme := c.String("default-scheme")
+		if scheme != "http" && scheme != "https" {
+			return fmt.Errorf("invalid default-scheme: %s (must be http or https)", scheme)
+		}
+		opts.DefaultScheme = scheme
+	}
 
 	// Timeouts
 	if c.IsSet("timeout") {
@@ -263,6 +1211,47 @@ func parseFlags(c *cli.Context, opts *Options) error {
 		opts.ConnectTimeout = duration
 	}
 
+	if c.IsSet("tls-handshake-timeout") {
+		duration, err := time.ParseDuration(c.String("tls-handshake-timeout"))
+		if err != nil {
+			return fmt.Errorf("invalid tls-handshake-timeout format: %v", err)
+		}
+		opts.TLSHandshakeTimeout = duration
+	}
+
+	if c.IsSet("max-latency") {
+		duration, err := time.ParseDuration(c.String("max-latency"))
+		if err != nil {
+			return fmt.Errorf("invalid max-latency format: %v", err)
+		}
+		if duration <= 0 {
+			return fmt.Errorf("invalid max-latency: %s (must be positive)", c.String("max-latency"))
+		}
+		opts.MaxLatency = duration
+	}
+
+	if c.IsSet("max-filesize") {
+		size, err := ParseSize(c.String("max-filesize"))
+		if err != nil {
+			return fmt.Errorf("invalid max-filesize: %v", err)
+		}
+		if size == 0 {
+			return fmt.Errorf("invalid max-filesize: %s (must be positive)", c.String("max-filesize"))
+		}
+		opts.MaxFileSize = size
+	}
+
+	if c.IsSet("deadline") {
+		deadline, err := time.Parse(time.RFC3339, c.String("deadline"))
+		if err != nil {
+			return fmt.Errorf("invalid deadline format: %v", err)
+		}
+		if !deadline.After(time.Now()) {
+			return fmt.Errorf("invalid deadline: %s is in the past", c.String("deadline"))
+		}
+		opts.Deadline = deadline
+	}
+
 	// max-time is an alias for timeout
 	if c.IsSet("max-time") {
 		duration, err := time.ParseDuration(c.String("max-time"))
@@ -272,6 +1261,42 @@ func parseFlags(c *cli.Context, opts *Options) error {
 		opts.Timeout = duration
 	}
 
+	// Connect-only
+	opts.ConnectOnly = c.Bool("connect-only")
+	if c.IsSet("connect-retry-count") {
+		count := c.Int("connect-retry-count")
+		if count < 0 {
+			return fmt.Errorf("invalid connect-retry-count: %d (must not be negative)", count)
+		}
+		opts.ConnectRetryCount = count
+	}
+	if c.IsSet("connect-retry-interval") {
+		interval, err := time.ParseDuration(c.String("connect-retry-interval"))
+		if err != nil {
+			return fmt.Errorf("invalid connect-retry-interval format: %v", err)
+		}
+		opts.ConnectRetryInterval = interval
+	}
+
+	// Benchmark
+	if c.IsSet("benchmark") {
+		opts.Benchmark = c.Bool("benchmark")
+	}
+	if c.IsSet("concurrency") {
+		concurrency := c.Int("concurrency")
+		if concurrency < 1 {
+			return fmt.Errorf("invalid concurrency: %d (must be at least 1)", concurrency)
+		}
+		opts.Concurrency = concurrency
+	}
+	if c.IsSet("duration") {
+		duration, err := time.ParseDuration(c.String("duration"))
+		if err != nil {
+			return fmt.Errorf("invalid duration format: %v", err)
+		}
+		opts.Duration = duration
+	}
+
 	return nil
 }
 
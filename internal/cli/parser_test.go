@@ -159,7 +159,7 @@ func TestParseArgsBasic(t *testing.T) {
 			args:    []string{"purl", "--cacert", "/path/to/ca.pem", "localhost:8080"},
 			wantErr: false,
 			check: func(o *Options) bool {
-				return o.CACert == "/path/to/ca.pem"
+				return len(o.CACerts) == 1 && o.CACerts[0] == "/path/to/ca.pem"
 			},
 		},
 		{
@@ -218,6 +218,450 @@ func TestParseArgsBasic(t *testing.T) {
 				return o.Timeout == 20*time.Second
 			},
 		},
+		{
+			name:    "with probe-accept-status flag",
+			args:    []string{"purl", "--probe-accept-status", "2xx", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.ProbeAcceptStatus.Matches(204) && !o.ProbeAcceptStatus.Matches(302)
+			},
+		},
+		{
+			name:    "with tls-handshake-timeout flag",
+			args:    []string{"purl", "--connect-timeout", "30s", "--tls-handshake-timeout", "2s", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.ConnectTimeout == 30*time.Second && o.TLSHandshakeTimeout == 2*time.Second
+			},
+		},
+		{
+			name:    "with no-host-header flag",
+			args:    []string{"purl", "--no-host-header", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.NoHostHeader == true
+			},
+		},
+		{
+			name:    "with benchmark flags",
+			args:    []string{"purl", "--benchmark", "--concurrency", "10", "--duration", "30s", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.Benchmark && o.Concurrency == 10 && o.Duration == 30*time.Second
+			},
+		},
+		{
+			name:    "with status-format flag",
+			args:    []string{"purl", "--status-format", "{proto} {code} {time}", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.StatusFormat == "{proto} {code} {time}"
+			},
+		},
+		{
+			name:    "with compress-request flags",
+			args:    []string{"purl", "--compress-request", "--compress-level", "9", "-d", "x", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.CompressRequest && o.CompressLevel == 9
+			},
+		},
+		{
+			name:    "with verbose-level flag",
+			args:    []string{"purl", "--verbose-level", "3", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.VerboseLevel == 3
+			},
+		},
+		{
+			name:    "with proxy-chain flag",
+			args:    []string{"purl", "--proxy-chain", "http://p1:8080, socks5://p2:1080", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return len(o.ProxyChain) == 2 && o.ProxyChain[0] == "http://p1:8080" && o.ProxyChain[1] == "socks5://p2:1080"
+			},
+		},
+		{
+			name:    "with prometheus flag",
+			args:    []string{"purl", "--prometheus", "metrics.prom", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.Prometheus == "metrics.prom"
+			},
+		},
+		{
+			name:    "with retry flags",
+			args:    []string{"purl", "--retry", "3", "--retry-budget", "10", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.Retry == 3 && o.RetryBudget == 10
+			},
+		},
+		{
+			name:    "with extract and next request",
+			args:    []string{"purl", "--extract", "token=$.token", "localhost:8080/login", "--next", "--use", "token", "-H", "Authorization: Bearer {{token}}", "localhost:8080/me"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.Target == "localhost:8080/login" &&
+					len(o.Extract) == 1 && o.Extract[0].Name == "token" && o.Extract[0].Path == "$.token" &&
+					o.Next != nil && o.Next.Target == "localhost:8080/me" &&
+					len(o.Next.Use) == 1 && o.Next.Use[0] == "token" &&
+					len(o.Next.Headers) == 1 && o.Next.Next == nil
+			},
+		},
+		{
+			name:    "with deadline",
+			args:    []string{"purl", "--deadline", "2999-01-01T12:00:00Z", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.Deadline.Equal(time.Date(2999, 1, 1, 12, 0, 0, 0, time.UTC))
+			},
+		},
+		{
+			name:    "with probe retries",
+			args:    []string{"purl", "--probe-retries", "2", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.ProbeRetries == 2
+			},
+		},
+		{
+			name:    "tcp-nagle flag",
+			args:    []string{"purl", "--tcp-nagle", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.TCPNagle
+			},
+		},
+		{
+			name:    "tcp-nodelay defaults on",
+			args:    []string{"purl", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return !o.TCPNagle
+			},
+		},
+		{
+			name:    "summary-json flag",
+			args:    []string{"purl", "--summary-json", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.SummaryJSON
+			},
+		},
+		{
+			name:    "json-strict flag",
+			args:    []string{"purl", "--json", "--json-strict", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.JSON && o.JSONStrict
+			},
+		},
+		{
+			name:    "include short flag",
+			args:    []string{"purl", "-i", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.Include
+			},
+		},
+		{
+			name:    "path flag",
+			args:    []string{"purl", "--path", "/health", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.Path == "/health"
+			},
+		},
+		{
+			name:    "combined silent and show-error",
+			args:    []string{"purl", "-sS", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.Silent && o.ShowError
+			},
+		},
+		{
+			name:    "silent alone",
+			args:    []string{"purl", "-s", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.Silent && !o.ShowError
+			},
+		},
+		{
+			name:    "repeated query flags",
+			args:    []string{"purl", "--query", "a=1", "--query", "b=2", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return len(o.Query) == 2 && o.Query[0] == "a=1" && o.Query[1] == "b=2"
+			},
+		},
+		{
+			name:    "fail short flag",
+			args:    []string{"purl", "-f", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.Fail
+			},
+		},
+		{
+			name:    "export-cookies short flag",
+			args:    []string{"purl", "-c", "cookies.txt", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.CookieJar == "cookies.txt"
+			},
+		},
+		{
+			name:    "dns-servers flag",
+			args:    []string{"purl", "--dns-servers", "8.8.8.8,1.1.1.1:5353", "--dns-strict", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return len(o.DNSServers) == 2 && o.DNSServers[0] == "8.8.8.8:53" && o.DNSServers[1] == "1.1.1.1:5353" && o.DNSStrict
+			},
+		},
+		{
+			name:    "proxy short flag",
+			args:    []string{"purl", "-x", "http://proxy:8080", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.Proxy == "http://proxy:8080"
+			},
+		},
+		{
+			name:    "tls-early-data enables resumption",
+			args:    []string{"purl", "--tls-early-data", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.TLSEarlyData && o.TLSResumption
+			},
+		},
+		{
+			name:    "repeated resolve flags",
+			args:    []string{"purl", "--resolve", "example.com:443:127.0.0.1", "--resolve", "example.com:80:127.0.0.1", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return len(o.Resolve) == 2 && o.Resolve[0] == "example.com:443:127.0.0.1"
+			},
+		},
+		{
+			name:    "connect-to",
+			args:    []string{"purl", "--connect-to", "example.com:443:127.0.0.1:8443", "example.com"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return len(o.ConnectTo) == 1 && o.ConnectTo[0] == "example.com:443:127.0.0.1:8443"
+			},
+		},
+		{
+			name:    "fail-on-weak-tls with custom ciphers",
+			args:    []string{"purl", "--fail-on-weak-tls", "--weak-ciphers", "TLS_RSA_WITH_AES_128_CBC_SHA,TLS_RSA_WITH_3DES_EDE_CBC_SHA", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.FailOnWeakTLS && len(o.WeakCiphers) == 2
+			},
+		},
+		{
+			name:    "write-out short flag",
+			args:    []string{"purl", "-w", "%{http_code}\\n", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.WriteOut == "%{http_code}\\n"
+			},
+		},
+		{
+			name:    "probe-path flag",
+			args:    []string{"purl", "--probe-path", "/healthz", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.ProbePath == "/healthz"
+			},
+		},
+		{
+			name:    "repeated data joined with ampersand",
+			args:    []string{"purl", "-G", "-d", "q=test", "-d", "page=2", "--data-urlencode", "name=a b", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.Get && o.Data == "q=test&page=2" && len(o.DataURLEncode) == 1 && o.DataURLEncode[0] == "name=a b"
+			},
+		},
+		{
+			name:    "data with commas kept whole",
+			args:    []string{"purl", "-d", `{"a":1,"b":2}`, "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.Data == `{"a":1,"b":2}` && !o.Get
+			},
+		},
+		{
+			name:    "assume-content-type with pretty-json",
+			args:    []string{"purl", "--pretty-json", "--content-type-override", "application/json", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.PrettyJSON && o.AssumeContentType == "application/json"
+			},
+		},
+		{
+			name:    "retry methods uppercased",
+			args:    []string{"purl", "--retry-methods", "post,Put", "--retry-all-errors", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return len(o.RetryMethods) == 2 && o.RetryMethods[0] == "POST" && o.RetryMethods[1] == "PUT" && o.RetryAllErrors
+			},
+		},
+		{
+			name:    "retry backoff flags",
+			args:    []string{"purl", "--retry", "3", "--retry-delay", "500ms", "--retry-max-time", "30s", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.Retry == 3 && o.RetryDelay == 500*time.Millisecond && o.RetryMaxTime == 30*time.Second
+			},
+		},
+		{
+			name:    "bare request-id generates one",
+			args:    []string{"purl", "--request-id", "--traceparent", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.GenerateRequestID && o.RequestID == "" && o.Traceparent && o.Target == "localhost:8080"
+			},
+		},
+		{
+			name:    "request-id with attached value",
+			args:    []string{"purl", "--request-id=abc-123", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return !o.GenerateRequestID && o.RequestID == "abc-123" && o.Target == "localhost:8080"
+			},
+		},
+		{
+			name:    "tls version bounds",
+			args:    []string{"purl", "--tlsv1.1", "--tlsv1.2", "--tls-max", "1.3", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.TLSMin == "1.2" && o.TLSMax == "1.3" && o.Target == "localhost:8080"
+			},
+		},
+		{
+			name:    "ciphers list",
+			args:    []string{"purl", "--ciphers", "TLS_AES_128_GCM_SHA256:TLS_RSA_WITH_AES_128_CBC_SHA", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.Ciphers == "TLS_AES_128_GCM_SHA256:TLS_RSA_WITH_AES_128_CBC_SHA" && o.Target == "localhost:8080"
+			},
+		},
+		{
+			name:    "http2",
+			args:    []string{"purl", "--http2", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.HTTPVersion == "2" && o.Target == "localhost:8080"
+			},
+		},
+		{
+			name:    "http1.1",
+			args:    []string{"purl", "--http1.1", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.HTTPVersion == "1.1" && o.Target == "localhost:8080"
+			},
+		},
+		{
+			name:    "probe order and timeout",
+			args:    []string{"purl", "--probe-order", "https-first", "--probe-timeout", "2s", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.ProbeOrder == "https-first" && o.ProbeTimeout == 2*time.Second && o.Target == "localhost:8080"
+			},
+		},
+		{
+			name:    "probe-method",
+			args:    []string{"purl", "--probe-method", "get", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.ProbeMethod == "GET" && o.Target == "localhost:8080"
+			},
+		},
+		{
+			name:    "max-filesize with suffix",
+			args:    []string{"purl", "--max-filesize", "10M", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.MaxFileSize == 10<<20 && o.Target == "localhost:8080"
+			},
+		},
+		{
+			name:    "json output format with body",
+			args:    []string{"purl", "--output-format", "json", "--include-body", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.OutputFormat == "json" && o.IncludeBody && o.Target == "localhost:8080"
+			},
+		},
+		{
+			name:    "targets file without target argument",
+			args:    []string{"purl", "--targets-file", "-", "--weighted-sample", "5", "--sample-seed", "42"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.TargetsFile == "-" && o.WeightedSample == 5 && o.SampleSeed == 42 && o.Target == ""
+			},
+		},
+		{
+			name:    "parallel targets",
+			args:    []string{"purl", "--targets-file", "hosts.txt", "--parallel", "8"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.Parallel == 8
+			},
+		},
+		{
+			name:    "default-scheme https",
+			args:    []string{"purl", "--default-scheme", "https", "localhost:8443"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.DefaultScheme == "https"
+			},
+		},
+		{
+			name:    "trace-ascii to stderr",
+			args:    []string{"purl", "--trace-ascii", "-", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.TraceASCII == "-" && o.Trace == ""
+			},
+		},
+		{
+			name:    "no-redact",
+			args:    []string{"purl", "-v", "--no-redact", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.NoRedact
+			},
+		},
+		{
+			name:    "repeated redact-header",
+			args:    []string{"purl", "-v", "--redact-header", "X-Api-Key", "--redact-header", "X-Session", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return len(o.RedactHeaders) == 2 && o.RedactHeaders[1] == "X-Session"
+			},
+		},
+		{
+			name:    "parallel with extract",
+			args:    []string{"purl", "--targets-file", "hosts.txt", "--parallel", "4", "--extract", "token=$.token"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.Parallel == 4 && len(o.Extract) == 1
+			},
+		},
+		{
+			name:    "with h2-initial-window flag",
+			args:    []string{"purl", "--h2-initial-window", "8388608", "localhost:8080"},
+			wantErr: false,
+			check: func(o *Options) bool {
+				return o.H2InitialWindow == 8<<20
+			},
+		},
 		{
 			name:    "flags before target",
 			args:    []string{"purl", "-X", "POST", "-H", "X-Test: value", "localhost:8080"},
@@ -277,6 +721,231 @@ func TestParseArgsBasic(t *testing.T) {
 			args:    []string{"purl", "--timeout", "invalid", "localhost:8080"},
 			wantErr: true,
 		},
+		{
+			name:    "invalid probe-accept-status value",
+			args:    []string{"purl", "--probe-accept-status", "7xx", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid concurrency value",
+			args:    []string{"purl", "--benchmark", "--concurrency", "0", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid compress-level value",
+			args:    []string{"purl", "--compress-request", "--compress-level", "10", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid verbose-level value",
+			args:    []string{"purl", "--verbose-level", "4", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "next without target",
+			args:    []string{"purl", "localhost:8080", "--next"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid extract",
+			args:    []string{"purl", "--extract", "token", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid max-redirs",
+			args:    []string{"purl", "--max-redirs", "0", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "deadline in the past",
+			args:    []string{"purl", "--deadline", "2000-01-01T00:00:00Z", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid deadline format",
+			args:    []string{"purl", "--deadline", "tomorrow", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid header-env",
+			args:    []string{"purl", "--header-env", "Authorization", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid default-scheme",
+			args:    []string{"purl", "--default-scheme", "ftp", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "h2-max-streams is not supported",
+			args:    []string{"purl", "--h2-max-streams", "10", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "h2-initial-window of zero",
+			args:    []string{"purl", "--h2-initial-window", "0", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "h2-initial-window over the HTTP/2 maximum",
+			args:    []string{"purl", "--h2-initial-window", "2147483648", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid abort-on-header",
+			args:    []string{"purl", "--abort-on-header", ": yes", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid connect-retry-interval",
+			args:    []string{"purl", "--connect-only", "--connect-retry-interval", "soon", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid expect-tls-version",
+			args:    []string{"purl", "--expect-tls-version", "1.4", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid body-regex",
+			args:    []string{"purl", "--body-regex", "(", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid tls-resumption",
+			args:    []string{"purl", "--tls-resumption", "maybe", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "path without leading slash",
+			args:    []string{"purl", "--path", "health", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "query without equals",
+			args:    []string{"purl", "--query", "novalue", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "dns-servers hostname",
+			args:    []string{"purl", "--dns-servers", "dns.google", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "proxy with proxy-chain",
+			args:    []string{"purl", "-x", "http://proxy:8080", "--proxy-chain", "http://p1:8080", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "resolve missing address",
+			args:    []string{"purl", "--resolve", "example.com:443", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid assume-content-type",
+			args:    []string{"purl", "--assume-content-type", "not a type", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "non-positive max-latency",
+			args:    []string{"purl", "--max-latency", "0s", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "http2 with http1.1",
+			args:    []string{"purl", "--http2", "--http1.1", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "http1.1 with h2 tuning",
+			args:    []string{"purl", "--http1.1", "--h2-initial-window", "65536", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid probe-order",
+			args:    []string{"purl", "--probe-order", "tls-first", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "zero probe-timeout",
+			args:    []string{"purl", "--probe-timeout", "0s", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid probe-method",
+			args:    []string{"purl", "--probe-method", "POST", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid max-filesize",
+			args:    []string{"purl", "--max-filesize", "10Q", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid output-format",
+			args:    []string{"purl", "--output-format", "xml", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "include-body without json",
+			args:    []string{"purl", "--include-body", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "targets file with target argument",
+			args:    []string{"purl", "--targets-file", "hosts.txt", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "weighted-sample without targets file",
+			args:    []string{"purl", "--weighted-sample", "5", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid connect-to",
+			args:    []string{"purl", "--connect-to", "example.com:443:127.0.0.1", "example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "trace with trace-ascii",
+			args:    []string{"purl", "--trace", "a.txt", "--trace-ascii", "b.txt", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "trace with http2",
+			args:    []string{"purl", "--trace", "-", "--http2", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "no-redact with redact-header",
+			args:    []string{"purl", "--no-redact", "--redact-header", "X-Api-Key", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "parallel without targets file",
+			args:    []string{"purl", "--parallel", "4", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "parallel zero",
+			args:    []string{"purl", "--targets-file", "hosts.txt", "--parallel", "0"},
+			wantErr: true,
+		},
+		{
+			name:    "no-host-header with proxy",
+			args:    []string{"purl", "--no-host-header", "-x", "http://proxy:8080", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "no-host-header with proxy-chain",
+			args:    []string{"purl", "--no-host-header", "--proxy-chain", "socks5://p1:1080", "localhost:8080"},
+			wantErr: true,
+		},
+		{
+			name:    "no-host-header with http2",
+			args:    []string{"purl", "--no-host-header", "--http2", "localhost:8080"},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -458,3 +1127,26 @@ func TestProperty22UnknownFlagRejection(t *testing.T) {
 		gen.AlphaString(),
 	).Check(gopter.DefaultTestParameters())
 }
+
+func TestParseArgs_HeaderEnvUnset(t *testing.T) {
+	_, err := ParseArgs([]string{"purl", "--header-env", "X-Api-Key=PURL_TEST_UNSET_VARIABLE", "localhost:8080"})
+	missing, ok := err.(*errors.MissingEnvError)
+	if !ok {
+		t.Fatalf("Expected MissingEnvError, got %T: %v", err, err)
+	}
+	if missing.Variable != "PURL_TEST_UNSET_VARIABLE" || missing.Header != "X-Api-Key" {
+		t.Errorf("Unexpected error fields: %+v", missing)
+	}
+	if code := errors.MapErrorToExitCode(err); code != errors.ExitUnknownFlag {
+		t.Errorf("Expected exit code %d, got %d", errors.ExitUnknownFlag, code)
+	}
+
+	t.Setenv("PURL_TEST_API_TOKEN", "")
+	opts, err := ParseArgs([]string{"purl", "--header-env", "Authorization=PURL_TEST_API_TOKEN", "localhost:8080"})
+	if err != nil {
+		t.Fatalf("Expected a set but empty variable to be accepted, got: %v", err)
+	}
+	if len(opts.HeaderEnv) != 1 {
+		t.Errorf("Expected one header-env mapping, got %v", opts.HeaderEnv)
+	}
+}
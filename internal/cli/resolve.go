@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ParseResolve parses a curl-style --resolve entry HOST:PORT:ADDRESS
+// Returns the "host:port" to match and the "address:port" to dial instead
+// ADDRESS may be an IPv6 address, with or without brackets
+func ParseResolve(spec string) (string, string, error) {
+	host, rest, found := strings.Cut(spec, ":")
+	if !found || host == "" {
+		return "", "", fmt.Errorf("expected HOST:PORT:ADDRESS, got %q", spec)
+	}
+	port, address, found := strings.Cut(rest, ":")
+	if !found {
+		return "", "", fmt.Errorf("expected HOST:PORT:ADDRESS, got %q", spec)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", "", fmt.Errorf("invalid port %q in %q", port, spec)
+	}
+
+	address = strings.TrimSuffix(strings.TrimPrefix(address, "["), "]")
+	if net.ParseIP(address) == nil {
+		return "", "", fmt.Errorf("%q is not an IP address in %q", address, spec)
+	}
+
+	return net.JoinHostPort(strings.ToLower(host), port), net.JoinHostPort(address, port), nil
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"testing"
+)
+
+func TestParseResolve(t *testing.T) {
+	tests := []struct {
+		spec      string
+		wantMatch string
+		wantDial  string
+		wantErr   bool
+	}{
+		{"example.com:443:127.0.0.1", "example.com:443", "127.0.0.1:443", false},
+		{"Staging.Example.com:8080:10.0.0.5", "staging.example.com:8080", "10.0.0.5:8080", false},
+		{"example.com:443:::1", "example.com:443", "[::1]:443", false},
+		{"example.com:443:[2001:db8::1]", "example.com:443", "[2001:db8::1]:443", false},
+		{"example.com:443", "", "", true},
+		{"example.com:https:127.0.0.1", "", "", true},
+		{"example.com:443:not-an-ip", "", "", true},
+		{":443:127.0.0.1", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			match, dial, err := ParseResolve(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseResolve(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if match != tt.wantMatch || dial != tt.wantDial {
+				t.Errorf("ParseResolve(%q) = (%q, %q), want (%q, %q)", tt.spec, match, dial, tt.wantMatch, tt.wantDial)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sizeSuffixes maps size suffixes to their multipliers (binary, as curl uses)
+var sizeSuffixes = map[string]int64{
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+}
+
+// ParseSize parses a byte count with an optional K, M or G suffix (e.g., 500, 10K, 2M)
+func ParseSize(input string) (int64, error) {
+	size := strings.TrimSpace(input)
+	multiplier := int64(1)
+	if n := len(size); n > 0 {
+		if m, ok := sizeSuffixes[strings.ToUpper(size[n-1:])]; ok {
+			multiplier = m
+			size = size[:n-1]
+		}
+	}
+
+	value, err := strconv.ParseInt(size, 10, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size: %q", input)
+	}
+	if value > (1<<63-1)/multiplier {
+		return 0, fmt.Errorf("size too large: %q", input)
+	}
+	return value * multiplier, nil
+}
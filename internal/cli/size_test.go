@@ -0,0 +1,35 @@
+package cli
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input     string
+		want      int64
+		shouldErr bool
+	}{
+		{input: "500", want: 500},
+		{input: "10K", want: 10 << 10},
+		{input: "10k", want: 10 << 10},
+		{input: "2M", want: 2 << 20},
+		{input: "1G", want: 1 << 30},
+		{input: "", shouldErr: true},
+		{input: "M", shouldErr: true},
+		{input: "1.5M", shouldErr: true},
+		{input: "-1", shouldErr: true},
+		{input: "10T", shouldErr: true},
+		{input: "99999999999G", shouldErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSize(tt.input)
+			if (err != nil) != tt.shouldErr {
+				t.Fatalf("ParseSize(%q) error = %v, shouldErr %v", tt.input, err, tt.shouldErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSize(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StatusRange is an inclusive range of HTTP status codes
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// StatusSpec is a set of HTTP status code ranges
+type StatusSpec []StatusRange
+
+// ParseStatusSpec parses a comma-separated status specification
+// Supports formats:
+// - class (e.g., 2xx)
+// - range (e.g., 200-299)
+// - single code (e.g., 301)
+func ParseStatusSpec(spec string) (StatusSpec, error) {
+	var result StatusSpec
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		// Status class such as "2xx"
+		if len(part) == 3 && strings.HasSuffix(strings.ToLower(part), "xx") {
+			class, err := strconv.Atoi(part[:1])
+			if err != nil || class < 1 || class > 5 {
+				return nil, fmt.Errorf("invalid status class: %s", part)
+			}
+			result = append(result, StatusRange{Min: class * 100, Max: class*100 + 99})
+			continue
+		}
+
+		// Status range such as "200-299"
+		if lo, hi, found := strings.Cut(part, "-"); found {
+			min, err := parseStatusCode(lo)
+			if err != nil {
+				return nil, err
+			}
+			max, err := parseStatusCode(hi)
+			if err != nil {
+				return nil, err
+			}
+			if min > max {
+				return nil, fmt.Errorf("invalid status range: %s", part)
+			}
+			result = append(result, StatusRange{Min: min, Max: max})
+			continue
+		}
+
+		// Single status code
+		code, err := parseStatusCode(part)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, StatusRange{Min: code, Max: code})
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("empty status specification")
+	}
+
+	return result, nil
+}
+
+// Matches reports whether the status code falls within any range of the spec
+func (s StatusSpec) Matches(code int) bool {
+	for _, r := range s {
+		if code >= r.Min && code <= r.Max {
+			return true
+		}
+	}
+	return false
+}
+
+// parseStatusCode parses a single three-digit HTTP status code
+func parseStatusCode(s string) (int, error) {
+	code, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || code < 100 || code > 599 {
+		return 0, fmt.Errorf("invalid status code: %s", s)
+	}
+	return code, nil
+}
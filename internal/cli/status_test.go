@@ -0,0 +1,77 @@
+package cli
+
+import "testing"
+
+func TestParseStatusSpec(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      string
+		shouldErr bool
+		matches   []int
+		rejects   []int
+	}{
+		{
+			name:    "status class",
+			spec:    "2xx",
+			matches: []int{200, 204, 299},
+			rejects: []int{199, 301, 404},
+		},
+		{
+			name:    "status range",
+			spec:    "200-302",
+			matches: []int{200, 301, 302},
+			rejects: []int{303, 404},
+		},
+		{
+			name:    "list of codes",
+			spec:    "200, 204,301",
+			matches: []int{200, 204, 301},
+			rejects: []int{201, 302},
+		},
+		{
+			name:      "invalid class",
+			spec:      "9xx",
+			shouldErr: true,
+		},
+		{
+			name:      "inverted range",
+			spec:      "299-200",
+			shouldErr: true,
+		},
+		{
+			name:      "non-numeric code",
+			spec:      "abc",
+			shouldErr: true,
+		},
+		{
+			name:      "empty spec",
+			spec:      "",
+			shouldErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := ParseStatusSpec(tt.spec)
+			if tt.shouldErr {
+				if err == nil {
+					t.Errorf("expected error for %q, got nil", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, code := range tt.matches {
+				if !spec.Matches(code) {
+					t.Errorf("expected %q to match %d", tt.spec, code)
+				}
+			}
+			for _, code := range tt.rejects {
+				if spec.Matches(code) {
+					t.Errorf("expected %q not to match %d", tt.spec, code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// redacted replaces secret values in traced configuration
+const redacted = "REDACTED"
+
+// sensitiveHeaderWords mark header names whose values are redacted
+var sensitiveHeaderWords = []string{"auth", "token", "key", "secret", "cookie", "session"}
+
+// WriteTraceConfig writes the resolved options as indented JSON with secrets redacted
+func WriteTraceConfig(w io.Writer, opts *Options) error {
+	data, err := json.MarshalIndent(redactOptions(opts), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// redactOptions returns a copy of opts with credentials and tokens masked
+func redactOptions(opts *Options) *Options {
+	clone := *opts
+
+	// Keep the username, hide the password
+	if clone.User != "" {
+		if name, _, found := strings.Cut(clone.User, ":"); found {
+			clone.User = name + ":" + redacted
+		}
+	}
+
+	if clone.Cookie != "" {
+		clone.Cookie = redacted
+	}
+
+	// Request bodies often carry credentials; only their size is traced
+	clone.Data = redactBody(clone.Data)
+	clone.DataRaw = redactBody(clone.DataRaw)
+	if len(opts.DataURLEncode) > 0 {
+		clone.DataURLEncode = make([]string, len(opts.DataURLEncode))
+		for i, value := range opts.DataURLEncode {
+			clone.DataURLEncode[i] = redactBody(value)
+		}
+	}
+
+	if len(opts.Headers) > 0 {
+		clone.Headers = make([]string, len(opts.Headers))
+		for i, header := range opts.Headers {
+			clone.Headers[i] = redactHeader(header)
+		}
+	}
+
+	// Target credentials are sent as Basic auth
+	if clone.Target != "" {
+		clone.Target = redactTarget(clone.Target)
+	}
+
+	if clone.Proxy != "" {
+		clone.Proxy = redactURL(clone.Proxy)
+	}
+
+	if len(opts.ProxyChain) > 0 {
+		clone.ProxyChain = make([]string, len(opts.ProxyChain))
+		for i, proxy := range opts.ProxyChain {
+			clone.ProxyChain[i] = redactURL(proxy)
+		}
+	}
+
+	if opts.Next != nil {
+		clone.Next = redactOptions(opts.Next)
+	}
+
+	return &clone
+}
+
+// redactHeader masks the value of a "Name: value" header with a sensitive name
+func redactHeader(header string) string {
+	name, _, found := strings.Cut(header, ":")
+	if !found {
+		return header
+	}
+	lower := strings.ToLower(name)
+	for _, word := range sensitiveHeaderWords {
+		if strings.Contains(lower, word) {
+			return name + ": " + redacted
+		}
+	}
+	return header
+}
+
+// redactBody replaces a request body with its size, e.g. "REDACTED (42 bytes)"
+func redactBody(body string) string {
+	if body == "" {
+		return body
+	}
+	return fmt.Sprintf("%s (%d bytes)", redacted, len(body))
+}
+
+// redactTarget masks the password of a target's user:password@, with or without a scheme
+func redactTarget(target string) string {
+	if strings.Contains(target, "://") {
+		return redactURL(target)
+	}
+
+	hostPort, path := target, ""
+	if slash := strings.Index(target, "/"); slash != -1 {
+		hostPort, path = target[:slash], target[slash:]
+	}
+	at := strings.LastIndex(hostPort, "@")
+	if at == -1 {
+		return target
+	}
+	name, _, found := strings.Cut(hostPort[:at], ":")
+	if !found {
+		return target
+	}
+	return name + ":" + redacted + hostPort[at:] + path
+}
+
+// redactURL masks the password embedded in a URL
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+	return u.String()
+}
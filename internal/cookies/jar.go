@@ -0,0 +1,222 @@
+package cookies
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// httpOnlyPrefix marks HttpOnly cookies in Netscape cookie files
+const httpOnlyPrefix = "#HttpOnly_"
+
+// Entry is one cookie as stored in a Netscape cookie file
+type Entry struct {
+	Domain            string    // host the cookie belongs to, without a leading dot
+	IncludeSubdomains bool      // false for host-only cookies
+	Path              string    // path the cookie is scoped to
+	Secure            bool      // only sent over HTTPS
+	HttpOnly          bool      // hidden from scripts in browsers
+	Expires           time.Time // zero for session cookies
+	Name              string
+	Value             string
+}
+
+// Jar is an http.CookieJar that also remembers each cookie's attributes
+// so the jar can be written back as a Netscape cookie file (-c)
+type Jar struct {
+	mu      sync.Mutex
+	jar     *cookiejar.Jar
+	entries []Entry
+	now     func() time.Time
+}
+
+// NewJar creates an empty jar
+func NewJar() *Jar {
+	jar, _ := cookiejar.New(nil)
+	return &Jar{jar: jar, now: time.Now}
+}
+
+// SetCookies stores the cookies received from u, following redirects included
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, cookie := range cookies {
+		entry := Entry{
+			Domain:   u.Hostname(),
+			Path:     cookie.Path,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+		}
+		if cookie.Domain != "" {
+			entry.Domain = strings.TrimPrefix(cookie.Domain, ".")
+			entry.IncludeSubdomains = true
+		}
+		if !strings.HasPrefix(entry.Path, "/") {
+			entry.Path = defaultPath(u.Path)
+		}
+
+		switch {
+		case cookie.MaxAge < 0:
+			entry.Expires = time.Unix(1, 0) // deleted
+		case cookie.MaxAge > 0:
+			entry.Expires = j.now().Add(time.Duration(cookie.MaxAge) * time.Second)
+		case !cookie.Expires.IsZero():
+			entry.Expires = cookie.Expires
+		}
+		j.store(entry)
+	}
+}
+
+// Cookies returns the cookies to send to u
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// Entries returns the cookies that have not expired, in the order they were first set
+func (j *Jar) Entries() []Entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := j.now()
+	var live []Entry
+	for _, entry := range j.entries {
+		if entry.Expires.IsZero() || entry.Expires.After(now) {
+			live = append(live, entry)
+		}
+	}
+	return live
+}
+
+// store adds entry, replacing a cookie with the same name, domain and path
+func (j *Jar) store(entry Entry) {
+	for i, existing := range j.entries {
+		if existing.Name == entry.Name && existing.Domain == entry.Domain && existing.Path == entry.Path {
+			j.entries[i] = entry
+			return
+		}
+	}
+	j.entries = append(j.entries, entry)
+}
+
+// Load reads a Netscape cookie file into the jar; expired cookies are skipped
+func (j *Jar) Load(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := strings.HasPrefix(line, httpOnlyPrefix)
+		line = strings.TrimPrefix(line, httpOnlyPrefix)
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return fmt.Errorf("cookie file line %d: expected 7 tab-separated fields, got %d", lineNum, len(fields))
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return fmt.Errorf("cookie file line %d: invalid expiry %q", lineNum, fields[4])
+		}
+
+		entry := Entry{
+			Domain:            strings.TrimPrefix(fields[0], "."),
+			IncludeSubdomains: strings.EqualFold(fields[1], "TRUE"),
+			Path:              fields[2],
+			Secure:            strings.EqualFold(fields[3], "TRUE"),
+			HttpOnly:          httpOnly,
+			Name:              fields[5],
+			Value:             fields[6],
+		}
+		if expires > 0 {
+			entry.Expires = time.Unix(expires, 0)
+			if !entry.Expires.After(j.now()) {
+				continue
+			}
+		}
+		j.add(entry)
+	}
+	return scanner.Err()
+}
+
+// add stores a cookie read from a file, as if entry's host had set it
+func (j *Jar) add(entry Entry) {
+	scheme := "http"
+	if entry.Secure {
+		scheme = "https"
+	}
+	u := &url.URL{Scheme: scheme, Host: entry.Domain, Path: entry.Path}
+
+	cookie := &http.Cookie{
+		Name:     entry.Name,
+		Value:    entry.Value,
+		Path:     entry.Path,
+		Secure:   entry.Secure,
+		HttpOnly: entry.HttpOnly,
+		Expires:  entry.Expires,
+	}
+	if entry.IncludeSubdomains {
+		cookie.Domain = entry.Domain
+	}
+	j.jar.SetCookies(u, []*http.Cookie{cookie})
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.store(entry)
+}
+
+// Write writes the jar's cookies in the Netscape cookie file format used by curl -c
+// Session cookies get an expiry of 0
+func (j *Jar) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# Netscape HTTP Cookie File")
+	fmt.Fprintln(bw, "# Written by purl; edit at your own risk")
+	fmt.Fprintln(bw)
+
+	for _, entry := range j.Entries() {
+		domain := entry.Domain
+		if entry.IncludeSubdomains {
+			domain = "." + domain
+		}
+		if entry.HttpOnly {
+			domain = httpOnlyPrefix + domain
+		}
+
+		var expires int64
+		if !entry.Expires.IsZero() {
+			expires = entry.Expires.Unix()
+		}
+
+		fmt.Fprintf(bw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain, netscapeBool(entry.IncludeSubdomains), entry.Path, netscapeBool(entry.Secure), expires, entry.Name, entry.Value)
+	}
+
+	return bw.Flush()
+}
+
+// defaultPath returns the RFC 6265 default cookie path for a request path
+func defaultPath(requestPath string) string {
+	if !strings.HasPrefix(requestPath, "/") || strings.Count(requestPath, "/") == 1 {
+		return "/"
+	}
+	return path.Dir(requestPath)
+}
+
+// netscapeBool formats a flag as the TRUE/FALSE used by cookie files
+func netscapeBool(value bool) string {
+	if value {
+		return "TRUE"
+	}
+	return "FALSE"
+}
@@ -0,0 +1,111 @@
+package cookies
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestJar(now time.Time) *Jar {
+	jar := NewJar()
+	jar.now = func() time.Time { return now }
+	return jar
+}
+
+func TestJar_Write(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Add("Set-Cookie", "session=abc123; Path=/app; Secure; HttpOnly")
+	resp.Header.Add("Set-Cookie", "theme=dark; Domain=example.com; Expires=Wed, 21 Oct 2065 07:28:00 GMT")
+	resp.Header.Add("Set-Cookie", "token=x; Max-Age=60")
+
+	reqURL, _ := url.Parse("https://www.example.com/login")
+	jar := newTestJar(time.Unix(1700000000, 0))
+	jar.SetCookies(reqURL, resp.Cookies())
+
+	var out strings.Builder
+	if err := jar.Write(&out); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if lines[0] != "# Netscape HTTP Cookie File" {
+		t.Errorf("Expected the Netscape header, got %q", lines[0])
+	}
+
+	want := []string{
+		"#HttpOnly_www.example.com\tFALSE\t/app\tTRUE\t0\tsession\tabc123",
+		".example.com\tTRUE\t/\tFALSE\t3023335680\ttheme\tdark",
+		"www.example.com\tFALSE\t/\tFALSE\t1700000060\ttoken\tx",
+	}
+	got := lines[len(lines)-3:]
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestJar_RoundTrip(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	file := "# Netscape HTTP Cookie File\n" +
+		"\n" +
+		"#HttpOnly_api.example.com\tFALSE\t/\tTRUE\t0\tsid\ts1\n" +
+		".example.com\tTRUE\t/\tFALSE\t1800000000\tlang\ten\n" +
+		"old.example.com\tFALSE\t/\tFALSE\t1600000000\tstale\tx\n"
+
+	jar := newTestJar(now)
+	if err := jar.Load(strings.NewReader(file)); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	// Loaded cookies are sent to matching hosts
+	u, _ := url.Parse("https://api.example.com/v1")
+	sent := map[string]string{}
+	for _, cookie := range jar.Cookies(u) {
+		sent[cookie.Name] = cookie.Value
+	}
+	if sent["sid"] != "s1" || sent["lang"] != "en" || len(sent) != 2 {
+		t.Errorf("Expected sid and lang for api.example.com, got %v", sent)
+	}
+
+	// A response updates one cookie and deletes another
+	jar.SetCookies(u, []*http.Cookie{{Name: "sid", Value: "s2", Path: "/", Secure: true, HttpOnly: true}, {Name: "lang", Domain: "example.com", Path: "/", MaxAge: -1}})
+
+	var out strings.Builder
+	if err := jar.Write(&out); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	reloaded := newTestJar(now)
+	if err := reloaded.Load(strings.NewReader(out.String())); err != nil {
+		t.Fatalf("Load of written jar failed: %v\n%s", err, out.String())
+	}
+	entries := reloaded.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 cookie after the round trip, got %d:\n%s", len(entries), out.String())
+	}
+	want := Entry{Domain: "api.example.com", Path: "/", Secure: true, HttpOnly: true, Name: "sid", Value: "s2"}
+	if entries[0] != want {
+		t.Errorf("entry = %+v, want %+v", entries[0], want)
+	}
+}
+
+func TestJar_LoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"too few fields", "example.com\tFALSE\t/\tFALSE\t0\tname\n"},
+		{"invalid expiry", "example.com\tFALSE\t/\tFALSE\tsoon\tname\tvalue\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NewJar().Load(strings.NewReader(tt.file)); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
@@ -7,15 +7,59 @@ import (
 
 // Exit code constants matching curl's exit codes
 const (
-	ExitSuccess       = 0
-	ExitUnknownFlag   = 2
-	ExitURLParse      = 3
-	ExitNoRoute       = 6
-	ExitConnectFailed = 7
-	ExitTimeout       = 28
-	ExitTLSError      = 35
+	ExitSuccess          = 0
+	ExitAssertionFailed  = 1
+	ExitUnknownFlag      = 2
+	ExitURLParse         = 3
+	ExitNoRoute          = 6
+	ExitConnectFailed    = 7
+	ExitHTTPError        = 22
+	ExitReadError        = 26
+	ExitTimeout          = 28
+	ExitTLSError         = 35
+	ExitAborted          = 42
+	ExitTooManyRedirects = 47
+	ExitLatencyExceeded  = 50 // not a curl code; curl leaves 50 unused
+	ExitBadEncoding      = 61
+	ExitFileSizeExceeded = 63
+	ExitInterrupted      = 130
 )
 
+// exitMeanings describes each exit code for --explain-exit
+var exitMeanings = map[int]string{
+	ExitSuccess:          "success",
+	ExitAssertionFailed:  "response assertion failed",
+	ExitUnknownFlag:      "invalid usage",
+	ExitURLParse:         "malformed URL",
+	ExitNoRoute:          "could not resolve host or no route",
+	ExitConnectFailed:    "failed to connect",
+	ExitHTTPError:        "HTTP error status",
+	ExitReadError:        "failed to read a local file",
+	ExitTimeout:          "operation timed out",
+	ExitTLSError:         "TLS/SSL error",
+	ExitAborted:          "transfer aborted",
+	ExitTooManyRedirects: "redirect failure",
+	ExitLatencyExceeded:  "response slower than --max-latency",
+	ExitBadEncoding:      "could not decode the response body",
+	ExitFileSizeExceeded: "response larger than --max-filesize",
+	ExitInterrupted:      "interrupted",
+}
+
+// ExitCodeMeaning returns a short description of an exit code
+func ExitCodeMeaning(code int) string {
+	if meaning, ok := exitMeanings[code]; ok {
+		return meaning
+	}
+	return "unknown error"
+}
+
+// ExplainExit describes the exit code err maps to and why,
+// e.g. "exit 35 (TLS/SSL error): TLS error for host: certificate expired"
+func ExplainExit(err error) string {
+	code := MapErrorToExitCode(err)
+	return fmt.Sprintf("exit %d (%s): %v", code, ExitCodeMeaning(code), err)
+}
+
 // URLParseError represents an error parsing the target URL
 type URLParseError struct {
 	Input   string
@@ -37,6 +81,10 @@ func (e *ConnectionError) Error() string {
 	return fmt.Sprintf("connection error to %s:%s: %v", e.Host, e.Port, e.Cause)
 }
 
+func (e *ConnectionError) Unwrap() error {
+	return e.Cause
+}
+
 // TimeoutError represents a timeout during request or connection
 type TimeoutError struct {
 	Duration time.Duration
@@ -66,6 +114,16 @@ func (e *UnknownFlagError) Error() string {
 	return fmt.Sprintf("unknown flag: %s", e.Flag)
 }
 
+// MissingEnvError represents a --header-env variable that is not set
+type MissingEnvError struct {
+	Variable string
+	Header   string
+}
+
+func (e *MissingEnvError) Error() string {
+	return fmt.Sprintf("environment variable %s for header %s is not set", e.Variable, e.Header)
+}
+
 // NoRouteError represents a DNS resolution or routing failure
 type NoRouteError struct {
 	Host  string
@@ -76,6 +134,165 @@ func (e *NoRouteError) Error() string {
 	return fmt.Sprintf("no route to host %s: %v", e.Host, e.Cause)
 }
 
+// InterruptedError represents an operation cancelled by SIGINT or SIGTERM
+type InterruptedError struct {
+	Cause error
+}
+
+func (e *InterruptedError) Error() string {
+	return fmt.Sprintf("interrupted: %v", e.Cause)
+}
+
+// RedirectLoopError represents a redirect chain that revisits a URL
+type RedirectLoopError struct {
+	URL string
+}
+
+func (e *RedirectLoopError) Error() string {
+	return fmt.Sprintf("redirect loop detected: %s was already visited", e.URL)
+}
+
+// TooManyRedirectsError represents a redirect chain exceeding --max-redirs
+type TooManyRedirectsError struct {
+	Max int
+}
+
+func (e *TooManyRedirectsError) Error() string {
+	return fmt.Sprintf("maximum (%d) redirects followed", e.Max)
+}
+
+// UnexpectedRedirectError represents a 3xx response rejected by --fail-if-redirect
+type UnexpectedRedirectError struct {
+	StatusCode int
+	Location   string
+}
+
+func (e *UnexpectedRedirectError) Error() string {
+	return fmt.Sprintf("unexpected redirect: %d to %s", e.StatusCode, e.Location)
+}
+
+// AbortedError represents a response abandoned by --abort-on-header before its body was read
+type AbortedError struct {
+	Name  string
+	Value string
+}
+
+func (e *AbortedError) Error() string {
+	return fmt.Sprintf("aborted: response header %s: %s matched --abort-on-header", e.Name, e.Value)
+}
+
+// TooManyTargetsError represents an expansion exceeding --max-total-targets
+type TooManyTargetsError struct {
+	Count int
+	Max   int
+}
+
+func (e *TooManyTargetsError) Error() string {
+	return fmt.Sprintf("%d targets exceed --max-total-targets %d; raise the cap if this is intended", e.Count, e.Max)
+}
+
+// HTTPError represents a response status of 400 or above under -f/--fail
+type HTTPError struct {
+	StatusCode int
+	URL        string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("the requested URL returned error: %d (%s)", e.StatusCode, e.URL)
+}
+
+// InvalidJSONBodyError represents a --json body that is not valid JSON under --json-strict
+type InvalidJSONBodyError struct {
+	Cause error
+}
+
+func (e *InvalidJSONBodyError) Error() string {
+	return fmt.Sprintf("--json body is not valid JSON: %v", e.Cause)
+}
+
+func (e *InvalidJSONBodyError) Unwrap() error {
+	return e.Cause
+}
+
+// BodyMismatchError represents a body that failed --body-regex (or --body-regex-absent)
+type BodyMismatchError struct {
+	Pattern string
+	Absent  bool
+}
+
+func (e *BodyMismatchError) Error() string {
+	if e.Absent {
+		return fmt.Sprintf("body matched forbidden pattern %q", e.Pattern)
+	}
+	return fmt.Sprintf("body did not match pattern %q", e.Pattern)
+}
+
+// LatencyError represents a response slower than --max-latency
+type LatencyError struct {
+	URL       string
+	Elapsed   time.Duration
+	Threshold time.Duration
+}
+
+func (e *LatencyError) Error() string {
+	return fmt.Sprintf("%s took %s, exceeding --max-latency %s", e.URL, e.Elapsed.Round(time.Millisecond), e.Threshold)
+}
+
+// SizeLimitError represents a response body larger than --max-filesize
+type SizeLimitError struct {
+	URL   string
+	Limit int64
+	Size  int64 // announced Content-Length, -1 when the limit was hit while streaming
+}
+
+func (e *SizeLimitError) Error() string {
+	if e.Size >= 0 {
+		return fmt.Sprintf("%s is %d bytes, exceeding --max-filesize %d", e.URL, e.Size, e.Limit)
+	}
+	return fmt.Sprintf("%s exceeded --max-filesize %d bytes", e.URL, e.Limit)
+}
+
+// DecodeError represents a --compressed body that could not be decoded
+type DecodeError struct {
+	Encoding string
+	Cause    error // nil when the encoding is not supported
+}
+
+func (e *DecodeError) Error() string {
+	if e.Cause == nil {
+		return fmt.Sprintf("unsupported Content-Encoding: %s", e.Encoding)
+	}
+	return fmt.Sprintf("failed to decode %s response: %v", e.Encoding, e.Cause)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Cause
+}
+
+// FileReadError represents a local input file (-d @file, a HAR or targets file) that could not be read
+type FileReadError struct {
+	Path  string
+	Cause error
+}
+
+func (e *FileReadError) Error() string {
+	return fmt.Sprintf("failed to read %s: %v", e.Path, e.Cause)
+}
+
+func (e *FileReadError) Unwrap() error {
+	return e.Cause
+}
+
+// ContentLengthMismatchError represents a HEAD Content-Length that disagrees with the GET body (--head-body-check)
+type ContentLengthMismatchError struct {
+	Head   int64
+	Actual int64
+}
+
+func (e *ContentLengthMismatchError) Error() string {
+	return fmt.Sprintf("HEAD Content-Length %d does not match GET body size %d", e.Head, e.Actual)
+}
+
 // MapErrorToExitCode maps error types to curl-compatible exit codes
 func MapErrorToExitCode(err error) int {
 	if err == nil {
@@ -85,17 +302,39 @@ func MapErrorToExitCode(err error) int {
 	switch err.(type) {
 	case *URLParseError:
 		return ExitURLParse
-	case *UnknownFlagError:
+	case *UnknownFlagError, *MissingEnvError, *TooManyTargetsError, *InvalidJSONBodyError:
 		return ExitUnknownFlag
 	case *NoRouteError:
 		return ExitNoRoute
 	case *ConnectionError:
 		return ExitConnectFailed
+	case *HTTPError:
+		return ExitHTTPError
+	case *FileReadError:
+		return ExitReadError
 	case *TimeoutError:
 		return ExitTimeout
 	case *TLSError:
 		return ExitTLSError
+	case *InterruptedError:
+		return ExitInterrupted
+	case *RedirectLoopError, *TooManyRedirectsError, *UnexpectedRedirectError:
+		return ExitTooManyRedirects
+	case *AbortedError:
+		return ExitAborted
+	case *BodyMismatchError, *ContentLengthMismatchError:
+		return ExitAssertionFailed
+	case *LatencyError:
+		return ExitLatencyExceeded
+	case *SizeLimitError:
+		return ExitFileSizeExceeded
+	case *DecodeError:
+		return ExitBadEncoding
 	default:
+		// Map the cause of wrapped errors such as *url.Error
+		if wrapped, ok := err.(interface{ Unwrap() error }); ok && wrapped.Unwrap() != nil {
+			return MapErrorToExitCode(wrapped.Unwrap())
+		}
 		// Default to connection error for unknown errors
 		return ExitConnectFailed
 	}
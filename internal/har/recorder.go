@@ -0,0 +1,199 @@
+package har
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Recorder accumulates request/response pairs as HAR entries
+// It is safe for concurrent use
+type Recorder struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// Timer captures connection phase timestamps through httptrace hooks
+type Timer struct {
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	gotConn      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Trace returns req with httptrace hooks attached and the timer they fill in
+func Trace(req *http.Request) (*http.Request, *Timer) {
+	timer := &Timer{start: time.Now()}
+	trace := &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { timer.dnsStart = time.Now() },
+		DNSDone:              func(httptrace.DNSDoneInfo) { timer.dnsDone = time.Now() },
+		ConnectStart:         func(string, string) { timer.connectStart = time.Now() },
+		ConnectDone:          func(string, string, error) { timer.connectDone = time.Now() },
+		TLSHandshakeStart:    func() { timer.tlsStart = time.Now() },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { timer.tlsDone = time.Now() },
+		GotConn:              func(httptrace.GotConnInfo) { timer.gotConn = time.Now() },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { timer.wroteRequest = time.Now() },
+		GotFirstResponseByte: func() { timer.firstByte = time.Now() },
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), timer
+}
+
+// Add records a completed exchange; call it once the response body has been read
+// so the receive phase is measured
+func (r *Recorder) Add(req *http.Request, resp *http.Response, timer *Timer) {
+	end := time.Now()
+	timings := timer.timings(end)
+
+	entry := Entry{
+		StartedDateTime: timer.start.Format(time.RFC3339Nano),
+		Time:            milliseconds(timer.start, end),
+		Request:         newRequest(req),
+		Response:        newResponse(resp),
+		Timings:         timings,
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+// Write writes the recorded entries as a HAR document
+func (r *Recorder) Write(w io.Writer) error {
+	r.mu.Lock()
+	entries := append([]Entry{}, r.entries...)
+	r.mu.Unlock()
+
+	archive := HAR{
+		Log: Log{
+			Version: "1.2",
+			Creator: Creator{Name: "purl", Version: "1.0"},
+			Entries: entries,
+		},
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(archive); err != nil {
+		return fmt.Errorf("failed to write HAR: %w", err)
+	}
+	return nil
+}
+
+// timings converts the captured timestamps into HAR phase durations
+// Phases that did not happen, such as DNS on a reused connection, are -1
+func (t *Timer) timings(end time.Time) Timings {
+	timings := Timings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1}
+
+	if !t.dnsStart.IsZero() && !t.dnsDone.IsZero() {
+		timings.DNS = milliseconds(t.dnsStart, t.dnsDone)
+	}
+	if !t.connectStart.IsZero() && !t.connectDone.IsZero() {
+		timings.Connect = milliseconds(t.connectStart, t.connectDone)
+	}
+	if !t.tlsStart.IsZero() && !t.tlsDone.IsZero() {
+		timings.SSL = milliseconds(t.tlsStart, t.tlsDone)
+		// HAR counts the TLS handshake as part of connect
+		if timings.Connect >= 0 {
+			timings.Connect += timings.SSL
+		}
+	}
+	if !t.gotConn.IsZero() && !t.wroteRequest.IsZero() {
+		timings.Send = milliseconds(t.gotConn, t.wroteRequest)
+	}
+	if !t.wroteRequest.IsZero() && !t.firstByte.IsZero() {
+		timings.Wait = milliseconds(t.wroteRequest, t.firstByte)
+	}
+	if !t.firstByte.IsZero() {
+		timings.Receive = milliseconds(t.firstByte, end)
+	}
+
+	return timings
+}
+
+// newRequest converts an http.Request into its HAR form
+func newRequest(req *http.Request) Request {
+	harReq := Request{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: req.Proto,
+		Headers:     headerPairs(req.Header),
+		QueryString: []NameValue{},
+		Cookies:     []NameValue{},
+		HeadersSize: -1,
+		BodySize:    int(req.ContentLength),
+	}
+
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			harReq.QueryString = append(harReq.QueryString, NameValue{Name: name, Value: value})
+		}
+	}
+	sort.Slice(harReq.QueryString, func(i, j int) bool {
+		return harReq.QueryString[i].Name < harReq.QueryString[j].Name
+	})
+
+	// Read the body through GetBody so the sent request is untouched
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			body.Close()
+			harReq.PostData = &PostData{MimeType: req.Header.Get("Content-Type"), Text: string(data)}
+		}
+	}
+
+	return harReq
+}
+
+// newResponse converts an http.Response into its HAR form
+func newResponse(resp *http.Response) Response {
+	return Response{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Headers:     headerPairs(resp.Header),
+		Cookies:     []NameValue{},
+		Content: Content{
+			Size:     int(resp.ContentLength),
+			MimeType: resp.Header.Get("Content-Type"),
+		},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    int(resp.ContentLength),
+	}
+}
+
+// headerPairs flattens headers into name/value pairs sorted by name
+func headerPairs(header http.Header) []NameValue {
+	pairs := []NameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			pairs = append(pairs, NameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].Name < pairs[j].Name
+	})
+	return pairs
+}
+
+// milliseconds returns the duration between two instants in milliseconds
+func milliseconds(from, to time.Time) float64 {
+	return float64(to.Sub(from)) / float64(time.Millisecond)
+}
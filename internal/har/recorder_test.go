@@ -0,0 +1,70 @@
+package har
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecorder_RecordsEntry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/submit?a=1", strings.NewReader("payload"))
+	req.Header.Set("X-Test", "yes")
+
+	recorder := NewRecorder()
+	req, timer := Trace(req)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+	recorder.Add(req, resp, timer)
+
+	var buf bytes.Buffer
+	if err := recorder.Write(&buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	archive, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("recorded HAR does not parse: %v", err)
+	}
+	if archive.Log.Version != "1.2" {
+		t.Errorf("Expected HAR version 1.2, got %q", archive.Log.Version)
+	}
+	if len(archive.Log.Entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(archive.Log.Entries))
+	}
+
+	entry := archive.Log.Entries[0]
+	if entry.Request.Method != "POST" || entry.Request.URL != server.URL+"/submit?a=1" {
+		t.Errorf("request = %s %s", entry.Request.Method, entry.Request.URL)
+	}
+	if entry.Request.PostData == nil || entry.Request.PostData.Text != "payload" {
+		t.Errorf("request body not recorded: %+v", entry.Request.PostData)
+	}
+	if len(entry.Request.QueryString) != 1 || entry.Request.QueryString[0] != (NameValue{Name: "a", Value: "1"}) {
+		t.Errorf("query string = %+v", entry.Request.QueryString)
+	}
+	if entry.Response.Status != 200 || entry.Response.Content.MimeType != "text/plain" {
+		t.Errorf("response = %d %q", entry.Response.Status, entry.Response.Content.MimeType)
+	}
+	if entry.Timings.Connect < 0 || entry.Timings.Wait < 0 {
+		t.Errorf("expected connect and wait timings, got %+v", entry.Timings)
+	}
+	if entry.Timings.SSL != -1 {
+		t.Errorf("expected no TLS timing for plain HTTP, got %v", entry.Timings.SSL)
+	}
+	if entry.Time <= 0 {
+		t.Errorf("expected positive total time, got %v", entry.Time)
+	}
+}
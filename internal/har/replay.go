@@ -0,0 +1,77 @@
+package har
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+// skippedHeaders are recomputed by the transport rather than replayed
+// Accept-Encoding is left to the transport so compressed bodies are decoded
+var skippedHeaders = map[string]bool{
+	"host":              true,
+	"content-length":    true,
+	"connection":        true,
+	"accept-encoding":   true,
+	"transfer-encoding": true,
+}
+
+// ReplayOptions reads a HAR file and returns one request per entry chained through Options.Next
+// Each request inherits the remaining settings (TLS, timeouts, output) from base
+// Any --next requests after base run once the replayed ones are done
+func ReplayOptions(path string, base *cli.Options) (*cli.Options, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, &errors.FileReadError{Path: path, Cause: err}
+	}
+	defer file.Close()
+
+	archive, err := Read(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(archive.Log.Entries) == 0 {
+		return nil, fmt.Errorf("HAR file %s has no entries", path)
+	}
+
+	var first, last *cli.Options
+	for _, entry := range archive.Log.Entries {
+		opts := entryOptions(&entry, base)
+		if first == nil {
+			first = opts
+		} else {
+			last.Next = opts
+		}
+		last = opts
+	}
+	last.Next = base.Next
+
+	return first, nil
+}
+
+// entryOptions converts an archived request into options for one request
+func entryOptions(entry *Entry, base *cli.Options) *cli.Options {
+	opts := *base
+	opts.Next = nil
+	opts.Target = entry.Request.URL
+	opts.Method = entry.Request.Method
+	opts.Data = ""
+	opts.DataRaw = ""
+	if entry.Request.PostData != nil {
+		opts.DataRaw = entry.Request.PostData.Text
+	}
+
+	opts.Headers = nil
+	for _, header := range entry.Request.Headers {
+		// HTTP/2 pseudo-headers such as :authority have no HTTP/1.1 equivalent
+		if strings.HasPrefix(header.Name, ":") || skippedHeaders[strings.ToLower(header.Name)] {
+			continue
+		}
+		opts.Headers = append(opts.Headers, header.Name+": "+header.Value)
+	}
+
+	return &opts
+}
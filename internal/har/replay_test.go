@@ -0,0 +1,130 @@
+package har
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+const minimalHAR = `{
+  "log": {
+    "version": "1.2",
+    "creator": {"name": "browser", "version": "1.0"},
+    "entries": [
+      {
+        "request": {
+          "method": "GET",
+          "url": "http://example.com/page?q=1",
+          "httpVersion": "HTTP/2",
+          "headers": [
+            {"name": ":authority", "value": "example.com"},
+            {"name": "Accept", "value": "text/html"},
+            {"name": "Accept-Encoding", "value": "gzip, br"},
+            {"name": "X-Trace", "value": "abc"}
+          ]
+        },
+        "response": {"status": 200}
+      },
+      {
+        "request": {
+          "method": "POST",
+          "url": "http://example.com/submit",
+          "headers": [{"name": "Content-Type", "value": "application/json"}],
+          "postData": {"mimeType": "application/json", "text": "{\"a\":1}"}
+        },
+        "response": {"status": 201}
+      }
+    ]
+  }
+}`
+
+func writeHAR(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "session.har")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write HAR: %v", err)
+	}
+	return path
+}
+
+func TestReplayOptions(t *testing.T) {
+	base := &cli.Options{Proto: "auto", Insecure: true, Data: "ignored"}
+
+	opts, err := ReplayOptions(writeHAR(t, minimalHAR), base)
+	if err != nil {
+		t.Fatalf("ReplayOptions failed: %v", err)
+	}
+
+	if opts.Method != "GET" || opts.Target != "http://example.com/page?q=1" {
+		t.Errorf("first request = %s %s", opts.Method, opts.Target)
+	}
+	if len(opts.Headers) != 2 || opts.Headers[0] != "Accept: text/html" || opts.Headers[1] != "X-Trace: abc" {
+		t.Errorf("first request headers = %v", opts.Headers)
+	}
+	if opts.Data != "" || opts.DataRaw != "" {
+		t.Errorf("GET should have no body, got data %q raw %q", opts.Data, opts.DataRaw)
+	}
+	if !opts.Insecure {
+		t.Error("base options were not inherited")
+	}
+
+	second := opts.Next
+	if second == nil {
+		t.Fatal("expected a second request")
+	}
+	if second.Method != "POST" || second.DataRaw != `{"a":1}` {
+		t.Errorf("second request = %s with body %q", second.Method, second.DataRaw)
+	}
+	if second.Next != nil {
+		t.Error("expected exactly two requests")
+	}
+}
+
+func TestReplayOptions_KeepsNext(t *testing.T) {
+	next := &cli.Options{Target: "http://example.com/after", Method: "DELETE"}
+	base := &cli.Options{Proto: "auto", Next: next}
+
+	opts, err := ReplayOptions(writeHAR(t, minimalHAR), base)
+	if err != nil {
+		t.Fatalf("ReplayOptions failed: %v", err)
+	}
+
+	var targets []string
+	for o := opts; o != nil; o = o.Next {
+		targets = append(targets, o.Method+" "+o.Target)
+	}
+	want := []string{"GET http://example.com/page?q=1", "POST http://example.com/submit", "DELETE http://example.com/after"}
+	if !slices.Equal(targets, want) {
+		t.Errorf("requests = %v, want %v", targets, want)
+	}
+	if opts.Next.Next != next {
+		t.Error("expected the --next chain to follow the replayed requests unchanged")
+	}
+}
+
+func TestReplayOptions_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"invalid JSON", "{not json"},
+		{"no entries", `{"log":{"entries":[]}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReplayOptions(writeHAR(t, tt.content), &cli.Options{}); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	_, err := ReplayOptions(filepath.Join(t.TempDir(), "missing.har"), &cli.Options{})
+	if code := errors.MapErrorToExitCode(err); code != errors.ExitReadError {
+		t.Errorf("missing file: exit code = %d, want %d (%v)", code, errors.ExitReadError, err)
+	}
+}
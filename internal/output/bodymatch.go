@@ -0,0 +1,65 @@
+package output
+
+import (
+	"io"
+	"regexp"
+
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+// maxMatchWindow bounds how much of the body is held for matching
+// Matches spanning more than this many bytes are not detected
+const maxMatchWindow = 64 * 1024
+
+// BodyMatcher matches a regular expression against a body as it streams past
+// Only a bounded tail of the body is buffered, so large downloads stay cheap
+type BodyMatcher struct {
+	re      *regexp.Regexp
+	window  []byte
+	matched bool
+}
+
+// NewBodyMatcher creates a matcher for an already compiled pattern
+func NewBodyMatcher(re *regexp.Regexp) *BodyMatcher {
+	return &BodyMatcher{re: re}
+}
+
+// Wrap returns body with everything read from it also fed to the matcher
+func (m *BodyMatcher) Wrap(body io.ReadCloser) io.ReadCloser {
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.TeeReader(body, m), body}
+}
+
+// Write scans the data seen so far, keeping the tail so matches may span chunks
+func (m *BodyMatcher) Write(p []byte) (int, error) {
+	if m.matched {
+		return len(p), nil
+	}
+
+	m.window = append(m.window, p...)
+	if m.re.Match(m.window) {
+		m.matched = true
+		m.window = nil
+		return len(p), nil
+	}
+	if len(m.window) > maxMatchWindow {
+		m.window = append(m.window[:0], m.window[len(m.window)-maxMatchWindow:]...)
+	}
+	return len(p), nil
+}
+
+// Matched reports whether the pattern matched anywhere in the body
+func (m *BodyMatcher) Matched() bool {
+	return m.matched
+}
+
+// Check returns a BodyMismatchError when the outcome is not the expected one
+// With absent set the pattern must not match
+func (m *BodyMatcher) Check(absent bool) error {
+	if m.matched == absent {
+		return &errors.BodyMismatchError{Pattern: m.re.String(), Absent: absent}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package output
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+func TestBodyMatcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		pattern string
+		absent  bool
+		wantErr bool
+	}{
+		{"pattern matches", `{"status":"OK"}`, `"status":"OK"`, false, false},
+		{"pattern does not match", `{"status":"OK"}`, `FAILED`, false, true},
+		{"absent pattern missing", `{"status":"OK"}`, `error`, true, false},
+		{"absent pattern present", `{"status":"OK"}`, `OK`, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher := NewBodyMatcher(regexp.MustCompile(tt.pattern))
+			body := matcher.Wrap(io.NopCloser(strings.NewReader(tt.body)))
+
+			// The body must still reach the output unchanged
+			out, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("read failed: %v", err)
+			}
+			if string(out) != tt.body {
+				t.Errorf("body = %q, want %q", out, tt.body)
+			}
+
+			err = matcher.Check(tt.absent)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && errors.MapErrorToExitCode(err) != errors.ExitAssertionFailed {
+				t.Errorf("Expected exit code %d, got %d", errors.ExitAssertionFailed, errors.MapErrorToExitCode(err))
+			}
+		})
+	}
+}
+
+func TestBodyMatcher_MatchAcrossChunks(t *testing.T) {
+	matcher := NewBodyMatcher(regexp.MustCompile(`needle`))
+	matcher.Write([]byte(strings.Repeat("x", maxMatchWindow) + "nee"))
+	matcher.Write([]byte("dle" + strings.Repeat("y", 10)))
+
+	if !matcher.Matched() {
+		t.Error("Expected a match spanning two chunks")
+	}
+}
+
+func TestBodyMatcher_BoundedWindow(t *testing.T) {
+	matcher := NewBodyMatcher(regexp.MustCompile(`never`))
+	for i := 0; i < 10; i++ {
+		matcher.Write([]byte(strings.Repeat("z", maxMatchWindow)))
+	}
+
+	if len(matcher.window) > maxMatchWindow {
+		t.Errorf("window grew to %d bytes, want at most %d", len(matcher.window), maxMatchWindow)
+	}
+}
@@ -0,0 +1,32 @@
+package output
+
+import (
+	"io"
+	"mime"
+	"net/http"
+
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/transform"
+)
+
+// transcodeUTF8 wraps the response body in a decoder for the charset named in contentType
+// The body is returned unchanged when no charset is given, it is unknown, or it is already UTF-8
+func transcodeUTF8(resp *http.Response, contentType string) io.ReadCloser {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil || params["charset"] == "" {
+		return resp.Body
+	}
+
+	enc, err := htmlindex.Get(params["charset"])
+	if err != nil {
+		return resp.Body
+	}
+	if name, _ := htmlindex.Name(enc); name == "utf-8" {
+		return resp.Body
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{transform.NewReader(resp.Body, enc.NewDecoder()), resp.Body}
+}
@@ -0,0 +1,91 @@
+package output
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/protocol"
+)
+
+func TestTranscodeUTF8(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{
+			name:        "latin1 is transcoded",
+			contentType: "text/plain; charset=ISO-8859-1",
+			body:        "caf\xe9 cr\xe8me",
+			expected:    "café crème",
+		},
+		{
+			name:        "utf-8 is left alone",
+			contentType: "text/plain; charset=utf-8",
+			body:        "café",
+			expected:    "café",
+		},
+		{
+			name:        "unknown charset is left alone",
+			contentType: "text/plain; charset=x-unknown",
+			body:        "caf\xe9",
+			expected:    "caf\xe9",
+		},
+		{
+			name:        "missing charset is left alone",
+			contentType: "application/octet-stream",
+			body:        "caf\xe9",
+			expected:    "caf\xe9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				Header: http.Header{"Content-Type": []string{tt.contentType}},
+				Body:   io.NopCloser(strings.NewReader(tt.body)),
+			}
+
+			body, err := io.ReadAll(transcodeUTF8(resp, tt.contentType))
+			if err != nil {
+				t.Fatalf("read failed: %v", err)
+			}
+			if string(body) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, string(body))
+			}
+		})
+	}
+}
+
+func TestWriteResponse_TranscodeUTF8(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=iso-8859-1")
+		w.Write([]byte("<p>na\xefve fa\xe7ade</p>"))
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var stdout, stderr strings.Builder
+	handler := NewHandler(&cli.Options{TranscodeUTF8: true})
+	handler.Stdout = &stdout
+	handler.Stderr = &stderr
+
+	result := &protocol.ProbeResult{Protocol: "http", StatusCode: resp.StatusCode, Response: resp}
+	if err := handler.WriteResponse(resp.Request, result); err != nil {
+		t.Fatalf("WriteResponse failed: %v", err)
+	}
+
+	if !strings.HasSuffix(stdout.String(), "<p>naïve façade</p>") {
+		t.Errorf("Expected transcoded body on stdout, got %q", stdout.String())
+	}
+}
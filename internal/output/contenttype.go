@@ -0,0 +1,51 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// contentType returns the content type that drives body processing:
+// --assume-content-type when set, otherwise the response's Content-Type
+func (h *Handler) contentType(resp *http.Response) string {
+	if h.opts.AssumeContentType != "" {
+		return h.opts.AssumeContentType
+	}
+	return resp.Header.Get("Content-Type")
+}
+
+// mediaType returns the lowercased media type of a Content-Type value without parameters
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return mt
+}
+
+// isJSONMediaType reports whether the media type is application/json or a +json suffix type
+func isJSONMediaType(mt string) bool {
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// writePrettyJSON writes body to w indented; a body that is not valid JSON is written unchanged
+func writePrettyJSON(w io.Writer, body io.Reader) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		_, err = w.Write(data)
+		return err
+	}
+	out.WriteByte('\n')
+	_, err = out.WriteTo(w)
+	return err
+}
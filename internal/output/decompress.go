@@ -0,0 +1,163 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+// DecompressBody replaces the body of a --compressed response with its decoded content
+// based on Content-Encoding (gzip, deflate, br, identity); headers are left as received
+// It is called once, before anything reads the body; a body that does not decode,
+// up front or while it is read, fails with a DecodeError
+func DecompressBody(resp *http.Response) error {
+	if resp.Body == nil {
+		return nil
+	}
+
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	raw := &errRecorder{r: resp.Body}
+	var decoded io.Reader
+	var err error
+	switch encoding {
+	case "", "identity":
+		return nil
+	case "gzip", "x-gzip":
+		decoded, err = gzip.NewReader(raw)
+	case "deflate":
+		decoded, err = newDeflateReader(raw)
+	case "br":
+		decoded = newBrotliReader(raw)
+	default:
+		return &errors.DecodeError{Encoding: encoding}
+	}
+	if err == io.EOF && raw.n == 0 {
+		// An empty body has nothing to decode
+		return nil
+	}
+	if err != nil {
+		return raw.decodeError(encoding, err)
+	}
+
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{&decodeReader{decoded: decoded, raw: raw, encoding: encoding}, resp.Body}
+	// The announced length counts encoded bytes, not what will be written out
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// errRecorder remembers the last error reading the encoded body, so a failed
+// connection is not mistaken for a body that does not decode
+type errRecorder struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+func (e *errRecorder) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	e.n += int64(n)
+	if err != nil {
+		e.err = err
+	}
+	return n, err
+}
+
+// decodeError returns err as a DecodeError unless it is the end of the decoded
+// body or came from reading the encoded one
+func (e *errRecorder) decodeError(encoding string, err error) error {
+	if err == io.EOF || (e.err != nil && e.err != io.EOF && stderrors.Is(err, e.err)) {
+		return err
+	}
+	return &errors.DecodeError{Encoding: encoding, Cause: err}
+}
+
+// decodeReader reads the decoded body, reporting decoder failures as DecodeErrors
+type decodeReader struct {
+	decoded  io.Reader
+	raw      *errRecorder
+	encoding string
+}
+
+func (d *decodeReader) Read(p []byte) (int, error) {
+	n, err := d.decoded.Read(p)
+	if err != nil {
+		err = d.raw.decodeError(d.encoding, err)
+	}
+	return n, err
+}
+
+// newDeflateReader decodes HTTP deflate, which is zlib-wrapped by the spec
+// but sent as raw DEFLATE by some servers
+func newDeflateReader(body io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(body)
+	header, err := buffered.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+
+	// A zlib header has compression method 8 and a check value divisible by 31
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
+
+// brotliReader decodes a br body, passing it through unchanged if it does not
+// decode; brotli has no magic number, so some servers mislabel plain bodies as br
+type brotliReader struct {
+	source   *recordingReader
+	decoder  io.Reader
+	fallback io.Reader // the raw body, once decoding failed before any output
+}
+
+func newBrotliReader(body io.Reader) *brotliReader {
+	source := &recordingReader{r: body, recording: true}
+	return &brotliReader{source: source, decoder: brotli.NewReader(source)}
+}
+
+func (b *brotliReader) Read(p []byte) (int, error) {
+	if b.fallback != nil {
+		return b.fallback.Read(p)
+	}
+
+	n, err := b.decoder.Read(p)
+	if b.source.recording {
+		if n > 0 || err == io.EOF {
+			// The body is brotli, so later errors are real
+			b.source.recording, b.source.buf = false, nil
+		} else if err != nil {
+			b.fallback = io.MultiReader(bytes.NewReader(b.source.buf), b.source.r)
+			return b.fallback.Read(p)
+		}
+	}
+	return n, err
+}
+
+// recordingReader keeps a copy of what it reads while recording is set
+type recordingReader struct {
+	r         io.Reader
+	buf       []byte
+	recording bool
+}
+
+func (r *recordingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if r.recording {
+		r.buf = append(r.buf, p[:n]...)
+	}
+	return n, err
+}
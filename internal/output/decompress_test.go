@@ -0,0 +1,160 @@
+package output
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+func TestDecompressBody(t *testing.T) {
+	const text = "hello, compressed world"
+
+	var gz, zl, raw bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write([]byte(text))
+	gw.Close()
+	zw := zlib.NewWriter(&zl)
+	zw.Write([]byte(text))
+	zw.Close()
+	fw, _ := flate.NewWriter(&raw, flate.DefaultCompression)
+	fw.Write([]byte(text))
+	fw.Close()
+	var br bytes.Buffer
+	bw := brotli.NewWriter(&br)
+	bw.Write([]byte(text))
+	bw.Close()
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		wantErr  bool
+	}{
+		{"gzip", "gzip", gz.Bytes(), false},
+		{"deflate zlib-wrapped", "deflate", zl.Bytes(), false},
+		{"deflate raw", "deflate", raw.Bytes(), false},
+		{"identity passes through", "identity", []byte(text), false},
+		{"no encoding passes through", "", []byte(text), false},
+		{"brotli", "br", br.Bytes(), false},
+		{"mislabeled brotli passes through", "br", []byte(text), false},
+		{"unsupported encoding", "zstd", []byte("?"), true},
+		{"corrupt gzip", "gzip", []byte("not gzip"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(tt.body))}
+			if tt.encoding != "" {
+				resp.Header.Set("Content-Encoding", tt.encoding)
+			}
+
+			err := DecompressBody(resp)
+			if tt.wantErr {
+				var decodeErr *errors.DecodeError
+				if !stderrors.As(err, &decodeErr) {
+					t.Fatalf("Expected a DecodeError, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecompressBody failed: %v", err)
+			}
+
+			got, _ := io.ReadAll(resp.Body)
+			if string(got) != text {
+				t.Errorf("body = %q, want %q", got, text)
+			}
+		})
+	}
+}
+
+// failingReader returns its data, then err
+type failingReader struct {
+	data []byte
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, r.err
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestDecompressBody_ReadErrors(t *testing.T) {
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(bytes.Repeat([]byte("compressible "), 1000))
+	gw.Close()
+	encoded := gz.Bytes()
+
+	corrupt := bytes.Clone(encoded)
+	corrupt[len(corrupt)-8] ^= 0xff // break the CRC-32 trailer
+
+	connErr := stderrors.New("connection reset by peer")
+	tests := []struct {
+		name       string
+		body       io.Reader
+		wantDecode bool
+	}{
+		{"corrupt stream is a decode error", bytes.NewReader(corrupt), true},
+		{"connection error is passed through", &failingReader{data: encoded[:len(encoded)/2], err: connErr}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{"Content-Encoding": {"gzip"}}, Body: io.NopCloser(tt.body)}
+			if err := DecompressBody(resp); err != nil {
+				t.Fatalf("DecompressBody failed: %v", err)
+			}
+
+			_, err := io.ReadAll(resp.Body)
+			var decodeErr *errors.DecodeError
+			if got := stderrors.As(err, &decodeErr); got != tt.wantDecode {
+				t.Errorf("read error %v: DecodeError = %v, want %v", err, got, tt.wantDecode)
+			}
+			if !tt.wantDecode && !stderrors.Is(err, connErr) {
+				t.Errorf("Expected the connection error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDecompressBody_Empty(t *testing.T) {
+	resp := &http.Response{Header: http.Header{"Content-Encoding": {"gzip"}}, Body: http.NoBody}
+	if err := DecompressBody(resp); err != nil {
+		t.Fatalf("DecompressBody failed on an empty body: %v", err)
+	}
+}
+
+func TestDecompressBody_LargeBrotli(t *testing.T) {
+	// Larger than one read, so decoding spans many reads after the first
+	original := bytes.Repeat([]byte("purl brotli stream 0123456789\n"), 20000)
+	var br bytes.Buffer
+	bw := brotli.NewWriter(&br)
+	bw.Write(original)
+	bw.Close()
+
+	resp := &http.Response{Header: http.Header{"Content-Encoding": {"br"}}, Body: io.NopCloser(&br)}
+	if err := DecompressBody(resp); err != nil {
+		t.Fatalf("DecompressBody failed: %v", err)
+	}
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading decoded body failed: %v", err)
+	}
+	if !bytes.Equal(got, original) {
+		t.Errorf("decoded %d bytes, want %d matching the original", len(got), len(original))
+	}
+}
@@ -0,0 +1,51 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/transport"
+)
+
+// WriteDNS resolves req's host the way the transport will, under req's context,
+// and writes its A/AAAA records to w
+// A failed lookup is reported but not returned; the request surfaces it with the right exit code
+func WriteDNS(w io.Writer, req *http.Request, opts *cli.Options) {
+	host := req.URL.Hostname()
+	port := req.URL.Port()
+	if port == "" {
+		port = "80"
+		if req.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	ips, err := transport.LookupIP(req.Context(), opts, net.JoinHostPort(host, port))
+	if err != nil {
+		fmt.Fprintf(w, "* DNS lookup for %s failed: %v\n", host, err)
+		return
+	}
+
+	fmt.Fprintf(w, "* DNS %s resolved to %d address(es)\n", host, len(ips))
+	for _, ip := range ips {
+		record := "AAAA"
+		if ip.To4() != nil {
+			record = "A"
+		}
+		fmt.Fprintf(w, "*   %-4s %s\n", record, ip)
+	}
+}
+
+// TraceConnection returns req with a GotConn hook that writes the address actually used to w
+func TraceConnection(req *http.Request, w io.Writer) *http.Request {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			fmt.Fprintf(w, "* Using %s\n", info.Conn.RemoteAddr())
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
@@ -0,0 +1,69 @@
+package output
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aleister1102/purl/internal/cli"
+)
+
+func TestWriteDNS_Localhost(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://localhost/", nil)
+	var out strings.Builder
+	WriteDNS(&out, req, &cli.Options{})
+
+	output := out.String()
+	if !strings.Contains(output, "* DNS localhost resolved to") {
+		t.Fatalf("Expected resolution summary, got:\n%s", output)
+	}
+	if !strings.Contains(output, "A    127.0.0.1") && !strings.Contains(output, "AAAA ::1") {
+		t.Errorf("Expected a loopback record, got:\n%s", output)
+	}
+}
+
+func TestWriteDNS_LookupFailure(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://nonexistent.invalid/", nil)
+	var out strings.Builder
+	WriteDNS(&out, req, &cli.Options{})
+
+	if !strings.Contains(out.String(), "* DNS lookup for nonexistent.invalid failed") {
+		t.Errorf("Expected lookup failure, got:\n%s", out.String())
+	}
+}
+
+func TestWriteDNS_Resolve(t *testing.T) {
+	// --resolve answers without a lookup, so the name need not exist
+	req := httptest.NewRequest("GET", "https://api.purl.invalid/", nil)
+	var out strings.Builder
+	WriteDNS(&out, req, &cli.Options{Resolve: []string{"api.purl.invalid:443:127.0.0.2"}})
+
+	if !strings.Contains(out.String(), "A    127.0.0.2") {
+		t.Errorf("Expected the --resolve address, got:\n%s", out.String())
+	}
+}
+
+func TestTraceConnection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest("GET", server.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+
+	var out strings.Builder
+	resp, err := http.DefaultClient.Do(TraceConnection(req, &out))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	expected := "* Using " + strings.TrimPrefix(server.URL, "http://") + "\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
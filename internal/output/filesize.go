@@ -0,0 +1,41 @@
+package output
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+// limitBody enforces --max-filesize on a response: an announced Content-Length
+// over the limit fails before any of the body is read, and otherwise the body
+// is wrapped to fail once more than limit bytes have been streamed. A
+// --compressed body is decoded first, so both checks count decoded bytes
+func limitBody(resp *http.Response, limit int64) error {
+	url := resp.Request.URL.String()
+	if resp.ContentLength > limit {
+		return &errors.SizeLimitError{URL: url, Limit: limit, Size: resp.ContentLength}
+	}
+	resp.Body = &sizeLimitReader{ReadCloser: resp.Body, url: url, limit: limit}
+	return nil
+}
+
+// sizeLimitReader fails with a SizeLimitError once more than limit bytes are read
+type sizeLimitReader struct {
+	io.ReadCloser
+	url   string
+	limit int64
+	read  int64
+}
+
+func (r *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	if r.read > r.limit {
+		// Hand on only the bytes within the limit
+		n -= int(r.read - r.limit)
+		r.read = r.limit
+		return n, &errors.SizeLimitError{URL: r.url, Limit: r.limit, Size: -1}
+	}
+	return n, err
+}
@@ -0,0 +1,98 @@
+package output
+
+import (
+	stderrors "errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
+	"github.com/aleister1102/purl/internal/protocol"
+)
+
+// unreadBody fails the test if the body is read at all
+type unreadBody struct {
+	t *testing.T
+}
+
+func (b unreadBody) Read(p []byte) (int, error) {
+	b.t.Error("Expected the body not to be read")
+	return 0, io.EOF
+}
+
+func (b unreadBody) Close() error { return nil }
+
+func TestWriteResponse_MaxFileSize(t *testing.T) {
+	tests := []struct {
+		name          string
+		contentLength int64
+		body          func(t *testing.T) io.ReadCloser
+		wantErr       bool
+		wantSize      int64
+		wantStdout    string
+	}{
+		{
+			name:          "Content-Length over the limit fails before reading",
+			contentLength: 100,
+			body:          func(t *testing.T) io.ReadCloser { return unreadBody{t} },
+			wantErr:       true,
+			wantSize:      100,
+		},
+		{
+			name:          "streamed body over the limit stops at the limit",
+			contentLength: -1,
+			body:          func(*testing.T) io.ReadCloser { return io.NopCloser(strings.NewReader("0123456789abcdef")) },
+			wantErr:       true,
+			wantSize:      -1,
+			wantStdout:    "0123456789",
+		},
+		{
+			name:          "body within the limit",
+			contentLength: 10,
+			body:          func(*testing.T) io.ReadCloser { return io.NopCloser(strings.NewReader("0123456789")) },
+			wantStdout:    "0123456789",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout strings.Builder
+			handler := NewHandler(&cli.Options{MaxFileSize: 10, StatusLineStderr: true})
+			handler.Stdout = &stdout
+			handler.Stderr = io.Discard
+
+			reqURL, _ := url.Parse("http://example.com/big")
+			req := &http.Request{Method: "GET", URL: reqURL, Proto: "HTTP/1.1", Header: http.Header{}}
+			result := &protocol.ProbeResult{
+				Protocol:   "http",
+				StatusCode: 200,
+				Response: &http.Response{
+					StatusCode:    200,
+					Header:        http.Header{},
+					ContentLength: tt.contentLength,
+					Body:          tt.body(t),
+					Request:       req,
+				},
+			}
+
+			err := handler.WriteResponse(req, result)
+			var sizeErr *errors.SizeLimitError
+			if tt.wantErr {
+				if !stderrors.As(err, &sizeErr) {
+					t.Fatalf("Expected SizeLimitError, got %v", err)
+				}
+				if sizeErr.Size != tt.wantSize || sizeErr.Limit != 10 {
+					t.Errorf("SizeLimitError = %+v, want size %d and limit 10", sizeErr, tt.wantSize)
+				}
+			} else if err != nil {
+				t.Fatalf("WriteResponse() error = %v", err)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
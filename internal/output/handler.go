@@ -3,17 +3,30 @@ package output
 import (
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aleister1102/purl/internal/cli"
 	"github.com/aleister1102/purl/internal/protocol"
+	"github.com/aleister1102/purl/internal/transport"
 )
 
 // Handler manages output formatting and writing
 type Handler struct {
 	opts *cli.Options
+
+	// Stdout and Stderr receive the output; nil uses os.Stdout and os.Stderr
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Target prefixes the status line, telling apart the targets of a --targets-file
+	Target string
 }
 
 // NewHandler creates a new output handler
@@ -25,23 +38,41 @@ func NewHandler(opts *cli.Options) *Handler {
 
 // WriteResponse handles writing the response to stdout or file, with optional verbose output
 func (h *Handler) WriteResponse(req *http.Request, result *protocol.ProbeResult) error {
+	level := h.VerboseLevel()
+
 	// Print verbose request details to stderr if requested
-	if h.opts.Verbose {
+	if level >= 2 {
 		if err := h.printVerboseRequest(req); err != nil {
 			return err
 		}
+	} else if level == 1 {
+		fmt.Fprintf(h.stderr(), "> %s %s %s\n", req.Method, req.URL.RequestURI(), req.Proto)
 	}
 
-	// Print status line to stdout
-	if err := h.printStatusLine(result); err != nil {
+	// Print status line to stdout, as a JSON object with --output-format json
+	if h.opts.OutputFormat == "json" {
+		if err := h.printStatusJSON(req, result); err != nil {
+			return err
+		}
+	} else if err := h.printStatusLine(result); err != nil {
 		return err
 	}
 
 	// Print verbose response headers to stderr if requested
-	if h.opts.Verbose && result.Response != nil {
+	if level >= 2 && result.Response != nil {
 		if err := h.printVerboseResponse(result.Response); err != nil {
 			return err
 		}
+	} else if level == 1 && result.Response != nil {
+		resp := result.Response
+		fmt.Fprintf(h.stderr(), "< %s %d %s\n", resp.Proto, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	// Print timing and connection details to stderr at the highest level
+	if level >= 3 {
+		if err := h.printVerboseDetails(req, result); err != nil {
+			return err
+		}
 	}
 
 	// Print TLS details to stderr if requested
@@ -51,8 +82,29 @@ func (h *Handler) WriteResponse(req *http.Request, result *protocol.ProbeResult)
 		}
 	}
 
-	// Stream response body to stdout or file
-	if result.Response != nil && result.Response.Body != nil {
+	// Report TLS session resumption in verbose mode when it was enabled
+	if h.opts.TLSResumption && (level > 0 || h.opts.VerboseTLS) && result.Response != nil && result.Response.TLS != nil {
+		resumed := "no"
+		if result.Response.TLS.DidResume {
+			resumed = "yes"
+		}
+		fmt.Fprintf(h.stderr(), "* TLS session resumed: %s\n", resumed)
+
+		// Go's TLS client never sends 0-RTT data, so early data cannot have been accepted
+		if h.opts.TLSEarlyData {
+			fmt.Fprintf(h.stderr(), "* TLS early data: not accepted (0-RTT is unsupported by the Go TLS client; %s)\n",
+				getTLSVersionString(result.Response.TLS.Version))
+		}
+	}
+
+	// Print the response status line and headers to stdout ahead of the body (-i),
+	// and for -I, where they are all there is to show
+	if (h.opts.Include || h.opts.Head) && result.Response != nil {
+		h.printIncludedHeaders(result.Response)
+	}
+
+	// Stream response body to stdout or file, unless the JSON status already carried it
+	if result.Response != nil && result.Response.Body != nil && !(h.opts.OutputFormat == "json" && h.opts.IncludeBody) {
 		if err := h.writeResponseBody(result.Response); err != nil {
 			return err
 		}
@@ -61,15 +113,54 @@ func (h *Handler) WriteResponse(req *http.Request, result *protocol.ProbeResult)
 	return nil
 }
 
+// VerboseLevel returns the effective verbosity
+// -v alone maps to level 2 (request/response lines and headers)
+func (h *Handler) VerboseLevel() int {
+	if h.opts.VerboseLevel > 0 {
+		return h.opts.VerboseLevel
+	}
+	if h.opts.Verbose {
+		return 2
+	}
+	return 0
+}
+
+// stdout returns the writer for the response body and status line
+func (h *Handler) stdout() io.Writer {
+	if h.Stdout != nil {
+		return h.Stdout
+	}
+	return os.Stdout
+}
+
+// stderr returns the writer for verbose and diagnostic output
+func (h *Handler) stderr() io.Writer {
+	if h.Stderr != nil {
+		return h.Stderr
+	}
+	return os.Stderr
+}
+
 // printStatusLine prints the formatted status line
-// Format: "[PROTO] Status: CODE Time: Xs"
+// Format: "[PROTO] Status: CODE Time: Xs", or the --status-format template when set
+// PROTO gains a "/2" suffix when the response came over HTTP/2, and the line is
+// prefixed with the target when running a --targets-file
+// Goes to stderr with --status-line-stderr so stdout carries only the body
+// Suppressed entirely with -s/--silent
 func (h *Handler) printStatusLine(result *protocol.ProbeResult) error {
+	if h.opts.Silent {
+		return nil
+	}
+
 	proto := result.Protocol
 	if proto == "" {
 		proto = "HTTP"
 	} else {
 		proto = formatProto(proto)
 	}
+	if strings.HasPrefix(result.HTTPVersion, "HTTP/2") {
+		proto += "/2"
+	}
 
 	statusCode := result.StatusCode
 	if statusCode == 0 {
@@ -77,13 +168,33 @@ func (h *Handler) printStatusLine(result *protocol.ProbeResult) error {
 	}
 
 	// Format duration with appropriate unit
-	durationStr := formatDuration(result.Duration)
+	durationStr := formatDuration(result.Elapsed())
 
 	statusLine := fmt.Sprintf("[%s] Status: %d Time: %s\n", proto, statusCode, durationStr)
-	_, err := fmt.Fprint(os.Stdout, statusLine)
+	if h.opts.StatusFormat != "" {
+		statusLine = expandStatusFormat(h.opts.StatusFormat, proto, statusCode, durationStr) + "\n"
+	}
+	if h.Target != "" {
+		statusLine = h.Target + " " + statusLine
+	}
+	w := h.stdout()
+	if h.opts.StatusLineStderr {
+		w = h.stderr()
+	}
+	_, err := fmt.Fprint(w, statusLine)
 	return err
 }
 
+// expandStatusFormat replaces the named placeholders in a status line template
+func expandStatusFormat(format, proto string, statusCode int, duration string) string {
+	replacer := strings.NewReplacer(
+		"{proto}", proto,
+		"{code}", strconv.Itoa(statusCode),
+		"{time}", duration,
+	)
+	return replacer.Replace(format)
+}
+
 // formatProto converts protocol string to uppercase
 func formatProto(proto string) string {
 	if proto == "http" {
@@ -119,68 +230,237 @@ func formatDuration(d time.Duration) string {
 }
 
 // writeResponseBody writes the response body to stdout or file
+// --json-lines streams the body to stdout as one JSON object per chunk or SSE event
+// --max-filesize bounds the body before anything is written, and --compressed bodies are decoded first
+// --assume-content-type replaces the Content-Type these decisions are based on
 func (h *Handler) writeResponseBody(resp *http.Response) error {
-	var writer io.Writer
+	if h.opts.MaxFileSize > 0 {
+		if err := limitBody(resp, h.opts.MaxFileSize); err != nil {
+			return err
+		}
+	}
+
+	contentType := h.contentType(resp)
+	if h.opts.TranscodeUTF8 {
+		resp.Body = transcodeUTF8(resp, contentType)
+	}
+
+	if h.opts.JSONLines {
+		if h.opts.SSE || mediaType(contentType) == "text/event-stream" {
+			return writeSSEJSONLines(h.stdout(), resp.Body)
+		}
+		return writeJSONLines(h.stdout(), resp.Body)
+	}
 
 	if h.opts.Output != "" {
-		// Write to file
-		file, err := os.Create(h.opts.Output)
+		return h.writeResponseFile(resp)
+	}
+
+	// Indent JSON bodies on stdout under --pretty-json
+	if h.opts.PrettyJSON && isJSONMediaType(mediaType(contentType)) {
+		return writePrettyJSON(h.stdout(), resp.Body)
+	}
+
+	// Copy response body to stdout
+	_, err := io.Copy(h.stdout(), resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to write response body: %w", err)
+	}
+
+	return nil
+}
+
+// writeResponseFile writes the response body to the output file atomically
+// The body goes to a temporary file that is renamed into place once complete,
+// so an interrupted download leaves the target untouched
+func (h *Handler) writeResponseFile(resp *http.Response) error {
+	// Special files such as /dev/null cannot be replaced by a rename
+	existing, statErr := os.Stat(h.opts.Output)
+	if statErr == nil && !existing.Mode().IsRegular() {
+		file, err := os.OpenFile(h.opts.Output, os.O_WRONLY, 0)
 		if err != nil {
 			return fmt.Errorf("failed to create output file: %w", err)
 		}
 		defer file.Close()
-		writer = file
-	} else {
-		// Write to stdout
-		writer = os.Stdout
+
+		if _, err := io.Copy(file, resp.Body); err != nil {
+			return fmt.Errorf("failed to write response body: %w", err)
+		}
+		return nil
 	}
 
-	// Copy response body to writer
-	_, err := io.Copy(writer, resp.Body)
+	dir, base := filepath.Split(h.opts.Output)
+	if dir == "" {
+		dir = "."
+	}
+
+	file, err := createOutputTemp(dir, base)
 	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	tmpName := file.Name()
+
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		file.Close()
+		os.Remove(tmpName)
 		return fmt.Errorf("failed to write response body: %w", err)
 	}
 
+	// Keep the mode of the file being replaced
+	if statErr == nil {
+		if err := file.Chmod(existing.Mode().Perm()); err != nil {
+			file.Close()
+			os.Remove(tmpName)
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to write response body: %w", err)
+	}
+
+	if err := os.Rename(tmpName, h.opts.Output); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+
 	return nil
 }
 
+// createOutputTemp creates a uniquely named temporary file beside the output
+// It is created with mode 0666 less the umask, as a plain create would be
+func createOutputTemp(dir, base string) (*os.File, error) {
+	for range 100 {
+		name := filepath.Join(dir, "."+base+"."+strconv.FormatUint(rand.Uint64(), 36)+".tmp")
+		file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
+		if !os.IsExist(err) {
+			return file, err
+		}
+	}
+	return nil, fmt.Errorf("no unused temporary name in %s", dir)
+}
+
 // printVerboseRequest prints request details to stderr
 func (h *Handler) printVerboseRequest(req *http.Request) error {
 	// Print request line
-	fmt.Fprintf(os.Stderr, "> %s %s %s\n", req.Method, req.URL.RequestURI(), req.Proto)
-
-	// Print request headers
-	for name, values := range req.Header {
-		for _, value := range values {
-			// Mask Authorization header for security
-			if name == "Authorization" {
-				fmt.Fprintf(os.Stderr, "> %s: [REDACTED]\n", name)
+	fmt.Fprintf(h.stderr(), "> %s %s %s\n", req.Method, req.URL.RequestURI(), req.Proto)
+
+	// Print request headers, sorted by name so runs can be diffed
+	for _, name := range sortedHeaderNames(req.Header) {
+		for _, value := range req.Header[name] {
+			// Mask credentials, cookies and --header-env values for security
+			if h.isSecretHeader(name) {
+				fmt.Fprintf(h.stderr(), "> %s: [REDACTED]\n", name)
 			} else {
-				fmt.Fprintf(os.Stderr, "> %s: %s\n", name, value)
+				fmt.Fprintf(h.stderr(), "> %s: %s\n", name, value)
 			}
 		}
 	}
 
 	// Print blank line after headers
-	fmt.Fprintf(os.Stderr, ">\n")
+	fmt.Fprintf(h.stderr(), ">\n")
 
 	return nil
 }
 
-// printVerboseResponse prints response headers to stderr
+// secretHeaders are masked in verbose output unless --no-redact; --redact-header adds more
+var secretHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// isSecretHeader reports whether a header value must be masked in verbose output
+// Names are compared canonically, since --randomize-headers re-cases them
+func (h *Handler) isSecretHeader(name string) bool {
+	if h.opts.NoRedact {
+		return false
+	}
+	name = http.CanonicalHeaderKey(name)
+	if secretHeaders[name] {
+		return true
+	}
+	for _, extra := range h.opts.RedactHeaders {
+		if http.CanonicalHeaderKey(extra) == name {
+			return true
+		}
+	}
+	for _, mapping := range h.opts.HeaderEnv {
+		envName, _, _ := strings.Cut(mapping, "=")
+		if http.CanonicalHeaderKey(strings.TrimSpace(envName)) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// printVerboseResponse prints response headers to stderr, masking Set-Cookie and other secrets
 func (h *Handler) printVerboseResponse(resp *http.Response) error {
 	// Print status line
-	fmt.Fprintf(os.Stderr, "< %s %d %s\n", resp.Proto, resp.StatusCode, http.StatusText(resp.StatusCode))
+	fmt.Fprintf(h.stderr(), "< %s %d %s\n", resp.Proto, resp.StatusCode, http.StatusText(resp.StatusCode))
 
-	// Print response headers
-	for name, values := range resp.Header {
-		for _, value := range values {
-			fmt.Fprintf(os.Stderr, "< %s: %s\n", name, value)
+	// Print response headers, sorted by name so runs can be diffed
+	for _, name := range sortedHeaderNames(resp.Header) {
+		for _, value := range resp.Header[name] {
+			if h.isSecretHeader(name) {
+				fmt.Fprintf(h.stderr(), "< %s: [REDACTED]\n", name)
+			} else {
+				fmt.Fprintf(h.stderr(), "< %s: %s\n", name, value)
+			}
 		}
 	}
 
 	// Print blank line after headers
-	fmt.Fprintf(os.Stderr, "<\n")
+	fmt.Fprintf(h.stderr(), "<\n")
+
+	return nil
+}
+
+// printIncludedHeaders prints the response status line and headers to stdout for -i and -I
+// Headers are sorted by name so the output is reproducible
+func (h *Handler) printIncludedHeaders(resp *http.Response) {
+	w := h.stdout()
+	fmt.Fprintf(w, "%s %d %s\r\n", resp.Proto, resp.StatusCode, http.StatusText(resp.StatusCode))
+
+	for _, name := range sortedHeaderNames(resp.Header) {
+		for _, value := range resp.Header[name] {
+			fmt.Fprintf(w, "%s: %s\r\n", name, value)
+		}
+	}
+
+	fmt.Fprint(w, "\r\n")
+}
+
+// sortedHeaderNames returns the names of header in sorted order
+func sortedHeaderNames(header http.Header) []string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// printVerboseDetails prints connection details and timing to stderr
+func (h *Handler) printVerboseDetails(req *http.Request, result *protocol.ProbeResult) error {
+	host := req.URL.Hostname()
+	port := req.URL.Port()
+	if port == "" {
+		port = "80"
+		if req.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	fmt.Fprintf(h.stderr(), "* Connected to %s port %s via %s\n", host, port, formatProto(result.Protocol))
+	fmt.Fprintf(h.stderr(), "* Total time: %s\n", formatDuration(result.Elapsed()))
+	if result.TotalTime > 0 {
+		fmt.Fprintf(h.stderr(), "* Probe timing: DNS %s, connect %s, TLS %s, TTFB %s, total %s\n",
+			formatDuration(result.DNSTime), formatDuration(result.ConnectTime), formatDuration(result.TLSTime),
+			formatDuration(result.TTFB), formatDuration(result.TotalTime))
+	}
 
 	return nil
 }
@@ -188,7 +468,7 @@ func (h *Handler) printVerboseResponse(resp *http.Response) error {
 // printTLSDetails prints TLS handshake details to stderr
 func (h *Handler) printTLSDetails(resp *http.Response) error {
 	if resp.TLS == nil {
-		fmt.Fprintf(os.Stderr, "* No TLS connection\n")
+		fmt.Fprintf(h.stderr(), "* No TLS connection\n")
 		return nil
 	}
 
@@ -196,19 +476,19 @@ func (h *Handler) printTLSDetails(resp *http.Response) error {
 
 	// Print TLS version
 	tlsVersion := getTLSVersionString(tls.Version)
-	fmt.Fprintf(os.Stderr, "* TLS Version: %s\n", tlsVersion)
+	fmt.Fprintf(h.stderr(), "* TLS Version: %s\n", tlsVersion)
 
 	// Print cipher suite
 	cipherSuite := getCipherSuiteName(tls.CipherSuite)
-	fmt.Fprintf(os.Stderr, "* Cipher Suite: %s\n", cipherSuite)
+	fmt.Fprintf(h.stderr(), "* Cipher Suite: %s\n", cipherSuite)
 
 	// Print certificate info
 	if len(tls.PeerCertificates) > 0 {
 		cert := tls.PeerCertificates[0]
-		fmt.Fprintf(os.Stderr, "* Subject: %s\n", cert.Subject.String())
-		fmt.Fprintf(os.Stderr, "* Issuer: %s\n", cert.Issuer.String())
-		fmt.Fprintf(os.Stderr, "* Valid From: %s\n", cert.NotBefore.String())
-		fmt.Fprintf(os.Stderr, "* Valid Until: %s\n", cert.NotAfter.String())
+		fmt.Fprintf(h.stderr(), "* Subject: %s\n", cert.Subject.String())
+		fmt.Fprintf(h.stderr(), "* Issuer: %s\n", cert.Issuer.String())
+		fmt.Fprintf(h.stderr(), "* Valid From: %s\n", cert.NotBefore.String())
+		fmt.Fprintf(h.stderr(), "* Valid Until: %s\n", cert.NotAfter.String())
 	}
 
 	return nil
@@ -232,26 +512,5 @@ func getTLSVersionString(version uint16) string {
 
 // getCipherSuiteName converts cipher suite constant to name
 func getCipherSuiteName(suite uint16) string {
-	// Map common cipher suites
-	cipherSuites := map[uint16]string{
-		0x002f: "TLS_RSA_WITH_AES_128_CBC_SHA",
-		0x0035: "TLS_RSA_WITH_AES_256_CBC_SHA",
-		0x003c: "TLS_RSA_WITH_AES_128_CBC_SHA256",
-		0x003d: "TLS_RSA_WITH_AES_256_CBC_SHA256",
-		0x009c: "TLS_RSA_WITH_AES_128_GCM_SHA256",
-		0x009d: "TLS_RSA_WITH_AES_256_GCM_SHA384",
-		0x1301: "TLS_AES_128_GCM_SHA256",
-		0x1302: "TLS_AES_256_GCM_SHA384",
-		0x1303: "TLS_CHACHA20_POLY1305_SHA256",
-		0xc02b: "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
-		0xc02c: "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
-		0xc02f: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
-		0xc030: "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
-	}
-
-	if name, ok := cipherSuites[suite]; ok {
-		return name
-	}
-
-	return fmt.Sprintf("Unknown (0x%04x)", suite)
+	return transport.CipherSuiteName(suite)
 }
@@ -1,11 +1,13 @@
 package output
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -171,6 +173,61 @@ func TestPrintStatusLine(t *testing.T) {
 	}
 }
 
+func TestPrintStatusLine_CustomFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{
+			name:     "all placeholders",
+			format:   "{proto} {code} {time}",
+			expected: "HTTPS 201 250ms\n",
+		},
+		{
+			name:     "literal text around placeholders",
+			format:   "proto={proto} status={code}",
+			expected: "proto=HTTPS status=201\n",
+		},
+		{
+			name:     "unknown placeholder left as-is",
+			format:   "{code} {unknown}",
+			expected: "201 {unknown}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(&cli.Options{StatusFormat: tt.format})
+
+			result := &protocol.ProbeResult{
+				Protocol:   "https",
+				StatusCode: 201,
+				Duration:   250 * time.Millisecond,
+			}
+
+			// Capture stdout
+			oldStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			err := handler.printStatusLine(result)
+
+			w.Close()
+			os.Stdout = oldStdout
+
+			if err != nil {
+				t.Fatalf("printStatusLine() error = %v", err)
+			}
+
+			output, _ := io.ReadAll(r)
+			if string(output) != tt.expected {
+				t.Errorf("printStatusLine() output = %q, want %q", string(output), tt.expected)
+			}
+		})
+	}
+}
+
 func TestWriteResponseBody_ToStdout(t *testing.T) {
 	opts := &cli.Options{
 		Output: "", // Write to stdout
@@ -239,6 +296,56 @@ func TestWriteResponseBody_ToFile(t *testing.T) {
 	}
 }
 
+func TestWriteResponseFile_Mode(t *testing.T) {
+	dir := t.TempDir()
+
+	// A plain create gives the mode the umask allows
+	reference, err := os.OpenFile(filepath.Join(dir, "reference"), os.O_CREATE|os.O_WRONLY, 0o666)
+	if err != nil {
+		t.Fatalf("failed to create reference file: %v", err)
+	}
+	info, _ := reference.Stat()
+	reference.Close()
+	umasked := info.Mode().Perm()
+
+	existing := filepath.Join(dir, "secret.txt")
+	if err := os.WriteFile(existing, []byte("old"), 0o600); err != nil {
+		t.Fatalf("failed to write existing file: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want os.FileMode
+	}{
+		{"replacing keeps the existing mode", existing, 0o600},
+		{"new file follows the umask", filepath.Join(dir, "new.txt"), umasked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(&cli.Options{Output: tt.path})
+			resp := &http.Response{Body: io.NopCloser(strings.NewReader("body"))}
+			if err := handler.writeResponseBody(resp); err != nil {
+				t.Fatalf("writeResponseBody() error = %v", err)
+			}
+
+			info, err := os.Stat(tt.path)
+			if err != nil {
+				t.Fatalf("failed to stat output: %v", err)
+			}
+			if info.Mode().Perm() != tt.want {
+				t.Errorf("mode = %v, want %v", info.Mode().Perm(), tt.want)
+			}
+		})
+	}
+
+	// No temporary files are left behind
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		t.Errorf("Expected only the output files in %s, got %d entries", dir, len(entries))
+	}
+}
+
 func TestPrintVerboseRequest(t *testing.T) {
 	opts := &cli.Options{}
 	handler := NewHandler(opts)
@@ -322,6 +429,463 @@ func TestPrintVerboseRequest_MasksAuthHeader(t *testing.T) {
 	}
 }
 
+func TestPrintVerbose_RedactsSecretHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   *cli.Options
+		hidden []string
+		shown  []string
+	}{
+		{
+			name:   "redacted by default",
+			opts:   &cli.Options{},
+			hidden: []string{"session=abc123", "Basic cHJveHk6cHc=", "session=def456", "Bearer tok"},
+			shown:  []string{"> Cookie: [REDACTED]", "> Proxy-Authorization: [REDACTED]", "< Set-Cookie: [REDACTED]", "> X-Api-Key: key-1"},
+		},
+		{
+			name:   "no-redact shows values",
+			opts:   &cli.Options{NoRedact: true},
+			shown:  []string{"> Cookie: session=abc123", "> Proxy-Authorization: Basic cHJveHk6cHc=", "< Set-Cookie: session=def456", "> AUTHORIZATION: Bearer tok"},
+			hidden: []string{"[REDACTED]"},
+		},
+		{
+			name:   "redact-header adds names",
+			opts:   &cli.Options{RedactHeaders: []string{"x-api-key"}},
+			hidden: []string{"key-1"},
+			shown:  []string{"> X-Api-Key: [REDACTED]"},
+		},
+	}
+
+	reqURL, _ := url.Parse("http://example.com/")
+	req := &http.Request{Method: "GET", URL: reqURL, Proto: "HTTP/1.1", Header: http.Header{
+		"Cookie":              {"session=abc123"},
+		"Proxy-Authorization": {"Basic cHJveHk6cHc="},
+		"X-Api-Key":           {"key-1"},
+		// --randomize-headers re-cases names, which must not unmask them
+		"AUTHORIZATION": {"Bearer tok"},
+	}}
+	resp := &http.Response{Proto: "HTTP/1.1", StatusCode: 200, Header: http.Header{
+		"Set-Cookie": {"session=def456"},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr strings.Builder
+			handler := NewHandler(tt.opts)
+			handler.Stderr = &stderr
+			handler.printVerboseRequest(req)
+			handler.printVerboseResponse(resp)
+
+			for _, secret := range tt.hidden {
+				if strings.Contains(stderr.String(), secret) {
+					t.Errorf("Expected %q to be hidden, got\n%s", secret, stderr.String())
+				}
+			}
+			for _, want := range tt.shown {
+				if !strings.Contains(stderr.String(), want) {
+					t.Errorf("Expected %q in output, got\n%s", want, stderr.String())
+				}
+			}
+		})
+	}
+}
+
+func TestPrintVerboseHeaders_SortedAndStable(t *testing.T) {
+	header := http.Header{
+		"X-Zeta":       {"z"},
+		"Accept":       {"*/*"},
+		"X-Multi":      {"second", "first"},
+		"Content-Type": {"application/json"},
+		"User-Agent":   {"purl/1.0"},
+	}
+	reqURL, _ := url.Parse("http://example.com/")
+	req := &http.Request{Method: "GET", URL: reqURL, Proto: "HTTP/1.1", Header: header}
+	resp := &http.Response{Proto: "HTTP/1.1", StatusCode: 200, Header: header}
+
+	render := func() string {
+		var stderr strings.Builder
+		handler := NewHandler(&cli.Options{})
+		handler.Stderr = &stderr
+		handler.printVerboseRequest(req)
+		handler.printVerboseResponse(resp)
+		return stderr.String()
+	}
+
+	first := render()
+	if second := render(); second != first {
+		t.Errorf("Expected identical output across calls, got\n%s\nthen\n%s", first, second)
+	}
+
+	want := "> GET / HTTP/1.1\n" +
+		"> Accept: */*\n" +
+		"> Content-Type: application/json\n" +
+		"> User-Agent: purl/1.0\n" +
+		"> X-Multi: second\n" +
+		"> X-Multi: first\n" +
+		"> X-Zeta: z\n" +
+		">\n"
+	if !strings.HasPrefix(first, want) {
+		t.Errorf("Expected request headers sorted by name with values in order, got\n%s", first)
+	}
+	if !strings.Contains(first, "< Accept: */*\n< Content-Type: application/json\n") {
+		t.Errorf("Expected response headers sorted by name, got\n%s", first)
+	}
+}
+
+func TestPrintVerboseRequest_MasksHeaderEnv(t *testing.T) {
+	opts := &cli.Options{
+		HeaderEnv: []string{"x-api-key=API_KEY"},
+	}
+	handler := NewHandler(opts)
+
+	url, _ := url.Parse("http://example.com/")
+	req := &http.Request{
+		Method: "GET",
+		URL:    url,
+		Proto:  "HTTP/1.1",
+		Header: http.Header{
+			"X-Api-Key": []string{"env-secret-value"},
+			"Accept":    []string{"text/plain"},
+		},
+	}
+
+	// Capture stderr
+	oldStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+
+	err := handler.printVerboseRequest(req)
+
+	w.Close()
+	os.Stderr = oldStderr
+
+	if err != nil {
+		t.Fatalf("printVerboseRequest() error = %v", err)
+	}
+
+	output, _ := io.ReadAll(r)
+	outputStr := string(output)
+
+	if strings.Contains(outputStr, "env-secret-value") {
+		t.Errorf("--header-env value not redacted: %q", outputStr)
+	}
+	if !strings.Contains(outputStr, "> X-Api-Key: [REDACTED]") {
+		t.Errorf("output missing redacted header: %q", outputStr)
+	}
+	if !strings.Contains(outputStr, "> Accept: text/plain") {
+		t.Errorf("unrelated header was altered: %q", outputStr)
+	}
+}
+
+func TestWriteResponse_StatusLineStderr(t *testing.T) {
+	var stdout, stderr strings.Builder
+	handler := NewHandler(&cli.Options{StatusLineStderr: true})
+	handler.Stdout = &stdout
+	handler.Stderr = &stderr
+
+	reqURL, _ := url.Parse("http://example.com/")
+	req := &http.Request{Method: "GET", URL: reqURL, Proto: "HTTP/1.1", Header: http.Header{}}
+	result := &protocol.ProbeResult{
+		Protocol:   "http",
+		StatusCode: 200,
+		Duration:   5 * time.Millisecond,
+		Response: &http.Response{
+			Proto:      "HTTP/1.1",
+			StatusCode: 200,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader("pure body")),
+		},
+	}
+
+	if err := handler.WriteResponse(req, result); err != nil {
+		t.Fatalf("WriteResponse() error = %v", err)
+	}
+
+	if stdout.String() != "pure body" {
+		t.Errorf("stdout = %q, want only the body", stdout.String())
+	}
+	if stderr.String() != "[HTTP] Status: 200 Time: 5ms\n" {
+		t.Errorf("stderr = %q, want the status line", stderr.String())
+	}
+}
+
+func TestWriteResponse_VerboseLevels(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     *cli.Options
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "level 1 prints request and response lines only",
+			opts:     &cli.Options{VerboseLevel: 1},
+			contains: []string{"> GET /api HTTP/1.1", "< HTTP/1.1 200 OK"},
+			excludes: []string{"> X-Test: value", "< Content-Type: text/plain", "* Total time"},
+		},
+		{
+			name:     "level 2 adds headers",
+			opts:     &cli.Options{VerboseLevel: 2},
+			contains: []string{"> GET /api HTTP/1.1", "> X-Test: value", "< HTTP/1.1 200 OK", "< Content-Type: text/plain"},
+			excludes: []string{"* Total time", "* Connected to"},
+		},
+		{
+			name:     "level 3 adds timing and connection details",
+			opts:     &cli.Options{VerboseLevel: 3},
+			contains: []string{"> X-Test: value", "< Content-Type: text/plain", "* Connected to example.com port 80 via HTTP", "* Total time: 120ms"},
+		},
+		{
+			name:     "-v matches level 2",
+			opts:     &cli.Options{Verbose: true},
+			contains: []string{"> X-Test: value", "< Content-Type: text/plain"},
+			excludes: []string{"* Total time"},
+		},
+		{
+			name:     "not verbose prints nothing",
+			opts:     &cli.Options{},
+			excludes: []string{"> GET", "< HTTP/1.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(tt.opts)
+
+			reqURL, _ := url.Parse("http://example.com/api")
+			req := &http.Request{
+				Method: "GET",
+				URL:    reqURL,
+				Proto:  "HTTP/1.1",
+				Header: http.Header{"X-Test": []string{"value"}},
+			}
+			result := &protocol.ProbeResult{
+				Protocol:   "http",
+				StatusCode: 200,
+				Duration:   120 * time.Millisecond,
+				Response: &http.Response{
+					Proto:      "HTTP/1.1",
+					StatusCode: 200,
+					Header:     http.Header{"Content-Type": []string{"text/plain"}},
+					Body:       io.NopCloser(strings.NewReader("")),
+				},
+			}
+
+			// Capture stdout and stderr
+			oldStdout, oldStderr := os.Stdout, os.Stderr
+			_, outW, _ := os.Pipe()
+			errR, errW, _ := os.Pipe()
+			os.Stdout, os.Stderr = outW, errW
+
+			err := handler.WriteResponse(req, result)
+
+			outW.Close()
+			errW.Close()
+			os.Stdout, os.Stderr = oldStdout, oldStderr
+
+			if err != nil {
+				t.Fatalf("WriteResponse() error = %v", err)
+			}
+
+			output, _ := io.ReadAll(errR)
+			outputStr := string(output)
+
+			for _, want := range tt.contains {
+				if !strings.Contains(outputStr, want) {
+					t.Errorf("stderr missing %q:\n%s", want, outputStr)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(outputStr, unwanted) {
+					t.Errorf("stderr unexpectedly contains %q:\n%s", unwanted, outputStr)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteResponse_Include(t *testing.T) {
+	var stdout, stderr strings.Builder
+	handler := NewHandler(&cli.Options{Include: true, StatusLineStderr: true})
+	handler.Stdout = &stdout
+	handler.Stderr = &stderr
+
+	reqURL, _ := url.Parse("http://example.com/")
+	req := &http.Request{Method: "GET", URL: reqURL, Proto: "HTTP/1.1", Header: http.Header{}}
+	result := &protocol.ProbeResult{
+		Protocol:   "http",
+		StatusCode: 404,
+		Duration:   5 * time.Millisecond,
+		Response: &http.Response{
+			Proto:      "HTTP/1.1",
+			StatusCode: 404,
+			Header: http.Header{
+				"X-Trace":      {"b"},
+				"Content-Type": {"text/plain"},
+				"Set-Cookie":   {"a=1", "b=2"},
+			},
+			Body: io.NopCloser(strings.NewReader("missing")),
+		},
+	}
+
+	if err := handler.WriteResponse(req, result); err != nil {
+		t.Fatalf("WriteResponse() error = %v", err)
+	}
+
+	want := "HTTP/1.1 404 Not Found\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"Set-Cookie: a=1\r\n" +
+		"Set-Cookie: b=2\r\n" +
+		"X-Trace: b\r\n" +
+		"\r\n" +
+		"missing"
+	if stdout.String() != want {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+	if strings.Contains(stderr.String(), "Content-Type") {
+		t.Errorf("stderr = %q, headers should only go to stdout", stderr.String())
+	}
+}
+
+func TestWriteResponse_HeadPrintsHeaders(t *testing.T) {
+	var stdout, stderr strings.Builder
+	handler := NewHandler(&cli.Options{Head: true, StatusLineStderr: true})
+	handler.Stdout = &stdout
+	handler.Stderr = &stderr
+
+	reqURL, _ := url.Parse("http://example.com/")
+	req := &http.Request{Method: "HEAD", URL: reqURL, Proto: "HTTP/1.1", Header: http.Header{}}
+	result := &protocol.ProbeResult{
+		Protocol:   "http",
+		StatusCode: 200,
+		Duration:   5 * time.Millisecond,
+		Response: &http.Response{
+			Proto:      "HTTP/1.1",
+			StatusCode: 200,
+			Header: http.Header{
+				"Content-Type":   {"text/html; charset=utf-8"},
+				"Content-Length": {"1256"},
+			},
+			Body: http.NoBody,
+		},
+	}
+
+	if err := handler.WriteResponse(req, result); err != nil {
+		t.Fatalf("WriteResponse() error = %v", err)
+	}
+
+	want := "HTTP/1.1 200 OK\r\n" +
+		"Content-Length: 1256\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n" +
+		"\r\n"
+	if stdout.String() != want {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestWriteResponse_Silent(t *testing.T) {
+	var stdout, stderr strings.Builder
+	handler := NewHandler(&cli.Options{Silent: true})
+	handler.Stdout = &stdout
+	handler.Stderr = &stderr
+
+	reqURL, _ := url.Parse("http://example.com/")
+	req := &http.Request{Method: "GET", URL: reqURL, Proto: "HTTP/1.1", Header: http.Header{}}
+	result := &protocol.ProbeResult{
+		Protocol:   "http",
+		StatusCode: 200,
+		Duration:   5 * time.Millisecond,
+		Response: &http.Response{
+			Proto:      "HTTP/1.1",
+			StatusCode: 200,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader("just the body")),
+		},
+	}
+
+	if err := handler.WriteResponse(req, result); err != nil {
+		t.Fatalf("WriteResponse() error = %v", err)
+	}
+
+	if stdout.String() != "just the body" {
+		t.Errorf("stdout = %q, want only the body", stdout.String())
+	}
+	if stderr.String() != "" {
+		t.Errorf("stderr = %q, want nothing", stderr.String())
+	}
+}
+
+func TestWriteResponse_TLSEarlyDataReport(t *testing.T) {
+	var stdout, stderr strings.Builder
+	handler := NewHandler(&cli.Options{VerboseLevel: 1, TLSResumption: true, TLSEarlyData: true})
+	handler.Stdout = &stdout
+	handler.Stderr = &stderr
+
+	reqURL, _ := url.Parse("https://example.com/")
+	req := &http.Request{Method: "GET", URL: reqURL, Proto: "HTTP/1.1", Header: http.Header{}}
+	result := &protocol.ProbeResult{
+		Protocol:   "https",
+		StatusCode: 200,
+		Response: &http.Response{
+			Proto:      "HTTP/1.1",
+			StatusCode: 200,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader("")),
+			TLS:        &tls.ConnectionState{Version: tls.VersionTLS13, DidResume: true},
+		},
+	}
+
+	if err := handler.WriteResponse(req, result); err != nil {
+		t.Fatalf("WriteResponse() error = %v", err)
+	}
+
+	for _, want := range []string{"* TLS session resumed: yes\n", "* TLS early data: not accepted", "TLS 1.3"} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("stderr = %q, want it to contain %q", stderr.String(), want)
+		}
+	}
+}
+
+func TestWriteResponse_AssumeContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		assume      string
+		contentType string
+		want        string
+	}{
+		{"mislabeled JSON pretty-printed under override", "application/json", "text/plain", "{\n  \"a\": 1,\n  \"b\": [\n    true\n  ]\n}\n"},
+		{"mislabeled JSON left raw without override", "", "text/plain", `{"a":1,"b":[true]}`},
+		{"labeled JSON pretty-printed", "", "application/problem+json", "{\n  \"a\": 1,\n  \"b\": [\n    true\n  ]\n}\n"},
+		{"override to text keeps JSON raw", "text/plain", "application/json", `{"a":1,"b":[true]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout strings.Builder
+			handler := NewHandler(&cli.Options{PrettyJSON: true, AssumeContentType: tt.assume, StatusLineStderr: true})
+			handler.Stdout = &stdout
+			handler.Stderr = io.Discard
+
+			reqURL, _ := url.Parse("http://example.com/")
+			req := &http.Request{Method: "GET", URL: reqURL, Proto: "HTTP/1.1", Header: http.Header{}}
+			result := &protocol.ProbeResult{
+				Protocol:   "http",
+				StatusCode: 200,
+				Response: &http.Response{
+					StatusCode: 200,
+					Header:     http.Header{"Content-Type": {tt.contentType}},
+					Body:       io.NopCloser(strings.NewReader(`{"a":1,"b":[true]}`)),
+				},
+			}
+
+			if err := handler.WriteResponse(req, result); err != nil {
+				t.Fatalf("WriteResponse() error = %v", err)
+			}
+			if stdout.String() != tt.want {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.want)
+			}
+		})
+	}
+}
+
 // Property-Based Tests
 
 // Property 7: Status Line Format
@@ -0,0 +1,46 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+)
+
+// WriteHTTPFile writes the request in the .http format used by editor REST clients:
+// request line, headers, a blank line, then the body
+// The body is read through GetBody so the request can still be sent afterwards
+func WriteHTTPFile(w io.Writer, req *http.Request) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "%s %s HTTP/1.1\n", req.Method, req.URL.String())
+
+	// Sort header names for stable output
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			fmt.Fprintf(bw, "%s: %s\n", name, value)
+		}
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+		defer body.Close()
+
+		bw.WriteString("\n")
+		if _, err := io.Copy(bw, body); err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+		bw.WriteString("\n")
+	}
+
+	return bw.Flush()
+}
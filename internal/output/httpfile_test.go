@@ -0,0 +1,63 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWriteHTTPFile_RoundTrip(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "https://example.com/api/items?limit=5", strings.NewReader(`{"name":"x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Add("X-Multi", "a")
+	req.Header.Add("X-Multi", "b")
+
+	var buf bytes.Buffer
+	if err := WriteHTTPFile(&buf, req); err != nil {
+		t.Fatalf("WriteHTTPFile failed: %v", err)
+	}
+
+	parsed, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(buf.Bytes())))
+	if err != nil {
+		t.Fatalf("written file does not parse back: %v\n%s", err, buf.String())
+	}
+
+	if parsed.Method != req.Method {
+		t.Errorf("Method = %q, want %q", parsed.Method, req.Method)
+	}
+	if parsed.URL.String() != req.URL.String() {
+		t.Errorf("URL = %q, want %q", parsed.URL.String(), req.URL.String())
+	}
+	for name, values := range req.Header {
+		if got := parsed.Header.Values(name); strings.Join(got, ",") != strings.Join(values, ",") {
+			t.Errorf("Header %s = %v, want %v", name, got, values)
+		}
+	}
+
+	if !strings.HasSuffix(buf.String(), "\n\n{\"name\":\"x\"}\n") {
+		t.Errorf("Expected body after a blank line, got:\n%s", buf.String())
+	}
+
+	// The request body is still available for sending
+	body, _ := io.ReadAll(req.Body)
+	if string(body) != `{"name":"x"}` {
+		t.Errorf("Request body consumed, got %q", string(body))
+	}
+}
+
+func TestWriteHTTPFile_NoBody(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/", nil)
+
+	var buf bytes.Buffer
+	if err := WriteHTTPFile(&buf, req); err != nil {
+		t.Fatalf("WriteHTTPFile failed: %v", err)
+	}
+
+	if buf.String() != "GET http://localhost:8080/ HTTP/1.1\n" {
+		t.Errorf("Unexpected output: %q", buf.String())
+	}
+}
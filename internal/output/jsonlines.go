@@ -0,0 +1,105 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// jsonLine is one streamed body chunk or Server-Sent Event
+type jsonLine struct {
+	Time   string `json:"time"`
+	Offset int64  `json:"offset"`
+	Size   int    `json:"size"`
+	Event  string `json:"event,omitempty"`
+	ID     string `json:"id,omitempty"`
+	Data   string `json:"data"`
+}
+
+// writeJSONLines writes each chunk read from body as a JSON line with a timestamp
+// and the byte offset of the chunk within the body
+func writeJSONLines(w io.Writer, body io.Reader) error {
+	encoder := json.NewEncoder(w)
+	buf := make([]byte, 32*1024)
+	var offset int64
+
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			line := jsonLine{
+				Time:   time.Now().Format(time.RFC3339Nano),
+				Offset: offset,
+				Size:   n,
+				Data:   string(buf[:n]),
+			}
+			if encErr := encoder.Encode(line); encErr != nil {
+				return fmt.Errorf("failed to write JSON line: %w", encErr)
+			}
+			offset += int64(n)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read response body: %w", err)
+		}
+	}
+}
+
+// writeSSEJSONLines parses body as a Server-Sent Events stream and writes each
+// event as a JSON line; the offset is where the event starts in the body
+func writeSSEJSONLines(w io.Writer, body io.Reader) error {
+	encoder := json.NewEncoder(w)
+	reader := bufio.NewReader(body)
+
+	var offset, start int64
+	var event jsonLine
+	var data []string
+	pending := false
+
+	for {
+		raw, err := reader.ReadString('\n')
+		if raw != "" {
+			if !pending {
+				start = offset
+			}
+			offset += int64(len(raw))
+
+			line := strings.TrimRight(raw, "\r\n")
+			if line == "" {
+				// A blank line dispatches the event
+				if pending {
+					event.Time = time.Now().Format(time.RFC3339Nano)
+					event.Offset = start
+					event.Size = int(offset - start)
+					event.Data = strings.Join(data, "\n")
+					if encErr := encoder.Encode(event); encErr != nil {
+						return fmt.Errorf("failed to write JSON line: %w", encErr)
+					}
+				}
+				event, data, pending = jsonLine{}, nil, false
+			} else {
+				pending = true
+				field, value, _ := strings.Cut(line, ":")
+				value = strings.TrimPrefix(value, " ")
+				switch field {
+				case "event":
+					event.Event = value
+				case "id":
+					event.ID = value
+				case "data":
+					data = append(data, value)
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read response body: %w", err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeJSONLines(t *testing.T, output string) []jsonLine {
+	t.Helper()
+
+	var lines []jsonLine
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		var line jsonLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestWriteJSONLines_ChunkedServer(t *testing.T) {
+	chunks := []string{"first", "second-chunk", "third"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, chunk := range chunks {
+			w.Write([]byte(chunk))
+			w.(http.Flusher).Flush()
+			time.Sleep(20 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var out strings.Builder
+	if err := writeJSONLines(&out, resp.Body); err != nil {
+		t.Fatalf("writeJSONLines failed: %v", err)
+	}
+
+	lines := decodeJSONLines(t, out.String())
+	if len(lines) != len(chunks) {
+		t.Fatalf("Expected %d JSON lines, got %d:\n%s", len(chunks), len(lines), out.String())
+	}
+
+	var offset int64
+	for i, line := range lines {
+		if line.Data != chunks[i] {
+			t.Errorf("line %d data = %q, want %q", i, line.Data, chunks[i])
+		}
+		if line.Offset != offset {
+			t.Errorf("line %d offset = %d, want %d", i, line.Offset, offset)
+		}
+		if _, err := time.Parse(time.RFC3339Nano, line.Time); err != nil {
+			t.Errorf("line %d has invalid timestamp %q", i, line.Time)
+		}
+		offset += int64(len(chunks[i]))
+	}
+}
+
+func TestWriteSSEJSONLines(t *testing.T) {
+	stream := "event: update\nid: 1\ndata: hello\n\n" +
+		": comment\ndata: line one\ndata: line two\n\n" +
+		"data: trailing without blank line\n"
+
+	var out strings.Builder
+	if err := writeSSEJSONLines(&out, strings.NewReader(stream)); err != nil {
+		t.Fatalf("writeSSEJSONLines failed: %v", err)
+	}
+
+	lines := decodeJSONLines(t, out.String())
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 events, got %d:\n%s", len(lines), out.String())
+	}
+
+	if lines[0].Event != "update" || lines[0].ID != "1" || lines[0].Data != "hello" || lines[0].Offset != 0 {
+		t.Errorf("first event = %+v", lines[0])
+	}
+	if lines[1].Data != "line one\nline two" || lines[1].Offset != int64(len("event: update\nid: 1\ndata: hello\n\n")) {
+		t.Errorf("second event = %+v", lines[1])
+	}
+	if lines[1].Offset <= lines[0].Offset {
+		t.Errorf("offsets not increasing: %d then %d", lines[0].Offset, lines[1].Offset)
+	}
+}
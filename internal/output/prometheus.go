@@ -0,0 +1,161 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aleister1102/purl/internal/protocol"
+)
+
+// promMetric is a single gauge in the exposition output
+type promMetric struct {
+	name  string
+	help  string
+	value string
+}
+
+// WritePrometheus writes probe metrics in the Prometheus text exposition format
+// Metric names mirror blackbox_exporter so existing dashboards and alerts apply
+func WritePrometheus(w io.Writer, result *protocol.ProbeResult) error {
+	return writeMetricFamilies(w, probeMetrics(result), unlabelled)
+}
+
+// probeMetrics returns the gauges reported for one result
+// The duration is the request's that was sent, or the probe's when it never was
+func probeMetrics(result *protocol.ProbeResult) []promMetric {
+	success := 0
+	statusCode := 0
+	var duration float64
+
+	if result != nil {
+		duration = result.Elapsed().Seconds()
+		statusCode = result.StatusCode
+		if result.Error == nil && statusCode >= 200 && statusCode < 300 {
+			success = 1
+		}
+	}
+
+	metrics := []promMetric{
+		{
+			name:  "probe_success",
+			help:  "Displays whether or not the probe was a success",
+			value: strconv.Itoa(success),
+		},
+		{
+			name:  "probe_duration_seconds",
+			help:  "Returns how long the probe took to complete in seconds",
+			value: strconv.FormatFloat(duration, 'f', -1, 64),
+		},
+		{
+			name:  "probe_http_status_code",
+			help:  "Response HTTP status code",
+			value: strconv.Itoa(statusCode),
+		},
+	}
+
+	// Certificate expiry is only reported for TLS connections
+	if result != nil && result.Response != nil && result.Response.TLS != nil {
+		certs := result.Response.TLS.PeerCertificates
+		if len(certs) > 0 {
+			earliest := certs[0].NotAfter
+			for _, cert := range certs[1:] {
+				if cert.NotAfter.Before(earliest) {
+					earliest = cert.NotAfter
+				}
+			}
+			metrics = append(metrics, promMetric{
+				name:  "probe_ssl_earliest_cert_expiry",
+				help:  "Returns earliest SSL cert expiry in unixtime",
+				value: strconv.FormatInt(earliest.Unix(), 10),
+			})
+		}
+	}
+
+	return metrics
+}
+
+// Metrics collects --prometheus results over a run so each file is written once
+// at the end; a file keeps the last result of every target sent to it
+type Metrics struct {
+	mu    sync.Mutex
+	paths []string
+	files map[string]map[string][]promMetric // path → target → gauges
+}
+
+// NewMetrics returns an empty collector
+func NewMetrics() *Metrics {
+	return &Metrics{files: map[string]map[string][]promMetric{}}
+}
+
+// Add records the result for target in the metrics file at path
+// It is safe for concurrent use by --parallel workers
+func (m *Metrics) Add(path, target string, result *protocol.ProbeResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.files[path] == nil {
+		m.files[path] = map[string][]promMetric{}
+		m.paths = append(m.paths, path)
+	}
+	m.files[path][target] = probeMetrics(result)
+}
+
+// Paths returns the metrics files in the order they were first added
+func (m *Metrics) Paths() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.paths)
+}
+
+// Write writes the metrics collected for path to w
+// A single target is written unlabelled like blackbox_exporter; several are
+// told apart by a target label
+func (m *Metrics) Write(w io.Writer, path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	targets := slices.Sorted(maps.Keys(m.files[path]))
+	if len(targets) == 1 {
+		return writeMetricFamilies(w, m.files[path][targets[0]], unlabelled)
+	}
+
+	// Group each gauge's samples under one HELP and TYPE header
+	var families []promMetric
+	samples := map[string][]string{}
+	for _, target := range targets {
+		for _, metric := range m.files[path][target] {
+			if _, seen := samples[metric.name]; !seen {
+				families = append(families, metric)
+			}
+			samples[metric.name] = append(samples[metric.name],
+				fmt.Sprintf("%s{target=\"%s\"} %s", metric.name, labelEscaper.Replace(target), metric.value))
+		}
+	}
+	return writeMetricFamilies(w, families, func(metric promMetric) string {
+		return strings.Join(samples[metric.name], "\n")
+	})
+}
+
+// labelEscaper escapes a label value for the text exposition format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// unlabelled returns the single sample line of a gauge without labels
+func unlabelled(metric promMetric) string {
+	return metric.name + " " + metric.value
+}
+
+// writeMetricFamilies writes each gauge's HELP and TYPE lines followed by the
+// sample lines that lines returns for it
+func writeMetricFamilies(w io.Writer, metrics []promMetric, lines func(promMetric) string) error {
+	for _, metric := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s\n",
+			metric.name, metric.help, metric.name, lines(metric)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package output
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aleister1102/purl/internal/protocol"
+)
+
+func TestWritePrometheus_SuccessfulTLSProbe(t *testing.T) {
+	earliest := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	result := &protocol.ProbeResult{
+		Protocol:   "https",
+		StatusCode: 200,
+		Duration:   1500 * time.Millisecond,
+		Response: &http.Response{
+			StatusCode: 200,
+			TLS: &tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{
+					{NotAfter: earliest.AddDate(1, 0, 0)},
+					{NotAfter: earliest},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, result); err != nil {
+		t.Fatalf("WritePrometheus() error = %v", err)
+	}
+	output := buf.String()
+
+	expected := []string{
+		"# TYPE probe_success gauge\nprobe_success 1\n",
+		"probe_duration_seconds 1.5\n",
+		"probe_http_status_code 200\n",
+		fmt.Sprintf("probe_ssl_earliest_cert_expiry %d\n", earliest.Unix()),
+	}
+	for _, want := range expected {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestWritePrometheus_FailedProbe(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *protocol.ProbeResult
+	}{
+		{
+			name:   "connection error",
+			result: &protocol.ProbeResult{Protocol: "http", Error: fmt.Errorf("connection refused")},
+		},
+		{
+			name:   "server error status",
+			result: &protocol.ProbeResult{Protocol: "http", StatusCode: 503, Response: &http.Response{StatusCode: 503}},
+		},
+		{
+			name:   "no result",
+			result: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WritePrometheus(&buf, tt.result); err != nil {
+				t.Fatalf("WritePrometheus() error = %v", err)
+			}
+			output := buf.String()
+
+			if !strings.Contains(output, "probe_success 0\n") {
+				t.Errorf("expected probe_success 0:\n%s", output)
+			}
+			if strings.Contains(output, "probe_ssl_earliest_cert_expiry") {
+				t.Errorf("expected no certificate expiry without TLS:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestMetrics_Write(t *testing.T) {
+	m := NewMetrics()
+	m.Add("a.prom", "http://one", &protocol.ProbeResult{StatusCode: 500, Response: &http.Response{StatusCode: 500}})
+	// A repeated target keeps its last result
+	m.Add("a.prom", "http://one", &protocol.ProbeResult{StatusCode: 200, RequestTime: 250 * time.Millisecond, Response: &http.Response{StatusCode: 200}})
+	m.Add("a.prom", `http://two/"q"`, &protocol.ProbeResult{Error: fmt.Errorf("connection refused")})
+	m.Add("b.prom", "http://one", &protocol.ProbeResult{StatusCode: 204, Response: &http.Response{StatusCode: 204}})
+
+	if paths := m.Paths(); len(paths) != 2 || paths[0] != "a.prom" || paths[1] != "b.prom" {
+		t.Fatalf("Paths() = %v", paths)
+	}
+
+	var several bytes.Buffer
+	if err := m.Write(&several, "a.prom"); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	want := "# HELP probe_success Displays whether or not the probe was a success\n" +
+		"# TYPE probe_success gauge\n" +
+		"probe_success{target=\"http://one\"} 1\n" +
+		"probe_success{target=\"http://two/\\\"q\\\"\"} 0\n"
+	if !strings.HasPrefix(several.String(), want) {
+		t.Errorf("Write() = %q, want it to start with %q", several.String(), want)
+	}
+	if !strings.Contains(several.String(), "probe_duration_seconds{target=\"http://one\"} 0.25\n") {
+		t.Errorf("Expected the request's duration:\n%s", several.String())
+	}
+
+	// A single target is written like WritePrometheus, without labels
+	var single bytes.Buffer
+	if err := m.Write(&single, "b.prom"); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if !strings.Contains(single.String(), "probe_http_status_code 204\n") || strings.Contains(single.String(), "{") {
+		t.Errorf("Expected unlabelled metrics for one target:\n%s", single.String())
+	}
+}
@@ -0,0 +1,78 @@
+package output
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/aleister1102/purl/internal/protocol"
+)
+
+// StatusJSON is the record --output-format json prints in place of the status line
+type StatusJSON struct {
+	URL          string      `json:"url"`
+	Protocol     string      `json:"protocol"`
+	StatusCode   int         `json:"status_code"`
+	TimeTotalMS  float64     `json:"time_total_ms"`
+	Headers      http.Header `json:"headers"`
+	Body         *string     `json:"body,omitempty"`          // only with --include-body
+	BodyEncoding string      `json:"body_encoding,omitempty"` // "base64" when the body is not valid UTF-8
+}
+
+// printStatusJSON prints the status line as a single JSON object
+// With --include-body the body is read into the object instead of being streamed
+// Unlike the text status line it is printed even with -s, since it is the output
+func (h *Handler) printStatusJSON(req *http.Request, result *protocol.ProbeResult) error {
+	record := StatusJSON{
+		URL:         req.URL.String(),
+		Protocol:    result.Protocol,
+		StatusCode:  result.StatusCode,
+		TimeTotalMS: float64(result.Elapsed().Microseconds()) / 1000,
+		Headers:     http.Header{},
+	}
+
+	if resp := result.Response; resp != nil {
+		record.Headers = resp.Header
+		if h.opts.IncludeBody && resp.Body != nil {
+			body, err := h.readBody(resp)
+			if err != nil {
+				return err
+			}
+			text := string(body)
+			if !utf8.Valid(body) {
+				text = base64.StdEncoding.EncodeToString(body)
+				record.BodyEncoding = "base64"
+			}
+			record.Body = &text
+		}
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode status: %w", err)
+	}
+	w := h.stdout()
+	if h.opts.StatusLineStderr {
+		w = h.stderr()
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// readBody reads the whole response body, applying --max-filesize
+func (h *Handler) readBody(resp *http.Response) ([]byte, error) {
+	if h.opts.MaxFileSize > 0 {
+		if err := limitBody(resp, h.opts.MaxFileSize); err != nil {
+			return nil, err
+		}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return body, nil
+}
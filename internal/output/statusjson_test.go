@@ -0,0 +1,99 @@
+package output
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/protocol"
+)
+
+func TestWriteResponse_OutputFormatJSON(t *testing.T) {
+	tests := []struct {
+		name         string
+		includeBody  bool
+		body         string
+		wantBody     *string
+		wantEncoding string
+		wantStdout   string // after the JSON line
+	}{
+		{
+			name:       "body streams after the JSON line",
+			body:       "hello",
+			wantStdout: "hello",
+		},
+		{
+			name:        "UTF-8 body included as a string",
+			includeBody: true,
+			body:        "héllo",
+			wantBody:    ptr("héllo"),
+		},
+		{
+			name:         "binary body included as base64",
+			includeBody:  true,
+			body:         "\xff\xfe\x00",
+			wantBody:     ptr(base64.StdEncoding.EncodeToString([]byte("\xff\xfe\x00"))),
+			wantEncoding: "base64",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout strings.Builder
+			handler := NewHandler(&cli.Options{OutputFormat: "json", IncludeBody: tt.includeBody})
+			handler.Stdout = &stdout
+			handler.Stderr = io.Discard
+
+			reqURL, _ := url.Parse("https://example.com/api?q=1")
+			req := &http.Request{Method: "GET", URL: reqURL, Proto: "HTTP/1.1", Header: http.Header{}}
+			result := &protocol.ProbeResult{
+				Protocol:   "https",
+				StatusCode: 201,
+				// The probe's time must not be reported for the request that was sent
+				Duration:    1500 * time.Microsecond,
+				RequestTime: 2500 * time.Microsecond,
+				Response: &http.Response{
+					StatusCode: 201,
+					Header:     http.Header{"Content-Type": {"text/plain"}, "X-Trace": {"a", "b"}},
+					Body:       io.NopCloser(strings.NewReader(tt.body)),
+					Request:    req,
+				},
+			}
+
+			if err := handler.WriteResponse(req, result); err != nil {
+				t.Fatalf("WriteResponse() error = %v", err)
+			}
+
+			line, rest, _ := strings.Cut(stdout.String(), "\n")
+			var got StatusJSON
+			if err := json.Unmarshal([]byte(line), &got); err != nil {
+				t.Fatalf("Expected a JSON line, got %q: %v", line, err)
+			}
+			if got.URL != "https://example.com/api?q=1" || got.Protocol != "https" || got.StatusCode != 201 || got.TimeTotalMS != 2.5 {
+				t.Errorf("Unexpected fields: %+v", got)
+			}
+			if strings.Join(got.Headers.Values("X-Trace"), ",") != "a,b" {
+				t.Errorf("headers = %v, want X-Trace a,b", got.Headers)
+			}
+			if (got.Body == nil) != (tt.wantBody == nil) || (got.Body != nil && *got.Body != *tt.wantBody) {
+				t.Errorf("body = %v, want %v", got.Body, tt.wantBody)
+			}
+			if got.BodyEncoding != tt.wantEncoding {
+				t.Errorf("body_encoding = %q, want %q", got.BodyEncoding, tt.wantEncoding)
+			}
+			if rest != tt.wantStdout {
+				t.Errorf("stdout after JSON = %q, want %q", rest, tt.wantStdout)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,49 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/aleister1102/purl/internal/protocol"
+)
+
+// Summary is the one-line JSON record written by --summary-json
+type Summary struct {
+	URL        string  `json:"url"`
+	Status     int     `json:"status"`
+	Protocol   string  `json:"protocol"`
+	TLSVersion string  `json:"tls_version"`
+	TimeMS     float64 `json:"time_ms"`
+	Size       int64   `json:"size"`
+	RemoteIP   string  `json:"remote_ip"`
+}
+
+// NewSummary collects the summary for a finished request
+// size is the number of body bytes received; the time and peer come from the
+// trace of the request that was sent, not the probe
+func NewSummary(req *http.Request, result *protocol.ProbeResult, size int64, trace *RequestTrace) Summary {
+	summary := Summary{
+		URL:      req.URL.String(),
+		Status:   result.StatusCode,
+		Protocol: result.Protocol,
+		TimeMS:   float64(trace.Elapsed().Microseconds()) / 1000,
+		Size:     size,
+		RemoteIP: trace.RemoteIP(),
+	}
+	if result.Response != nil && result.Response.TLS != nil {
+		summary.TLSVersion = getTLSVersionString(result.Response.TLS.Version)
+	}
+	return summary
+}
+
+// WriteSummaryJSON writes summary to w as a single JSON line
+func WriteSummaryJSON(w io.Writer, summary Summary) error {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to encode summary: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
@@ -0,0 +1,95 @@
+package output
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aleister1102/purl/internal/protocol"
+)
+
+func TestSummaryJSON_TLSRequest(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("hello summary"))
+	}))
+	server.TLS = &tls.Config{MinVersion: tls.VersionTLS13}
+	server.StartTLS()
+	defer server.Close()
+
+	req, err := http.NewRequest("GET", server.URL+"/path", nil)
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+	req, trace := NewRequestTrace(req)
+
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	result := &protocol.ProbeResult{
+		Protocol:   "https",
+		StatusCode: resp.StatusCode,
+		Duration:   1500 * time.Microsecond,
+		Response:   resp,
+	}
+
+	var out strings.Builder
+	if err := WriteSummaryJSON(&out, NewSummary(req, result, int64(len(body)), trace)); err != nil {
+		t.Fatalf("WriteSummaryJSON failed: %v", err)
+	}
+	if strings.Count(out.String(), "\n") != 1 || !strings.HasSuffix(out.String(), "\n") {
+		t.Errorf("Expected a single JSON line, got %q", out.String())
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(out.String()), &got); err != nil {
+		t.Fatalf("summary is not JSON: %v", err)
+	}
+
+	want := map[string]any{
+		"url":         server.URL + "/path",
+		"status":      float64(200),
+		"protocol":    "https",
+		"tls_version": "TLS 1.3",
+		"size":        float64(len("hello summary")),
+		"remote_ip":   "127.0.0.1",
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %v, want %v", key, got[key], value)
+		}
+	}
+	// The time is the request's, which the handler delays, not the probe's 1.5ms
+	if ms, _ := got["time_ms"].(float64); ms < 20 {
+		t.Errorf("time_ms = %v, want the request's time of at least 20ms", got["time_ms"])
+	}
+	if len(got) != len(want)+1 {
+		t.Errorf("Expected %d keys, got %v", len(want), got)
+	}
+}
+
+func TestNewSummary_PlainHTTP(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+	result := &protocol.ProbeResult{
+		Protocol:   "http",
+		StatusCode: 404,
+		Response:   &http.Response{StatusCode: 404},
+	}
+
+	summary := NewSummary(req, result, 0, &RequestTrace{})
+	if summary.TLSVersion != "" {
+		t.Errorf("Expected no TLS version over plain HTTP, got %q", summary.TLSVersion)
+	}
+	if summary.Status != 404 || summary.Protocol != "http" {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+}
@@ -0,0 +1,70 @@
+package output
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+// CheckTLSVersion fails when --expect-tls-version is set and the response
+// was not received over that TLS version
+func CheckTLSVersion(resp *http.Response, opts *cli.Options) error {
+	if opts.ExpectTLSVersion == 0 {
+		return nil
+	}
+
+	host := resp.Request.URL.Host
+	expected := getTLSVersionString(opts.ExpectTLSVersion)
+	if resp.TLS == nil {
+		return &errors.TLSError{Host: host, Cause: fmt.Errorf("expected %s but the connection is not TLS", expected)}
+	}
+	if resp.TLS.Version != opts.ExpectTLSVersion {
+		return &errors.TLSError{
+			Host:  host,
+			Cause: fmt.Errorf("negotiated %s, expected %s", getTLSVersionString(resp.TLS.Version), expected),
+		}
+	}
+	return nil
+}
+
+// DefaultWeakCiphers returns the cipher suites --fail-on-weak-tls rejects unless
+// --weak-ciphers replaces the list: those crypto/tls considers insecure
+func DefaultWeakCiphers() []string {
+	var names []string
+	for _, suite := range tls.InsecureCipherSuites() {
+		names = append(names, suite.Name)
+	}
+	return names
+}
+
+// CheckWeakTLS fails when --fail-on-weak-tls is set and the response was received
+// below TLS 1.2 or with a cipher suite from the weak list
+func CheckWeakTLS(resp *http.Response, opts *cli.Options) error {
+	if !opts.FailOnWeakTLS || resp.TLS == nil {
+		return nil
+	}
+
+	host := resp.Request.URL.Host
+	if resp.TLS.Version < tls.VersionTLS12 {
+		return &errors.TLSError{
+			Host:  host,
+			Cause: fmt.Errorf("weak TLS version %s negotiated (minimum TLS 1.2)", getTLSVersionString(resp.TLS.Version)),
+		}
+	}
+
+	weak := opts.WeakCiphers
+	if len(weak) == 0 {
+		weak = DefaultWeakCiphers()
+	}
+	if cipher := getCipherSuiteName(resp.TLS.CipherSuite); slices.Contains(weak, cipher) {
+		return &errors.TLSError{
+			Host:  host,
+			Cause: fmt.Errorf("weak cipher suite %s negotiated over %s", cipher, getTLSVersionString(resp.TLS.Version)),
+		}
+	}
+	return nil
+}
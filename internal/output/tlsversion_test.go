@@ -0,0 +1,114 @@
+package output
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+func TestCheckTLSVersion(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	tests := []struct {
+		name     string
+		expected uint16
+		wantErr  string
+	}{
+		{"no expectation", 0, ""},
+		{"matching version", tls.VersionTLS12, ""},
+		{"downgrade detected", tls.VersionTLS13, "negotiated TLS 1.2, expected TLS 1.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckTLSVersion(resp, &cli.Options{ExpectTLSVersion: tt.expected})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if code := errors.MapErrorToExitCode(err); code != errors.ExitTLSError {
+				t.Errorf("Expected exit code %d, got %d", errors.ExitTLSError, code)
+			}
+		})
+	}
+}
+
+func TestCheckTLSVersion_PlainHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	err = CheckTLSVersion(resp, &cli.Options{ExpectTLSVersion: tls.VersionTLS13})
+	if err == nil || !strings.Contains(err.Error(), "not TLS") {
+		t.Errorf("Expected a not-TLS error, got %v", err)
+	}
+}
+
+func TestCheckWeakTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		MaxVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256},
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	client := server.Client()
+	client.Transport.(*http.Transport).TLSClientConfig.CipherSuites = []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	tests := []struct {
+		name    string
+		opts    *cli.Options
+		wantErr string
+	}{
+		{"check disabled", &cli.Options{}, ""},
+		{"default list flags CBC-SHA256", &cli.Options{FailOnWeakTLS: true}, "weak cipher suite TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256 negotiated over TLS 1.2"},
+		{"custom list without the cipher", &cli.Options{FailOnWeakTLS: true, WeakCiphers: []string{"TLS_RSA_WITH_RC4_128_SHA"}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckWeakTLS(resp, tt.opts)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package output
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// RequestTrace records when a request started, when each connection phase
+// finished and the peer it connected to, for -w and --summary-json
+type RequestTrace struct {
+	start       time.Time
+	dnsDone     time.Time
+	connectDone time.Time
+	tlsDone     time.Time
+	firstByte   time.Time
+	remoteAddr  string
+}
+
+// NewRequestTrace returns req with hooks recording into a new RequestTrace
+// Timing starts when the request asks for a connection
+func NewRequestTrace(req *http.Request) (*http.Request, *RequestTrace) {
+	t := &RequestTrace{}
+	trace := &httptrace.ClientTrace{
+		GetConn: func(string) {
+			if t.start.IsZero() {
+				t.start = time.Now()
+			}
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			if info.Err == nil && t.dnsDone.IsZero() {
+				t.dnsDone = time.Now()
+			}
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil && t.connectDone.IsZero() {
+				t.connectDone = time.Now()
+			}
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil && t.tlsDone.IsZero() {
+				t.tlsDone = time.Now()
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.remoteAddr = info.Conn.RemoteAddr().String()
+		},
+		GotFirstResponseByte: func() {
+			if t.firstByte.IsZero() {
+				t.firstByte = time.Now()
+			}
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), t
+}
+
+// RemoteIP returns the IP address of the peer the request used
+func (t *RequestTrace) RemoteIP() string {
+	host, _, err := net.SplitHostPort(t.remoteAddr)
+	if err != nil {
+		return t.remoteAddr
+	}
+	return host
+}
+
+// NameLookupTime returns the time from the start until the hostname was
+// resolved, 0 when no lookup was needed
+func (t *RequestTrace) NameLookupTime() time.Duration {
+	return t.since(t.dnsDone)
+}
+
+// ConnectTime returns the time from the start until the TCP connection was
+// established, 0 when an idle connection was reused
+func (t *RequestTrace) ConnectTime() time.Duration {
+	return t.since(t.connectDone)
+}
+
+// AppConnectTime returns the time from the start until the TLS handshake
+// completed, 0 without TLS or when an idle connection was reused
+func (t *RequestTrace) AppConnectTime() time.Duration {
+	return t.since(t.tlsDone)
+}
+
+// StartTransferTime returns the time from the start until the first
+// response byte arrived
+func (t *RequestTrace) StartTransferTime() time.Duration {
+	return t.since(t.firstByte)
+}
+
+// since returns the time from the start until at, 0 when either is unset
+func (t *RequestTrace) since(at time.Time) time.Duration {
+	if t.start.IsZero() || at.IsZero() {
+		return 0
+	}
+	return at.Sub(t.start)
+}
+
+// Elapsed returns the time since the request started
+func (t *RequestTrace) Elapsed() time.Duration {
+	if t.start.IsZero() {
+		return 0
+	}
+	return time.Since(t.start)
+}
@@ -0,0 +1,141 @@
+package output
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// TraceWriter dumps every byte sent and received for --trace and --trace-ascii,
+// each chunk headed by a timestamp and a direction marker as curl does
+type TraceWriter struct {
+	mu    sync.Mutex
+	w     io.Writer
+	ascii bool // print text lines only instead of hex with an ASCII column
+}
+
+// NewTraceWriter creates a trace writing to w, ASCII-only when ascii is set
+func NewTraceWriter(w io.Writer, ascii bool) *TraceWriter {
+	return &TraceWriter{w: w, ascii: ascii}
+}
+
+// WrapConn returns conn with its reads and writes recorded in the trace
+// The TLS state stays visible to net/http, so response TLS details survive
+func (t *TraceWriter) WrapConn(conn net.Conn) net.Conn {
+	t.info(fmt.Sprintf("Connected to %s", conn.RemoteAddr()))
+	traced := &tracedConn{Conn: conn, trace: t}
+	if stater, ok := conn.(connectionStater); ok {
+		return &tracedTLSConn{tracedConn: traced, stater: stater}
+	}
+	return traced
+}
+
+// info records a line of connection information
+func (t *TraceWriter) info(message string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintf(t.w, "%s == Info: %s\n", traceTime(), message)
+}
+
+// record dumps one chunk of data under a "=> Send" or "<= Recv" marker
+func (t *TraceWriter) record(direction string, p []byte) {
+	if len(p) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintf(t.w, "%s %s %d bytes (0x%x)\n", traceTime(), direction, len(p), len(p))
+	if t.ascii {
+		writeASCIIDump(t.w, p)
+	} else {
+		writeHexDump(t.w, p)
+	}
+}
+
+// traceTime formats the current time as curl's --trace-time does
+func traceTime() string {
+	return time.Now().Format("15:04:05.000000")
+}
+
+// writeHexDump writes 16 bytes per line as hex followed by their printable characters
+func writeHexDump(w io.Writer, p []byte) {
+	const width = 16
+	for offset := 0; offset < len(p); offset += width {
+		line := p[offset:min(offset+width, len(p))]
+
+		var hex bytes.Buffer
+		for i := range width {
+			if i < len(line) {
+				fmt.Fprintf(&hex, "%02x ", line[i])
+			} else {
+				hex.WriteString("   ")
+			}
+		}
+		fmt.Fprintf(w, "%04x: %s%s\n", offset, hex.String(), printable(line))
+	}
+}
+
+// writeASCIIDump writes the data as text, one line per CRLF-terminated line
+// and at most 64 bytes per line; the line terminators themselves are dropped
+func writeASCIIDump(w io.Writer, p []byte) {
+	const width = 64
+	for offset := 0; offset < len(p); {
+		line := p[offset:min(offset+width, len(p))]
+		if end := bytes.IndexByte(line, '\n'); end >= 0 {
+			line = line[:end+1]
+		}
+		fmt.Fprintf(w, "%04x: %s\n", offset, printable(bytes.TrimRight(line, "\r\n")))
+		offset += len(line)
+	}
+}
+
+// printable replaces bytes outside printable ASCII with '.'
+func printable(p []byte) string {
+	out := make([]byte, len(p))
+	for i, b := range p {
+		if b >= 0x20 && b < 0x7f {
+			out[i] = b
+		} else {
+			out[i] = '.'
+		}
+	}
+	return string(out)
+}
+
+// tracedConn records what passes through the connection
+type tracedConn struct {
+	net.Conn
+	trace *TraceWriter
+}
+
+func (c *tracedConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.trace.record("<= Recv", p[:n])
+	return n, err
+}
+
+func (c *tracedConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.trace.record("=> Send", p[:n])
+	return n, err
+}
+
+// connectionStater is how net/http finds the TLS state of a custom TLS connection
+type connectionStater interface {
+	ConnectionState() tls.ConnectionState
+}
+
+// tracedTLSConn is a tracedConn over TLS, exposing the connection's TLS state
+type tracedTLSConn struct {
+	*tracedConn
+	stater connectionStater
+}
+
+func (c *tracedTLSConn) ConnectionState() tls.ConnectionState {
+	return c.stater.ConnectionState()
+}
@@ -0,0 +1,100 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/target"
+	"github.com/aleister1102/purl/internal/transport"
+)
+
+func TestTraceWriter_RecordsRequestAndResponse(t *testing.T) {
+	tests := []struct {
+		name  string
+		ascii bool
+		want  []string
+	}{
+		{
+			name:  "hex",
+			ascii: false,
+			// "GET " and "HTTP" as hex, with the printable column beside them
+			want: []string{"== Info: Connected to", "=> Send", "0000: 47 45 54 20", "GET /traced HTTP", "<= Recv", "0000: 48 54 54 50", "X-Traced: yes"},
+		},
+		{
+			name:  "ascii",
+			ascii: true,
+			want:  []string{"=> Send", "0000: GET /traced HTTP/1.1\n", "<= Recv", "0000: HTTP/1.1 200 OK\n", ": X-Traced: yes\n", ": traced body"},
+		},
+	}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Traced", "yes")
+		io.WriteString(w, "traced body")
+	}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	defer server.Close()
+
+	parsedTarget, err := target.ParseTarget(server.URL)
+	if err != nil {
+		t.Fatalf("ParseTarget failed: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var trace bytes.Buffer
+			cache := transport.NewCache(nil)
+			cache.WrapConns(NewTraceWriter(&trace, tt.ascii).WrapConn)
+
+			tr, err := cache.Get(&cli.Options{Insecure: true}, parsedTarget)
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			resp, err := (&http.Client{Transport: tr}).Get(server.URL + "/traced")
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			io.ReadAll(resp.Body)
+			resp.Body.Close()
+			tr.CloseIdleConnections()
+
+			if resp.TLS == nil {
+				t.Error("Expected the traced response to keep its TLS state")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(trace.String(), want) {
+					t.Errorf("Expected %q in the trace, got:\n%s", want, trace.String())
+				}
+			}
+		})
+	}
+}
+
+func TestWriteHexDump(t *testing.T) {
+	var out bytes.Buffer
+	writeHexDump(&out, []byte("0123456789abcdef\r\nxy"))
+
+	want := "0000: 30 31 32 33 34 35 36 37 38 39 61 62 63 64 65 66 0123456789abcdef\n" +
+		"0010: 0d 0a 78 79                                     ..xy\n"
+	if out.String() != want {
+		t.Errorf("writeHexDump() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestWriteASCIIDump(t *testing.T) {
+	var out bytes.Buffer
+	writeASCIIDump(&out, []byte("GET / HTTP/1.1\r\nHost: a\r\n\r\n\x01body"))
+
+	want := "0000: GET / HTTP/1.1\n" +
+		"0010: Host: a\n" +
+		"0019: \n" +
+		"001b: .body\n"
+	if out.String() != want {
+		t.Errorf("writeASCIIDump() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,87 @@
+package output
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aleister1102/purl/internal/protocol"
+)
+
+// WriteOutVars returns the values -w/--write-out variables expand to
+// size is the number of body bytes received
+func WriteOutVars(req *http.Request, result *protocol.ProbeResult, size int64, trace *RequestTrace) map[string]string {
+	vars := map[string]string{
+		"http_code":     fmt.Sprintf("%03d", result.StatusCode),
+		"remote_ip":     trace.RemoteIP(),
+		"size_download": strconv.FormatInt(size, 10),
+		"scheme":        strings.ToUpper(req.URL.Scheme),
+		"url_effective": req.URL.String(),
+
+		// Timings are cumulative from the start of the request, like curl's
+		"time_namelookup":    formatSeconds(trace.NameLookupTime()),
+		"time_connect":       formatSeconds(trace.ConnectTime()),
+		"time_appconnect":    formatSeconds(trace.AppConnectTime()),
+		"time_starttransfer": formatSeconds(trace.StartTransferTime()),
+		"time_total":         formatSeconds(trace.Elapsed()),
+	}
+
+	if resp := result.Response; resp != nil {
+		vars["content_type"] = resp.Header.Get("Content-Type")
+		if resp.Request != nil {
+			vars["url_effective"] = resp.Request.URL.String()
+		}
+	}
+	return vars
+}
+
+// ExpandWriteOut expands %{name} variables and \n, \r, \t escapes in a -w format
+// Unknown variables are left as-is, like curl; %% is a literal %
+func ExpandWriteOut(format string, vars map[string]string) string {
+	var out strings.Builder
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		switch {
+		case c == '%' && strings.HasPrefix(format[i:], "%%"):
+			out.WriteByte('%')
+			i++
+		case c == '%' && strings.HasPrefix(format[i:], "%{"):
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 {
+				out.WriteString(format[i:])
+				return out.String()
+			}
+			name := format[i+2 : i+end]
+			if value, ok := vars[name]; ok {
+				out.WriteString(value)
+			} else {
+				out.WriteString(format[i : i+end+1])
+			}
+			i += end
+		case c == '\\' && i+1 < len(format):
+			switch format[i+1] {
+			case 'n':
+				out.WriteByte('\n')
+			case 'r':
+				out.WriteByte('\r')
+			case 't':
+				out.WriteByte('\t')
+			case '\\':
+				out.WriteByte('\\')
+			default:
+				out.WriteString(format[i : i+2])
+			}
+			i++
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+// formatSeconds formats a duration as seconds with microsecond precision, like curl
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 6, 64)
+}
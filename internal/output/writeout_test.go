@@ -0,0 +1,167 @@
+package output
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aleister1102/purl/internal/protocol"
+)
+
+func TestExpandWriteOut(t *testing.T) {
+	vars := map[string]string{
+		"http_code":  "200",
+		"time_total": "0.012345",
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{"variables", "%{http_code} %{time_total}", "200 0.012345"},
+		{"newline escape", `%{http_code}\n`, "200\n"},
+		{"tab and backslash", `a\tb\\c`, "a\tb\\c"},
+		{"unknown variable left as-is", "%{nope} %{http_code}", "%{nope} 200"},
+		{"literal percent", "100%% %{http_code}", "100% 200"},
+		{"unterminated variable", "code %{http_code", "code %{http_code"},
+		{"no trailing newline added", "%{http_code}", "200"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpandWriteOut(tt.format, vars); got != tt.want {
+				t.Errorf("ExpandWriteOut(%q) = %q, want %q", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteOutVars(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte("twelve bytes"))
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"/path", nil)
+	req, trace := NewRequestTrace(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	result := &protocol.ProbeResult{Protocol: "http", StatusCode: resp.StatusCode, Response: resp}
+	vars := WriteOutVars(req, result, int64(len(body)), trace)
+
+	want := map[string]string{
+		"http_code":     "200",
+		"remote_ip":     "127.0.0.1",
+		"size_download": "12",
+		"content_type":  "text/plain; charset=utf-8",
+		"scheme":        "HTTP",
+		"url_effective": server.URL + "/path",
+	}
+	for name, value := range want {
+		if vars[name] != value {
+			t.Errorf("%s = %q, want %q", name, vars[name], value)
+		}
+	}
+	for _, name := range []string{"time_total", "time_connect"} {
+		if vars[name] == "0.000000" || !strings.Contains(vars[name], ".") {
+			t.Errorf("%s = %q, want a non-zero duration in seconds", name, vars[name])
+		}
+	}
+}
+
+func TestWriteOutVars_PhaseTimings(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	// Use a hostname so the request resolves a name as well as connecting
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	req, _ := http.NewRequest("GET", "https://localhost:"+port+"/", nil)
+	req, trace := NewRequestTrace(req)
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	result := &protocol.ProbeResult{StatusCode: resp.StatusCode, Response: resp}
+	vars := WriteOutVars(req, result, 2, trace)
+
+	phases := []string{"time_namelookup", "time_connect", "time_appconnect", "time_starttransfer", "time_total"}
+	previous := 0.0
+	for _, name := range phases {
+		seconds, err := strconv.ParseFloat(vars[name], 64)
+		if err != nil || seconds <= 0 {
+			t.Errorf("%s = %q, want a non-zero duration in seconds", name, vars[name])
+			continue
+		}
+		if seconds < previous {
+			t.Errorf("%s = %q, want it cumulative and no earlier than the phase before", name, vars[name])
+		}
+		previous = seconds
+	}
+}
+
+func TestWriteOutVars_MissingPhases(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://127.0.0.1/", nil)
+	start := time.Now()
+
+	tests := []struct {
+		name  string
+		trace *RequestTrace
+		want  map[string]string
+	}{
+		{
+			name: "plain HTTP to an IP has no name lookup or appconnect",
+			trace: &RequestTrace{
+				start:       start,
+				connectDone: start.Add(3 * time.Millisecond),
+				firstByte:   start.Add(25 * time.Millisecond),
+			},
+			want: map[string]string{
+				"time_namelookup":    "0.000000",
+				"time_connect":       "0.003000",
+				"time_appconnect":    "0.000000",
+				"time_starttransfer": "0.025000",
+			},
+		},
+		{
+			name:  "request that never started",
+			trace: &RequestTrace{},
+			want: map[string]string{
+				"time_namelookup":    "0.000000",
+				"time_starttransfer": "0.000000",
+				"time_total":         "0.000000",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars := WriteOutVars(req, &protocol.ProbeResult{}, 0, tt.trace)
+			for name, value := range tt.want {
+				if vars[name] != value {
+					t.Errorf("%s = %q, want %q", name, vars[name], value)
+				}
+			}
+		})
+	}
+}
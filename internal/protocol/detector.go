@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/aleister1102/purl/internal/cli"
@@ -14,51 +15,221 @@ import (
 
 // ProbeResult contains the result of protocol detection
 type ProbeResult struct {
-	Protocol   string // "http" or "https"
-	StatusCode int
-	Duration   time.Duration
-	Response   *http.Response
-	Error      error
+	Protocol    string // "http" or "https"
+	HTTPVersion string // negotiated HTTP version of the response, e.g. "HTTP/2.0"
+	StatusCode  int
+	Duration    time.Duration
+	Response    *http.Response
+
+	// Timing breakdown of the request, zero for phases that did not happen
+	// (e.g. TLS over plain HTTP, or DNS and connect on a reused connection)
+	DNSTime     time.Duration
+	ConnectTime time.Duration
+	TLSTime     time.Duration
+	TTFB        time.Duration // time to the first response byte
+	TotalTime   time.Duration // time until the response headers were received
+
+	// RequestTime is how long the request that was sent took to return its
+	// response headers, 0 until it has been sent
+	RequestTime time.Duration
+
+	Error error
+}
+
+// Elapsed returns the time of the request that was sent, or of the probe
+// when the run failed before sending it
+func (r *ProbeResult) Elapsed() time.Duration {
+	if r.RequestTime > 0 {
+		return r.RequestTime
+	}
+	return r.Duration
+}
+
+// defaultProbeTimeouts bound each auto-mode probe unless --probe-timeout is set
+var defaultProbeTimeouts = map[string]time.Duration{
+	"http":  3 * time.Second,
+	"https": 7 * time.Second,
 }
 
 // DetectProtocol probes the target and returns the working protocol
-// In auto mode: tries HTTP first (3s timeout), then HTTPS (7s timeout)
+// In auto mode: tries HTTP first (3s timeout), then HTTPS (7s timeout) only if HTTP fails to connect
+// --probe-order https-first swaps the order, and --probe-timeout replaces both timeouts
+// --parallel-probe runs both probes at once instead of one after the other
 // In manual mode: uses the specified protocol directly
-func DetectProtocol(parsedTarget *target.ParsedTarget, opts *cli.Options) (*ProbeResult, error) {
+// A per-target protocol (from a targets file hint) takes precedence over --proto
+// With --default-scheme, scheme-less targets in auto mode skip probing entirely
+// Probe timeouts are derived from ctx, so its deadline (e.g. --max-time) bounds detection
+func DetectProtocol(ctx context.Context, parsedTarget *target.ParsedTarget, opts *cli.Options) (*ProbeResult, error) {
+	proto := opts.Proto
+	if parsedTarget.Proto != "" {
+		proto = parsedTarget.Proto
+	}
+
+	if (proto == "" || proto == "auto") && opts.DefaultScheme != "" && !parsedTarget.HasExplicitProto {
+		return &ProbeResult{Protocol: opts.DefaultScheme}, nil
+	}
+
 	// If protocol is manually specified, use it directly
-	if opts.Proto != "" && opts.Proto != "auto" {
-		result := probeProtocol(parsedTarget, opts, opts.Proto)
+	if proto != "" && proto != "auto" {
+		result := probeProtocol(ctx, parsedTarget, opts, proto)
 		return result, result.Error
 	}
 
-	// Auto mode: try HTTP first, then HTTPS
-	// Try HTTP with 3 second timeout
-	httpResult := probeProtocolWithTimeout(parsedTarget, opts, "http", 3*time.Second)
-	if httpResult.Error == nil && httpResult.StatusCode >= 200 && httpResult.StatusCode < 400 {
-		// HTTP succeeded with success status
-		return httpResult, nil
+	// Auto mode: try HTTP first, then HTTPS (or the reverse with --probe-order https-first)
+	first, second := "http", "https"
+	if opts.ProbeOrder == "https-first" {
+		first, second = second, first
+	}
+
+	if opts.ParallelProbe {
+		return detectParallel(ctx, parsedTarget, opts, first, second), nil
 	}
 
-	// HTTP failed or returned non-success status, try HTTPS with 7 second timeout
-	httpsResult := probeProtocolWithTimeout(parsedTarget, opts, "https", 7*time.Second)
-	if httpsResult.Error == nil {
-		// HTTPS succeeded
-		return httpsResult, nil
+	firstResult := probeProtocolWithTimeout(ctx, parsedTarget, opts, first, probeTimeout(opts, first))
+	if probeSucceeded(opts, firstResult) {
+		return firstResult, nil
 	}
 
-	// Both failed, return the HTTPS result with error
-	return httpsResult, nil
+	// The first probe failed at the transport level (or was rejected by --probe-accept-status), try the other
+	closeProbe(firstResult)
+	secondResult := probeProtocolWithTimeout(ctx, parsedTarget, opts, second, probeTimeout(opts, second))
+
+	// Return the second result, with its error if both failed
+	return secondResult, nil
+}
+
+// detectParallel probes both protocols at once and returns the first that succeeds,
+// cancelling the other; first wins a tie, and second's result is returned if both fail
+func detectParallel(ctx context.Context, parsedTarget *target.ParsedTarget, opts *cli.Options, first, second string) *ProbeResult {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Buffered so the losing probe never blocks once a winner is chosen
+	results := map[string]chan *ProbeResult{
+		first:  make(chan *ProbeResult, 1),
+		second: make(chan *ProbeResult, 1),
+	}
+	for proto, ch := range results {
+		go func() {
+			ch <- probeProtocolWithTimeout(ctx, parsedTarget, opts, proto, probeTimeout(opts, proto))
+		}()
+	}
+
+	received := map[string]*ProbeResult{}
+	winner := func() *ProbeResult {
+		for len(received) < 2 {
+			select {
+			case result := <-results[first]:
+				received[first] = result
+				if probeSucceeded(opts, result) {
+					return result
+				}
+			case result := <-results[second]:
+				received[second] = result
+				if !probeSucceeded(opts, result) {
+					continue
+				}
+				// On a tie, first wins if it has finished successfully too
+				if received[first] == nil {
+					select {
+					case received[first] = <-results[first]:
+						if probeSucceeded(opts, received[first]) {
+							return received[first]
+						}
+					default:
+					}
+				}
+				return result
+			}
+		}
+		return received[second]
+	}()
+
+	// Release the loser: close its response, waiting for it if it is still running
+	for proto, ch := range results {
+		if result, ok := received[proto]; !ok {
+			go func() { closeProbe(<-ch) }()
+		} else if result != winner {
+			closeProbe(result)
+		}
+	}
+	return winner
+}
+
+// closeProbe closes a probe's response body, if any
+func closeProbe(result *ProbeResult) {
+	if result != nil && result.Response != nil {
+		result.Response.Body.Close()
+	}
+}
+
+// probeSucceeded reports whether an auto-mode probe found a working protocol
+// The server answered, so the protocol works whatever the status, unless
+// --probe-accept-status rejects an HTTP probe's status
+func probeSucceeded(opts *cli.Options, result *ProbeResult) bool {
+	return result.Error == nil && (result.Protocol != "http" || acceptsProbeStatus(opts, result.StatusCode))
+}
+
+// probeTimeout returns the timeout for an auto-mode probe of proto
+func probeTimeout(opts *cli.Options, proto string) time.Duration {
+	if opts.ProbeTimeout > 0 {
+		return opts.ProbeTimeout
+	}
+	return defaultProbeTimeouts[proto]
+}
+
+// acceptsProbeStatus reports whether an HTTP probe status counts as success
+// Any status is accepted unless --probe-accept-status narrows it; a 4xx/5xx still means HTTP works
+func acceptsProbeStatus(opts *cli.Options, statusCode int) bool {
+	if len(opts.ProbeAcceptStatus) > 0 {
+		return opts.ProbeAcceptStatus.Matches(statusCode)
+	}
+	return true
 }
 
 // probeProtocol attempts to connect using the specified protocol
 // Uses the default timeout from opts
-func probeProtocol(parsedTarget *target.ParsedTarget, opts *cli.Options, proto string) *ProbeResult {
+func probeProtocol(ctx context.Context, parsedTarget *target.ParsedTarget, opts *cli.Options, proto string) *ProbeResult {
 	timeout := transport.ApplyTimeouts(opts)
-	return probeProtocolWithTimeout(parsedTarget, opts, proto, timeout)
+	return probeProtocolWithTimeout(ctx, parsedTarget, opts, proto, timeout)
 }
 
 // probeProtocolWithTimeout attempts to connect using the specified protocol and timeout
-func probeProtocolWithTimeout(parsedTarget *target.ParsedTarget, opts *cli.Options, proto string, timeout time.Duration) *ProbeResult {
+// Transient failures (errors and 5xx) are retried up to opts.ProbeRetries times
+func probeProtocolWithTimeout(ctx context.Context, parsedTarget *target.ParsedTarget, opts *cli.Options, proto string, timeout time.Duration) *ProbeResult {
+	result := probeOnce(ctx, parsedTarget, opts, proto, timeout)
+	for attempt := 0; attempt < opts.ProbeRetries && isTransientProbeFailure(result) && ctx.Err() == nil; attempt++ {
+		if result.Response != nil {
+			result.Response.Body.Close()
+		}
+		result = probeOnce(ctx, parsedTarget, opts, proto, timeout)
+	}
+	return result
+}
+
+// isTransientProbeFailure reports whether a probe result is worth retrying
+func isTransientProbeFailure(result *ProbeResult) bool {
+	return result.Error != nil || result.StatusCode >= 500
+}
+
+// probeOnce sends a single probe using the specified protocol and timeout
+// The probe uses --probe-method (HEAD by default); a HEAD answered with 405 is repeated with GET
+func probeOnce(ctx context.Context, parsedTarget *target.ParsedTarget, opts *cli.Options, proto string, timeout time.Duration) *ProbeResult {
+	method := opts.ProbeMethod
+	if method == "" {
+		method = http.MethodHead
+	}
+
+	result := sendProbe(ctx, parsedTarget, opts, proto, method, timeout)
+	if method == http.MethodHead && result.StatusCode == http.StatusMethodNotAllowed && ctx.Err() == nil {
+		result.Response.Body.Close()
+		result = sendProbe(ctx, parsedTarget, opts, proto, http.MethodGet, timeout)
+	}
+	return result
+}
+
+// sendProbe sends a probe request with the given method, protocol and timeout
+func sendProbe(parent context.Context, parsedTarget *target.ParsedTarget, opts *cli.Options, proto, method string, timeout time.Duration) *ProbeResult {
 	result := &ProbeResult{
 		Protocol: proto,
 	}
@@ -68,8 +239,8 @@ func probeProtocolWithTimeout(parsedTarget *target.ParsedTarget, opts *cli.Optio
 	probeOpts.Timeout = timeout
 	probeOpts.ConnectTimeout = timeout
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	// Create context with timeout, bounded by the parent's deadline
+	ctx, cancel := context.WithTimeout(parent, timeout)
 	defer cancel()
 
 	// Create transport for this protocol
@@ -80,20 +251,25 @@ func probeProtocolWithTimeout(parsedTarget *target.ParsedTarget, opts *cli.Optio
 	}
 
 	// Create HTTP client with the transport
+	// Redirects are not followed so the probe sees the status of the target itself
 	client := &http.Client{
 		Transport: tr,
 		Timeout:   timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
 	}
 
 	// Construct the URL with the specified protocol
-	probeURL := constructURL(parsedTarget, proto)
+	probeURL := constructURL(parsedTarget, proto, opts.ProbePath)
 
-	// Create a HEAD request (lightweight probe)
-	req, err := http.NewRequestWithContext(ctx, "HEAD", probeURL, nil)
+	// Create the probe request (HEAD unless --probe-method says otherwise, to stay lightweight)
+	req, err := http.NewRequestWithContext(ctx, method, probeURL, nil)
 	if err != nil {
 		result.Error = fmt.Errorf("failed to create request: %w", err)
 		return result
 	}
+	req = TraceTimings(req, result)
 
 	// Record start time
 	startTime := time.Now()
@@ -102,6 +278,7 @@ func probeProtocolWithTimeout(parsedTarget *target.ParsedTarget, opts *cli.Optio
 	resp, err := client.Do(req)
 	duration := time.Since(startTime)
 	result.Duration = duration
+	result.TotalTime = duration
 
 	if err != nil {
 		result.Error = mapProbeError(err, parsedTarget)
@@ -111,15 +288,22 @@ func probeProtocolWithTimeout(parsedTarget *target.ParsedTarget, opts *cli.Optio
 	// Store response and status code
 	result.Response = resp
 	result.StatusCode = resp.StatusCode
+	result.HTTPVersion = resp.Proto
 
 	return result
 }
 
 // constructURL constructs a URL with the specified protocol
-func constructURL(parsedTarget *target.ParsedTarget, proto string) string {
+// A non-empty probePath (--probe-path) replaces the target's path and query
+func constructURL(parsedTarget *target.ParsedTarget, proto, probePath string) string {
 	// Create a new URL with the specified scheme
 	probeURL := *parsedTarget.URL
 	probeURL.Scheme = proto
+	if probePath != "" {
+		probeURL.Path = probePath
+		probeURL.RawPath = ""
+		probeURL.RawQuery = ""
+	}
 	return probeURL.String()
 }
 
@@ -176,5 +360,5 @@ func mapProbeError(err error, parsedTarget *target.ParsedTarget) error {
 
 // contains checks if a string contains a substring (case-insensitive)
 func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(substr) == 0 || (len(s) > 0 && len(substr) > 0))
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
 }
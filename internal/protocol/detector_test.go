@@ -1,12 +1,17 @@
 package protocol
 
 import (
+	"bufio"
+	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
+	"log"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,6 +19,7 @@ import (
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
 	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
 	"github.com/aleister1102/purl/internal/target"
 )
 
@@ -65,7 +71,7 @@ func TestProtocolOverrideBehavior(t *testing.T) {
 				}
 
 				// Call DetectProtocol
-				result, _ := DetectProtocol(parsedTarget, opts)
+				result, _ := DetectProtocol(context.Background(), parsedTarget, opts)
 
 				// Verify the protocol matches what was specified
 				if result == nil {
@@ -128,7 +134,7 @@ func TestAutoModeTriesHTTPFirst(t *testing.T) {
 		ConnectTimeout: 5 * time.Second,
 	}
 
-	result, _ := DetectProtocol(parsedTarget, opts)
+	result, _ := DetectProtocol(context.Background(), parsedTarget, opts)
 
 	if result.Protocol != "http" {
 		t.Errorf("Expected protocol 'http', got '%s'", result.Protocol)
@@ -169,7 +175,7 @@ func TestManualProtocolNoFallback(t *testing.T) {
 		ConnectTimeout: 2 * time.Second,
 	}
 
-	result, _ := DetectProtocol(parsedTarget, opts)
+	result, _ := DetectProtocol(context.Background(), parsedTarget, opts)
 
 	// Should attempt HTTPS and fail, not fall back to HTTP
 	if result.Protocol != "https" {
@@ -210,7 +216,7 @@ func TestProbeResultStructure(t *testing.T) {
 		ConnectTimeout: 5 * time.Second,
 	}
 
-	result, err := DetectProtocol(parsedTarget, opts)
+	result, err := DetectProtocol(context.Background(), parsedTarget, opts)
 
 	if err != nil {
 		t.Fatalf("DetectProtocol failed: %v", err)
@@ -264,7 +270,7 @@ func TestDurationMeasurement(t *testing.T) {
 		ConnectTimeout: 5 * time.Second,
 	}
 
-	result, err := DetectProtocol(parsedTarget, opts)
+	result, err := DetectProtocol(context.Background(), parsedTarget, opts)
 
 	if err != nil {
 		t.Fatalf("DetectProtocol failed: %v", err)
@@ -305,7 +311,7 @@ func TestTimeoutBehavior(t *testing.T) {
 	}
 
 	startTime := time.Now()
-	result, _ := DetectProtocol(parsedTarget, opts)
+	result, _ := DetectProtocol(context.Background(), parsedTarget, opts)
 	elapsed := time.Since(startTime)
 
 	// Should timeout around 500ms
@@ -350,7 +356,7 @@ func TestStatusCodeCapture(t *testing.T) {
 				ConnectTimeout: 5 * time.Second,
 			}
 
-			result, err := DetectProtocol(parsedTarget, opts)
+			result, err := DetectProtocol(context.Background(), parsedTarget, opts)
 
 			if err != nil {
 				t.Fatalf("DetectProtocol failed: %v", err)
@@ -362,3 +368,758 @@ func TestStatusCodeCapture(t *testing.T) {
 		})
 	}
 }
+
+// sniffListener serves HTTP and HTTPS on the same port by peeking at the first byte
+type sniffListener struct {
+	net.Listener
+	tlsConfig *tls.Config
+}
+
+func (l *sniffListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	first, err := br.Peek(1)
+	if err != nil {
+		conn.Close()
+		return l.Accept()
+	}
+	peeked := &peekedConn{Conn: conn, reader: br}
+	// 0x16 is the TLS handshake record type
+	if first[0] == 0x16 {
+		return tls.Server(peeked, l.tlsConfig), nil
+	}
+	return peeked, nil
+}
+
+type peekedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *peekedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// newDualProtocolServer starts a server answering both HTTP and HTTPS on one port
+// Returns the host:port address of the server
+func newDualProtocolServer(t *testing.T, handler http.Handler) string {
+	t.Helper()
+
+	tlsServer := httptest.NewTLSServer(handler)
+	tlsConfig := &tls.Config{Certificates: tlsServer.TLS.Certificates}
+	tlsServer.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create listener: %v", err)
+	}
+
+	server := &http.Server{Handler: handler}
+	go server.Serve(&sniffListener{Listener: listener, tlsConfig: tlsConfig})
+	t.Cleanup(func() { server.Close() })
+
+	return listener.Addr().String()
+}
+
+// Test that a strict probe-accept-status makes a 3xx HTTP response fall back to HTTPS
+func TestProbeAcceptStatusStrict(t *testing.T) {
+	addr := newDualProtocolServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil {
+			http.Redirect(w, r, "https://"+r.Host+r.URL.Path, http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	parsedTarget := &target.ParsedTarget{
+		URL: &url.URL{
+			Scheme: "http",
+			Host:   addr,
+			Path:   "/",
+		},
+		IsIP:             true,
+		HasExplicitProto: false,
+	}
+
+	spec, err := cli.ParseStatusSpec("2xx")
+	if err != nil {
+		t.Fatalf("ParseStatusSpec failed: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		acceptStatus  cli.StatusSpec
+		expectedProto string
+		expectedCode  int
+	}{
+		{
+			name:          "default accepts 3xx over HTTP",
+			acceptStatus:  nil,
+			expectedProto: "http",
+			expectedCode:  http.StatusFound,
+		},
+		{
+			name:          "strict 2xx falls back to HTTPS",
+			acceptStatus:  spec,
+			expectedProto: "https",
+			expectedCode:  http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &cli.Options{
+				Proto:             "auto",
+				ProbeAcceptStatus: tt.acceptStatus,
+				Timeout:           5 * time.Second,
+				ConnectTimeout:    5 * time.Second,
+			}
+
+			result, _ := DetectProtocol(context.Background(), parsedTarget, opts)
+
+			if result.Protocol != tt.expectedProto {
+				t.Errorf("Expected protocol '%s', got '%s'", tt.expectedProto, result.Protocol)
+			}
+			if result.StatusCode != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, result.StatusCode)
+			}
+		})
+	}
+}
+
+// Test that targets from a file use their own protocol over the global --proto
+func TestPerTargetProtocolOverride(t *testing.T) {
+	addr := newDualProtocolServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	content := fmt.Sprintf("%[1]s\n%[1]s|proto=https\nhttps://%[1]s/\nhttp://%[1]s/\n", addr)
+	targets, err := target.ReadTargets(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ReadTargets failed: %v", err)
+	}
+
+	expected := []string{"http", "https", "https", "http"}
+	if len(targets) != len(expected) {
+		t.Fatalf("expected %d targets, got %d", len(expected), len(targets))
+	}
+
+	opts := &cli.Options{
+		Proto:          "auto",
+		Timeout:        5 * time.Second,
+		ConnectTimeout: 5 * time.Second,
+	}
+
+	for i, parsedTarget := range targets {
+		result, err := DetectProtocol(context.Background(), parsedTarget, opts)
+		if err != nil {
+			t.Fatalf("target %d: DetectProtocol failed: %v", i, err)
+		}
+		if result.Protocol != expected[i] {
+			t.Errorf("target %d (%s): expected protocol '%s', got '%s'",
+				i, parsedTarget.OriginalInput, expected[i], result.Protocol)
+		}
+	}
+}
+
+// Test that --probe-retries keeps a flaky HTTP target from falling back to HTTPS
+func TestProbeRetriesAvoidFallback(t *testing.T) {
+	tests := []struct {
+		name          string
+		probeRetries  int
+		expectedProto string
+	}{
+		{
+			name:          "without retries the transient failure falls back",
+			probeRetries:  0,
+			expectedProto: "https",
+		},
+		{
+			name:          "one retry selects HTTP",
+			probeRetries:  1,
+			expectedProto: "http",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				if attempts == 1 {
+					// Drop the connection to simulate a lossy network
+					conn, _, _ := w.(http.Hijacker).Hijack()
+					conn.Close()
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			parsedTarget, err := target.ParseTarget(strings.TrimPrefix(server.URL, "http://"))
+			if err != nil {
+				t.Fatalf("ParseTarget failed: %v", err)
+			}
+
+			opts := &cli.Options{
+				Proto:          "auto",
+				ProbeRetries:   tt.probeRetries,
+				Timeout:        5 * time.Second,
+				ConnectTimeout: 5 * time.Second,
+			}
+
+			result, _ := DetectProtocol(context.Background(), parsedTarget, opts)
+			if result.Protocol != tt.expectedProto {
+				t.Errorf("Expected protocol '%s', got '%s' (error: %v)", tt.expectedProto, result.Protocol, result.Error)
+			}
+		})
+	}
+}
+
+// Test that --default-scheme skips probing for scheme-less targets only
+func TestDefaultSchemeSkipsProbe(t *testing.T) {
+	var probes int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	tests := []struct {
+		name           string
+		input          string
+		expectedProto  string
+		expectedProbes int
+	}{
+		{
+			name:           "scheme-less target uses https without probing",
+			input:          addr,
+			expectedProto:  "https",
+			expectedProbes: 0,
+		},
+		{
+			name:           "explicit scheme still probes",
+			input:          server.URL,
+			expectedProto:  "http",
+			expectedProbes: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probes = 0
+			parsedTarget, err := target.ParseTargetWithScheme(tt.input, "https")
+			if err != nil {
+				t.Fatalf("ParseTargetWithScheme failed: %v", err)
+			}
+
+			opts := &cli.Options{
+				Proto:          "auto",
+				DefaultScheme:  "https",
+				Timeout:        5 * time.Second,
+				ConnectTimeout: 5 * time.Second,
+			}
+
+			result, err := DetectProtocol(context.Background(), parsedTarget, opts)
+			if err != nil {
+				t.Fatalf("DetectProtocol failed: %v", err)
+			}
+			if result.Protocol != tt.expectedProto {
+				t.Errorf("Expected protocol '%s', got '%s'", tt.expectedProto, result.Protocol)
+			}
+			if probes != tt.expectedProbes {
+				t.Errorf("Expected %d probes, got %d", tt.expectedProbes, probes)
+			}
+		})
+	}
+}
+
+// Test that a parent deadline bounds detection instead of the fixed 3s+7s probe timeouts
+func TestDetectProtocolRespectsParentDeadline(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	parsedTarget, err := target.ParseTarget(strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatalf("ParseTarget failed: %v", err)
+	}
+
+	opts := &cli.Options{
+		Proto:          "auto",
+		Timeout:        10 * time.Second,
+		ConnectTimeout: 10 * time.Second,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result, _ := DetectProtocol(ctx, parsedTarget, opts)
+	elapsed := time.Since(start)
+
+	if elapsed > 2*time.Second {
+		t.Errorf("Detection took %v, expected the 200ms parent deadline to bound it", elapsed)
+	}
+	if result.Error == nil {
+		t.Error("Expected a probe error once the parent deadline passed")
+	}
+}
+
+// Test that probe failures map to the error types behind their exit codes
+func TestProbeErrorClassification(t *testing.T) {
+	// A listener that is closed straight away gives a refused port
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	refusedAddr := listener.Addr().String()
+	listener.Close()
+
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer tlsServer.Close()
+	// IP targets skip verification, so reach the TLS server by hostname
+	tlsAddr := fmt.Sprintf("localhost:%d", tlsServer.Listener.Addr().(*net.TCPAddr).Port)
+
+	tests := []struct {
+		name     string
+		target   string
+		proto    string
+		wantCode int
+	}{
+		{"refused connection", refusedAddr, "http", errors.ExitConnectFailed},
+		{"untrusted certificate", tlsAddr, "https", errors.ExitTLSError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsedTarget, err := target.ParseTarget(tt.target)
+			if err != nil {
+				t.Fatalf("ParseTarget failed: %v", err)
+			}
+
+			opts := &cli.Options{
+				Proto:          tt.proto,
+				Timeout:        5 * time.Second,
+				ConnectTimeout: 5 * time.Second,
+			}
+
+			_, err = DetectProtocol(context.Background(), parsedTarget, opts)
+			if err == nil {
+				t.Fatal("Expected a probe error")
+			}
+			if code := errors.MapErrorToExitCode(err); code != tt.wantCode {
+				t.Errorf("Expected exit code %d, got %d (%v)", tt.wantCode, code, err)
+			}
+		})
+	}
+
+	// DNS failures are built directly so the test does not depend on a resolver
+	parsedTarget, err := target.ParseTarget("missing.invalid:8080")
+	if err != nil {
+		t.Fatalf("ParseTarget failed: %v", err)
+	}
+	dnsErr := &url.Error{
+		Op:  "Head",
+		URL: "http://missing.invalid:8080",
+		Err: &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "missing.invalid", IsNotFound: true}},
+	}
+	mapped := mapProbeError(dnsErr, parsedTarget)
+	if _, ok := mapped.(*errors.NoRouteError); !ok {
+		t.Errorf("Expected NoRouteError for a DNS failure, got %T", mapped)
+	}
+	if code := errors.MapErrorToExitCode(mapped); code != errors.ExitNoRoute {
+		t.Errorf("Expected exit code %d, got %d", errors.ExitNoRoute, code)
+	}
+}
+
+// Test that contains matches substrings case-insensitively
+func TestContains(t *testing.T) {
+	tests := []struct {
+		s      string
+		substr string
+		want   bool
+	}{
+		{"dial tcp: connection refused", "connection refused", true},
+		{"x509: Certificate signed by unknown authority", "certificate", true},
+		{"remote error: TLS handshake failure", "tls", true},
+		{"dial tcp: connection refused", "no such host", false},
+		{"short", "much longer substring", false},
+		{"anything", "", true},
+	}
+
+	for _, tt := range tests {
+		if got := contains(tt.s, tt.substr); got != tt.want {
+			t.Errorf("contains(%q, %q) = %v, want %v", tt.s, tt.substr, got, tt.want)
+		}
+	}
+}
+
+// Test that an HTTP server answering 4xx/5xx is detected as HTTP without an HTTPS probe
+func TestAutoModeAcceptsErrorStatus(t *testing.T) {
+	for _, statusCode := range []int{http.StatusNotFound, http.StatusInternalServerError} {
+		t.Run(fmt.Sprintf("StatusCode_%d", statusCode), func(t *testing.T) {
+			var httpsProbes int
+			addr := newDualProtocolServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.TLS != nil {
+					httpsProbes++
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				w.WriteHeader(statusCode)
+			}))
+
+			parsedTarget, err := target.ParseTarget(addr)
+			if err != nil {
+				t.Fatalf("ParseTarget failed: %v", err)
+			}
+
+			opts := &cli.Options{
+				Proto:          "auto",
+				Timeout:        5 * time.Second,
+				ConnectTimeout: 5 * time.Second,
+			}
+
+			result, err := DetectProtocol(context.Background(), parsedTarget, opts)
+			if err != nil {
+				t.Fatalf("DetectProtocol failed: %v", err)
+			}
+			if result.Protocol != "http" {
+				t.Errorf("Expected protocol 'http', got '%s'", result.Protocol)
+			}
+			if result.StatusCode != statusCode {
+				t.Errorf("Expected status %d, got %d", statusCode, result.StatusCode)
+			}
+			if httpsProbes != 0 {
+				t.Errorf("Expected no HTTPS probe, got %d", httpsProbes)
+			}
+		})
+	}
+}
+
+// Test that --probe-path sends the probe to the override path without touching the target
+func TestProbePathOverride(t *testing.T) {
+	var probedPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probedPath = r.URL.RequestURI()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	parsedTarget, err := target.ParseTarget(strings.TrimPrefix(server.URL, "http://") + "/slow/report?full=1")
+	if err != nil {
+		t.Fatalf("ParseTarget failed: %v", err)
+	}
+
+	opts := &cli.Options{
+		Proto:          "auto",
+		ProbePath:      "/healthz",
+		Timeout:        5 * time.Second,
+		ConnectTimeout: 5 * time.Second,
+	}
+
+	result, err := DetectProtocol(context.Background(), parsedTarget, opts)
+	if err != nil {
+		t.Fatalf("DetectProtocol failed: %v", err)
+	}
+	if result.Protocol != "http" {
+		t.Errorf("Expected protocol 'http', got '%s'", result.Protocol)
+	}
+	if probedPath != "/healthz" {
+		t.Errorf("Expected the probe to request /healthz, got %q", probedPath)
+	}
+	if got := parsedTarget.URL.RequestURI(); got != "/slow/report?full=1" {
+		t.Errorf("Target path changed to %q", got)
+	}
+}
+
+// Test that the probe records each phase of the request and that the phases are ordered
+func TestProbeTimingBreakdown(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	parsedTarget, err := target.ParseTarget("localhost:" + u.Port())
+	if err != nil {
+		t.Fatalf("ParseTarget failed: %v", err)
+	}
+
+	opts := &cli.Options{
+		Proto:          "https",
+		Insecure:       true,
+		Timeout:        5 * time.Second,
+		ConnectTimeout: 5 * time.Second,
+	}
+
+	result, err := DetectProtocol(context.Background(), parsedTarget, opts)
+	if err != nil {
+		t.Fatalf("DetectProtocol failed: %v", err)
+	}
+
+	phases := map[string]time.Duration{
+		"DNSTime":     result.DNSTime,
+		"ConnectTime": result.ConnectTime,
+		"TLSTime":     result.TLSTime,
+		"TTFB":        result.TTFB,
+		"TotalTime":   result.TotalTime,
+	}
+	for name, d := range phases {
+		if d <= 0 {
+			t.Errorf("Expected %s to be populated, got %v", name, d)
+		}
+	}
+
+	if setup := result.DNSTime + result.ConnectTime + result.TLSTime; setup > result.TTFB {
+		t.Errorf("Expected DNS+connect+TLS (%v) <= TTFB (%v)", setup, result.TTFB)
+	}
+	if result.TTFB < 20*time.Millisecond {
+		t.Errorf("Expected TTFB to include the server delay, got %v", result.TTFB)
+	}
+	if result.TTFB > result.TotalTime {
+		t.Errorf("Expected TTFB (%v) <= TotalTime (%v)", result.TTFB, result.TotalTime)
+	}
+}
+
+// Test that --probe-order decides which protocol is attempted first
+func TestProbeOrder(t *testing.T) {
+	// A TLS server answers plain HTTP with a 400, so whichever protocol is probed first wins
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	parsedTarget, err := target.ParseTarget("localhost:" + u.Port())
+	if err != nil {
+		t.Fatalf("ParseTarget failed: %v", err)
+	}
+
+	tests := []struct {
+		order     string
+		wantProto string
+	}{
+		{"", "http"},
+		{"http-first", "http"},
+		{"https-first", "https"},
+	}
+
+	for _, tt := range tests {
+		opts := &cli.Options{
+			Proto:      "auto",
+			ProbeOrder: tt.order,
+			Insecure:   true,
+			Timeout:    5 * time.Second,
+		}
+
+		result, _ := DetectProtocol(context.Background(), parsedTarget, opts)
+		if result.Error != nil {
+			t.Fatalf("order %q: unexpected error: %v", tt.order, result.Error)
+		}
+		if result.Protocol != tt.wantProto {
+			t.Errorf("order %q: expected protocol %q, got %q", tt.order, tt.wantProto, result.Protocol)
+		}
+	}
+}
+
+// Test that --probe-timeout bounds each auto-mode probe instead of 3s/7s
+func TestProbeTimeout(t *testing.T) {
+	// A listener that accepts connections but never answers
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create listener: %v", err)
+	}
+	defer listener.Close()
+
+	parsedTarget, err := target.ParseTarget(listener.Addr().String())
+	if err != nil {
+		t.Fatalf("ParseTarget failed: %v", err)
+	}
+
+	opts := &cli.Options{
+		Proto:        "auto",
+		ProbeTimeout: 200 * time.Millisecond,
+		Timeout:      10 * time.Second,
+	}
+
+	start := time.Now()
+	result, _ := DetectProtocol(context.Background(), parsedTarget, opts)
+	elapsed := time.Since(start)
+
+	if result.Error == nil {
+		t.Fatal("Expected both probes to time out")
+	}
+	// Two 200ms probes, far below the 3s+7s defaults
+	if elapsed < 350*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("Expected about 400ms for two probes, took %v", elapsed)
+	}
+}
+
+// Test that a HEAD probe rejected with 405 is retried as GET
+func TestProbeFallsBackToGETOn405(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	parsedTarget, err := target.ParseTarget("localhost:" + u.Port())
+	if err != nil {
+		t.Fatalf("ParseTarget failed: %v", err)
+	}
+
+	accept, _ := cli.ParseStatusSpec("2xx")
+	opts := &cli.Options{
+		Proto:             "auto",
+		ProbeAcceptStatus: accept,
+		Timeout:           5 * time.Second,
+	}
+
+	result, _ := DetectProtocol(context.Background(), parsedTarget, opts)
+	if result.Error != nil {
+		t.Fatalf("Expected detection to succeed, got %v", result.Error)
+	}
+	if result.Protocol != "http" || result.StatusCode != http.StatusOK {
+		t.Errorf("Expected http with status 200, got %s %d", result.Protocol, result.StatusCode)
+	}
+	if strings.Join(methods, ",") != "HEAD,GET" {
+		t.Errorf("Expected HEAD then GET, got %v", methods)
+	}
+
+	// --probe-method GET skips the HEAD attempt
+	methods = nil
+	opts.ProbeMethod = http.MethodGet
+	if result, _ := DetectProtocol(context.Background(), parsedTarget, opts); result.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", result.StatusCode)
+	}
+	if strings.Join(methods, ",") != "GET" {
+		t.Errorf("Expected a single GET, got %v", methods)
+	}
+}
+
+// slowPlaintextListener records accepted connections and delays plaintext (non-TLS)
+// connections so an HTTP probe loses to an HTTPS probe on the same port
+type slowPlaintextListener struct {
+	net.Listener
+	delay    time.Duration
+	accepted chan time.Time
+}
+
+func (l *slowPlaintextListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	l.accepted <- time.Now()
+	return &slowPlaintextConn{Conn: conn, delay: l.delay}, nil
+}
+
+type slowPlaintextConn struct {
+	net.Conn
+	delay   time.Duration
+	checked bool
+}
+
+func (c *slowPlaintextConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if !c.checked && n > 0 {
+		c.checked = true
+		if p[0] != 0x16 { // not a TLS handshake record
+			time.Sleep(c.delay)
+		}
+	}
+	return n, err
+}
+
+// Test that --parallel-probe attempts both protocols at once and returns the faster one
+func TestParallelProbe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create listener: %v", err)
+	}
+	slow := &slowPlaintextListener{Listener: listener, delay: 500 * time.Millisecond, accepted: make(chan time.Time, 16)}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Listener = slow
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	parsedTarget, err := target.ParseTarget(listener.Addr().String())
+	if err != nil {
+		t.Fatalf("ParseTarget failed: %v", err)
+	}
+
+	opts := &cli.Options{
+		Proto:         "auto",
+		ParallelProbe: true,
+		Insecure:      true,
+		Timeout:       5 * time.Second,
+	}
+
+	start := time.Now()
+	result, _ := DetectProtocol(context.Background(), parsedTarget, opts)
+	elapsed := time.Since(start)
+
+	if result.Error != nil {
+		t.Fatalf("Expected detection to succeed, got %v", result.Error)
+	}
+	if result.Protocol != "https" {
+		t.Errorf("Expected the faster https probe to win, got %s", result.Protocol)
+	}
+	if elapsed >= 500*time.Millisecond {
+		t.Errorf("Expected the result before the slow HTTP probe finished, took %v", elapsed)
+	}
+
+	// Both probes connected, close together in time
+	var accepts []time.Time
+	for len(accepts) < 2 {
+		select {
+		case at := <-slow.accepted:
+			accepts = append(accepts, at)
+		case <-time.After(time.Second):
+			t.Fatalf("Expected both protocols to be attempted, saw %d connection(s)", len(accepts))
+		}
+	}
+	if gap := accepts[1].Sub(accepts[0]); gap > 250*time.Millisecond {
+		t.Errorf("Expected concurrent probes, connections were %v apart", gap)
+	}
+}
+
+// Test that a failed parallel probe does not beat a working one
+func TestParallelProbeSkipsFailedProtocol(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	parsedTarget, err := target.ParseTarget("localhost:" + u.Port())
+	if err != nil {
+		t.Fatalf("ParseTarget failed: %v", err)
+	}
+
+	opts := &cli.Options{Proto: "auto", ParallelProbe: true, Timeout: 5 * time.Second}
+	result, _ := DetectProtocol(context.Background(), parsedTarget, opts)
+	if result.Error != nil || result.Protocol != "http" {
+		t.Errorf("Expected http, got %q (error: %v)", result.Protocol, result.Error)
+	}
+}
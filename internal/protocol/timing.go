@@ -0,0 +1,80 @@
+package protocol
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// timingTrace collects the phases of one request through httptrace hooks
+// Hooks can fire from dialing goroutines, so fields are guarded by mu
+type timingTrace struct {
+	mu       sync.Mutex
+	start    time.Time
+	dnsStart time.Time
+	dns      time.Duration
+	conStart time.Time
+	connect  time.Duration
+	tlsStart time.Time
+	tls      time.Duration
+	ttfb     time.Duration
+}
+
+// TraceTimings returns req with hooks that record the DNS, connect, TLS and
+// time-to-first-byte phases into result once the response headers arrive
+// TotalTime is set by the caller, which knows when the request is complete
+func TraceTimings(req *http.Request, result *ProbeResult) *http.Request {
+	t := &timingTrace{}
+	trace := &httptrace.ClientTrace{
+		GetConn: func(string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if t.start.IsZero() {
+				t.start = time.Now()
+			}
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dns = time.Since(t.dnsStart)
+		},
+		ConnectStart: func(_, _ string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if t.conStart.IsZero() {
+				t.conStart = time.Now()
+			}
+		},
+		ConnectDone: func(_, _ string, err error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if err == nil && t.connect == 0 {
+				t.connect = time.Since(t.conStart)
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tls = time.Since(t.tlsStart)
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.ttfb = time.Since(t.start)
+			result.DNSTime, result.ConnectTime, result.TLSTime, result.TTFB = t.dns, t.connect, t.tls, t.ttfb
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
@@ -0,0 +1,20 @@
+package request
+
+import (
+	"net/http"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+// CheckAbortHeader closes the body unread and returns an AbortedError
+// when the response carries a header matching --abort-on-header
+func CheckAbortHeader(resp *http.Response, opts *cli.Options) error {
+	for _, match := range opts.AbortOnHeader {
+		if match.Matches(resp.Header) {
+			resp.Body.Close()
+			return &errors.AbortedError{Name: match.Name, Value: resp.Header.Get(match.Name)}
+		}
+	}
+	return nil
+}
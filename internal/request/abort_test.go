@@ -0,0 +1,77 @@
+package request
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+// trackingBody records whether the response body was read or closed
+type trackingBody struct {
+	io.Reader
+	read   bool
+	closed bool
+}
+
+func (b *trackingBody) Read(p []byte) (int, error) {
+	b.read = true
+	return b.Reader.Read(p)
+}
+
+func (b *trackingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestCheckAbortHeader(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    http.Header
+		wantAbort bool
+	}{
+		{
+			name:      "matching header aborts",
+			header:    http.Header{"X-Trigger": []string{"yes"}},
+			wantAbort: true,
+		},
+		{
+			name:      "different value continues",
+			header:    http.Header{"X-Trigger": []string{"no"}},
+			wantAbort: false,
+		},
+		{
+			name:      "missing header continues",
+			header:    http.Header{},
+			wantAbort: false,
+		},
+	}
+
+	opts := &cli.Options{AbortOnHeader: []cli.HeaderMatch{{Name: "X-Trigger", Value: "yes"}}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := &trackingBody{Reader: strings.NewReader("large body")}
+			resp := &http.Response{StatusCode: http.StatusOK, Header: tt.header, Body: body}
+
+			err := CheckAbortHeader(resp, opts)
+			if (err != nil) != tt.wantAbort {
+				t.Fatalf("CheckAbortHeader() error = %v, wantAbort %v", err, tt.wantAbort)
+			}
+			if body.read {
+				t.Error("Body was read")
+			}
+			if tt.wantAbort {
+				if !body.closed {
+					t.Error("Body was not closed")
+				}
+				if code := errors.MapErrorToExitCode(err); code != errors.ExitAborted {
+					t.Errorf("Expected exit code %d, got %d", errors.ExitAborted, code)
+				}
+			}
+		})
+	}
+}
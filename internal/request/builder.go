@@ -1,25 +1,36 @@
 package request
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
 	"strings"
+	"sync"
 
 	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
 	"github.com/aleister1102/purl/internal/target"
 )
 
+// AcceptEncoding is sent with --compressed: the encodings the output can decode
+const AcceptEncoding = "gzip, deflate, br"
+
 // BuildRequest creates an http.Request from CLI options and parsed target
 // Handles method override, headers, body, authentication, and special flags
 func BuildRequest(ctx context.Context, parsedTarget *target.ParsedTarget, opts *cli.Options) (*http.Request, error) {
 	// Determine the HTTP method
 	method := opts.Method
 	if method == "" {
-		// Default to GET, unless data is provided
-		if opts.Data != "" || opts.DataRaw != "" {
+		// Default to GET, unless data is provided without -G
+		hasData := opts.Data != "" || opts.DataRaw != "" || len(opts.DataURLEncode) > 0
+		if hasData && !opts.Get {
 			method = "POST"
 		} else if opts.Head {
 			method = "HEAD"
@@ -33,17 +44,80 @@ func BuildRequest(ctx context.Context, parsedTarget *target.ParsedTarget, opts *
 	if opts.DataRaw != "" {
 		// --data-raw: preserve special characters literally
 		body = strings.NewReader(opts.DataRaw)
+	} else if strings.HasPrefix(opts.Data, "@") {
+		// -d @file / -d @-: read the body from a file or stdin like curl
+		data, err := readDataFile(strings.TrimPrefix(opts.Data, "@"))
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(data)
 	} else if opts.Data != "" {
-		// -d/--data: use as-is (no special interpretation in this implementation)
+		// -d/--data: use as-is
 		body = strings.NewReader(opts.Data)
 	}
 
+	// --data-urlencode fields follow the -d data, joined with &
+	if len(opts.DataURLEncode) > 0 {
+		var data string
+		if body != nil {
+			raw, err := io.ReadAll(body)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read request body: %w", err)
+			}
+			data = string(raw)
+		}
+		body = strings.NewReader(joinData(data, encodeData(opts.DataURLEncode)))
+	}
+
+	// -G moves the data into the query string and sends no body
+	var getQuery string
+	if body != nil && opts.Get {
+		raw, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		getQuery = string(raw)
+		body = nil
+	}
+
+	// Refuse to label a non-JSON body as application/json under --json-strict
+	if body != nil && opts.JSON && opts.JSONStrict {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		if err := checkJSONBody(data); err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	// Compress the body if --compress-request is set
+	if body != nil && opts.CompressRequest {
+		compressed, err := compressBody(body, opts.CompressLevel)
+		if err != nil {
+			return nil, err
+		}
+		body = compressed
+	}
+
+	// Append --query params, keeping any query the target already has
+	reqURL := *parsedTarget.URL
+	if len(opts.Query) > 0 {
+		reqURL.RawQuery = appendQuery(reqURL.RawQuery, opts.Query)
+	}
+	reqURL.RawQuery = joinData(reqURL.RawQuery, getQuery)
+
 	// Create the HTTP request
-	req, err := http.NewRequestWithContext(ctx, method, parsedTarget.URL.String(), body)
+	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	if body != nil && opts.CompressRequest {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
 	// Add headers from -H flags
 	for _, header := range opts.Headers {
 		// Parse header as "Name: Value"
@@ -51,13 +125,34 @@ func BuildRequest(ctx context.Context, parsedTarget *target.ParsedTarget, opts *
 		if len(parts) == 2 {
 			name := strings.TrimSpace(parts[0])
 			value := strings.TrimSpace(parts[1])
-			req.Header.Set(name, value)
+			if opts.RawHeaderCase {
+				setRawHeader(req, name, value)
+			} else {
+				req.Header.Set(name, value)
+			}
+		}
+	}
+
+	// Add headers whose values come from environment variables (--header-env)
+	for _, mapping := range opts.HeaderEnv {
+		name, envVar, _ := strings.Cut(mapping, "=")
+		name = strings.TrimSpace(name)
+		envVar = strings.TrimSpace(envVar)
+		value, ok := os.LookupEnv(envVar)
+		if !ok {
+			return nil, &errors.MissingEnvError{Variable: envVar, Header: name}
 		}
+		req.Header.Set(name, value)
 	}
 
 	// Add authentication header if -u flag is provided
-	if opts.User != "" {
+	// Digest credentials are added once the server's challenge is known
+	// Credentials embedded in the target URL apply only without -u
+	if opts.User != "" && !opts.Digest {
 		addBasicAuth(req, opts.User)
+	} else if opts.User == "" && parsedTarget.UserInfo != nil {
+		password, _ := parsedTarget.UserInfo.Password()
+		addBasicAuth(req, parsedTarget.UserInfo.Username()+":"+password)
 	}
 
 	// Add Cookie header if --cookie flag is provided
@@ -75,6 +170,21 @@ func BuildRequest(ctx context.Context, parsedTarget *target.ParsedTarget, opts *
 		req.Header.Set("Referer", opts.Referer)
 	}
 
+	// Tag the request for correlation with server logs and traces
+	if opts.GenerateRequestID {
+		req.Header.Set("X-Request-ID", newUUID())
+	} else if opts.RequestID != "" {
+		req.Header.Set("X-Request-ID", opts.RequestID)
+	}
+	if opts.Traceparent {
+		req.Header.Set("Traceparent", newTraceparent())
+	}
+
+	// Ask for a compressed body, decoded on output, unless -H chose the encodings
+	if opts.Compressed && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", AcceptEncoding)
+	}
+
 	// Add JSON headers if --json flag is provided
 	if opts.JSON {
 		req.Header.Set("Content-Type", "application/json")
@@ -84,6 +194,128 @@ func BuildRequest(ctx context.Context, parsedTarget *target.ParsedTarget, opts *
 	return req, nil
 }
 
+// appendQuery appends "key=value" params to rawQuery in order, URL-encoding each part
+func appendQuery(rawQuery string, params []string) string {
+	parts := make([]string, 0, len(params)+1)
+	if rawQuery != "" {
+		parts = append(parts, rawQuery)
+	}
+	for _, param := range params {
+		key, value, _ := strings.Cut(param, "=")
+		parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+	}
+	return strings.Join(parts, "&")
+}
+
+// encodeData URL-encodes --data-urlencode values: "name=content" encodes only
+// the content, anything else is encoded whole
+func encodeData(values []string) string {
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		if name, content, ok := strings.Cut(value, "="); ok && name != "" {
+			parts = append(parts, name+"="+url.QueryEscape(content))
+		} else {
+			parts = append(parts, url.QueryEscape(strings.TrimPrefix(value, "=")))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// joinData joins two &-separated strings, skipping empty ones
+func joinData(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + "&" + b
+}
+
+// checkJSONBody returns an InvalidJSONBodyError unless data is valid JSON
+func checkJSONBody(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return &errors.InvalidJSONBodyError{Cause: err}
+	}
+	return nil
+}
+
+// compressBody gzips the body at the given level
+// A level of 0 uses gzip.DefaultCompression
+func compressBody(body io.Reader, level int) (*bytes.Buffer, error) {
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+
+	var buf bytes.Buffer
+	gz, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, fmt.Errorf("invalid compression level: %w", err)
+	}
+	if _, err := io.Copy(gz, body); err != nil {
+		return nil, fmt.Errorf("failed to compress request body: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress request body: %w", err)
+	}
+
+	return &buf, nil
+}
+
+// dataFiles caches -d @file bodies by path, so --repeat, retries and --next send
+// the same bytes; stdin in particular can only be read once
+var (
+	dataFilesMu sync.Mutex
+	dataFiles   = map[string]string{}
+)
+
+// readDataFile reads a -d @file body, with "-" meaning stdin, once per run
+// Carriage returns and newlines are stripped as curl does for -d
+func readDataFile(path string) (string, error) {
+	dataFilesMu.Lock()
+	defer dataFilesMu.Unlock()
+	if data, ok := dataFiles[path]; ok {
+		return data, nil
+	}
+
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", &errors.FileReadError{Path: path, Cause: err}
+	}
+
+	dataFiles[path] = strings.NewReplacer("\r", "", "\n", "").Replace(string(data))
+	return dataFiles[path], nil
+}
+
+// framingHeaders are taken from request fields by net/http, so they are always canonicalized
+var framingHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+	"Trailer":           true,
+}
+
+// setRawHeader stores a header under the exact name typed; HTTP/1.1 writes map keys as-is
+// net/http adds its default User-Agent unless the canonical key exists, so an empty
+// canonical entry suppresses it in favor of the re-cased one
+func setRawHeader(req *http.Request, name, value string) {
+	canonical := http.CanonicalHeaderKey(name)
+	if framingHeaders[canonical] || name == canonical {
+		req.Header.Set(name, value)
+		return
+	}
+
+	delete(req.Header, canonical)
+	req.Header[name] = []string{value}
+	if canonical == "User-Agent" {
+		req.Header[canonical] = []string{""}
+	}
+}
+
 // addBasicAuth adds Basic Authentication header to the request
 // Expects user string in format "user:password"
 func addBasicAuth(req *http.Request, user string) {
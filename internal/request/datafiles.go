@@ -0,0 +1,83 @@
+package request
+
+import (
+	"bufio"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+// defaultContentType is sent for files whose extension has no known MIME type
+const defaultContentType = "application/octet-stream"
+
+// DataFileListOptions reads a list of file paths and returns one POST per file chained
+// through Options.Next, each with the file as the body and a Content-Type from its extension
+// Blank lines and lines starting with # are skipped; --next requests after base run last
+func DataFileListOptions(listPath string, base *cli.Options) (*cli.Options, error) {
+	file, err := os.Open(listPath)
+	if err != nil {
+		return nil, &errors.FileReadError{Path: listPath, Cause: err}
+	}
+	defer file.Close()
+
+	var first, last *cli.Options
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		path := strings.TrimSpace(scanner.Text())
+		if path == "" || strings.HasPrefix(path, "#") {
+			continue
+		}
+
+		opts, err := dataFileOptions(path, base)
+		if err != nil {
+			return nil, err
+		}
+		if first == nil {
+			first = opts
+		} else {
+			last.Next = opts
+		}
+		last = opts
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, &errors.FileReadError{Path: listPath, Cause: err}
+	}
+
+	if first == nil {
+		return nil, fmt.Errorf("data file list %s has no files", listPath)
+	}
+	last.Next = base.Next
+	return first, nil
+}
+
+// dataFileOptions builds the upload request for a single file
+func dataFileOptions(path string, base *cli.Options) (*cli.Options, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &errors.FileReadError{Path: path, Cause: err}
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+
+	opts := *base
+	opts.Next = nil
+	opts.DataFile = path
+	opts.Data = ""
+	opts.DataRaw = string(data)
+	if opts.Method == "" {
+		opts.Method = "POST"
+	}
+
+	// Explicit -H headers still override the sniffed type
+	opts.Headers = append([]string{"Content-Type: " + contentType}, base.Headers...)
+
+	return &opts, nil
+}
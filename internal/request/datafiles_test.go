@@ -0,0 +1,95 @@
+package request
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+func TestDataFileListOptions(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "a.json")
+	blobPath := filepath.Join(dir, "b.unknownext")
+	os.WriteFile(jsonPath, []byte(`{"a":1}`), 0644)
+	os.WriteFile(blobPath, []byte{0x00, 0x01, 0x02}, 0644)
+
+	listPath := filepath.Join(dir, "files.txt")
+	os.WriteFile(listPath, []byte("# uploads\n"+jsonPath+"\n\n"+blobPath+"\n"), 0644)
+
+	base := &cli.Options{Target: "localhost:8080/upload", Headers: []string{"X-Batch: 1"}}
+	opts, err := DataFileListOptions(listPath, base)
+	if err != nil {
+		t.Fatalf("DataFileListOptions failed: %v", err)
+	}
+
+	if opts.Method != "POST" || opts.DataRaw != `{"a":1}` || opts.DataFile != jsonPath {
+		t.Errorf("first upload = %s %q from %s", opts.Method, opts.DataRaw, opts.DataFile)
+	}
+	if len(opts.Headers) != 2 || opts.Headers[0] != "Content-Type: application/json" || opts.Headers[1] != "X-Batch: 1" {
+		t.Errorf("first upload headers = %v", opts.Headers)
+	}
+
+	second := opts.Next
+	if second == nil {
+		t.Fatal("expected a second upload")
+	}
+	if second.DataRaw != "\x00\x01\x02" || second.Headers[0] != "Content-Type: "+defaultContentType {
+		t.Errorf("second upload = %q with headers %v", second.DataRaw, second.Headers)
+	}
+	if second.Next != nil {
+		t.Error("expected exactly two uploads")
+	}
+
+	// The base options are left untouched
+	if len(base.Headers) != 1 {
+		t.Errorf("base headers modified: %v", base.Headers)
+	}
+}
+
+func TestDataFileListOptions_KeepsNext(t *testing.T) {
+	dir := t.TempDir()
+	dataPath := filepath.Join(dir, "a.json")
+	os.WriteFile(dataPath, []byte(`{"a":1}`), 0644)
+	listPath := filepath.Join(dir, "files.txt")
+	os.WriteFile(listPath, []byte(dataPath+"\n"), 0644)
+
+	next := &cli.Options{Target: "localhost:8080/done", Method: "GET"}
+	opts, err := DataFileListOptions(listPath, &cli.Options{Target: "localhost:8080/upload", Next: next})
+	if err != nil {
+		t.Fatalf("DataFileListOptions failed: %v", err)
+	}
+
+	if opts.DataFile != dataPath {
+		t.Errorf("first request uploads %q, want %q", opts.DataFile, dataPath)
+	}
+	if opts.Next != next {
+		t.Errorf("expected the --next request after the last upload, got %+v", opts.Next)
+	}
+}
+
+func TestDataFileListOptions_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	missingFile := filepath.Join(dir, "missing-file.txt")
+	os.WriteFile(missingFile, []byte(filepath.Join(dir, "nope.bin")+"\n"), 0644)
+
+	empty := filepath.Join(dir, "empty.txt")
+	os.WriteFile(empty, []byte("# nothing\n"), 0644)
+
+	for _, listPath := range []string{filepath.Join(dir, "no-list.txt"), missingFile, empty} {
+		if _, err := DataFileListOptions(listPath, &cli.Options{}); err == nil {
+			t.Errorf("expected error for list %s", filepath.Base(listPath))
+		}
+	}
+
+	// Unreadable lists and listed files exit like any other unreadable input
+	for _, listPath := range []string{filepath.Join(dir, "no-list.txt"), missingFile, dir} {
+		_, err := DataFileListOptions(listPath, &cli.Options{})
+		if code := errors.MapErrorToExitCode(err); code != errors.ExitReadError {
+			t.Errorf("list %s: exit code = %d, want %d (%v)", filepath.Base(listPath), code, errors.ExitReadError, err)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package request
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/aleister1102/purl/internal/cli"
+)
+
+// DigestChallenge is a server's Digest challenge plus the nonce count used against it
+// It is persisted by --digest-state so later runs can authenticate preemptively
+type DigestChallenge struct {
+	Host      string `json:"host"` // host:port that issued the challenge
+	Realm     string `json:"realm"`
+	Nonce     string `json:"nonce"`
+	Opaque    string `json:"opaque,omitempty"`
+	QOP       string `json:"qop,omitempty"`
+	Algorithm string `json:"algorithm,omitempty"`
+	NC        int    `json:"nc"`
+}
+
+// DoDigest sends req with HTTP Digest authentication using the -u credentials
+// A 401 Digest challenge is answered with one authenticated retry; with --preemptive-auth
+// a challenge stored in --digest-state is answered up front, skipping the 401 round trip
+func DoDigest(req *http.Request, opts *cli.Options, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	user, password, _ := strings.Cut(opts.User, ":")
+
+	// A stored challenge only answers the host that issued it
+	var challenge *DigestChallenge
+	if opts.DigestState != "" {
+		var err error
+		if challenge, err = LoadDigestState(opts.DigestState); err != nil {
+			return nil, err
+		}
+		if challenge != nil && challenge.Host != req.URL.Host {
+			challenge = nil
+		}
+	}
+
+	if opts.PreemptiveAuth && challenge != nil {
+		if err := challenge.Authorize(req, user, password); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := send(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Answer a fresh challenge (or a stale stored nonce) once
+	if resp.StatusCode == http.StatusUnauthorized {
+		fresh, parseErr := ParseDigestChallenge(resp.Header.Get("WWW-Authenticate"))
+		if parseErr != nil {
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		retry := req.Clone(req.Context())
+		if req.GetBody != nil {
+			if retry.Body, err = req.GetBody(); err != nil {
+				return nil, fmt.Errorf("failed to rewind request body: %w", err)
+			}
+		}
+		// Keep counting a nonce the same host and realm issued again, so it is not replayed
+		fresh.Host = req.URL.Host
+		if challenge != nil && challenge.Realm == fresh.Realm && challenge.Nonce == fresh.Nonce {
+			fresh.NC = challenge.NC
+		}
+		challenge = fresh
+		if err := challenge.Authorize(retry, user, password); err != nil {
+			return nil, err
+		}
+		if resp, err = send(retry); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.DigestState != "" && challenge != nil && resp.StatusCode != http.StatusUnauthorized {
+		if err := SaveDigestState(opts.DigestState, challenge); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// ParseDigestChallenge parses a WWW-Authenticate header carrying a Digest challenge
+func ParseDigestChallenge(header string) (*DigestChallenge, error) {
+	scheme, params, _ := strings.Cut(strings.TrimSpace(header), " ")
+	if !strings.EqualFold(scheme, "Digest") {
+		return nil, fmt.Errorf("not a Digest challenge: %q", header)
+	}
+
+	challenge := &DigestChallenge{}
+	for _, param := range splitDigestParams(params) {
+		key, value, _ := strings.Cut(param, "=")
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "realm":
+			challenge.Realm = value
+		case "nonce":
+			challenge.Nonce = value
+		case "opaque":
+			challenge.Opaque = value
+		case "algorithm":
+			challenge.Algorithm = value
+		case "qop":
+			// Prefer auth when the server offers several protection levels
+			for _, qop := range strings.Split(value, ",") {
+				if strings.TrimSpace(qop) == "auth" {
+					challenge.QOP = "auth"
+				}
+			}
+		}
+	}
+
+	if challenge.Nonce == "" {
+		return nil, fmt.Errorf("Digest challenge has no nonce: %q", header)
+	}
+	return challenge, nil
+}
+
+// splitDigestParams splits challenge parameters on commas outside quoted strings
+func splitDigestParams(params string) []string {
+	var parts []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range params {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+		case r == ',' && !quoted:
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	return append(parts, current.String())
+}
+
+// Authorize sets the Digest Authorization header on req, advancing the nonce count
+func (c *DigestChallenge) Authorize(req *http.Request, user, password string) error {
+	var newHash func() hash.Hash
+	switch strings.ToUpper(c.Algorithm) {
+	case "", "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return fmt.Errorf("unsupported Digest algorithm: %s", c.Algorithm)
+	}
+	digest := func(s string) string {
+		h := newHash()
+		h.Write([]byte(s))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+
+	c.NC++
+	uri := req.URL.RequestURI()
+	ha1 := digest(user + ":" + c.Realm + ":" + password)
+	ha2 := digest(req.Method + ":" + uri)
+
+	fields := []string{
+		fmt.Sprintf("username=%q", user),
+		fmt.Sprintf("realm=%q", c.Realm),
+		fmt.Sprintf("nonce=%q", c.Nonce),
+		fmt.Sprintf("uri=%q", uri),
+	}
+
+	var response string
+	if c.QOP == "auth" {
+		cnonce := make([]byte, 8)
+		if _, err := rand.Read(cnonce); err != nil {
+			return fmt.Errorf("failed to generate cnonce: %w", err)
+		}
+		nc := fmt.Sprintf("%08x", c.NC)
+		cnonceHex := hex.EncodeToString(cnonce)
+		response = digest(strings.Join([]string{ha1, c.Nonce, nc, cnonceHex, c.QOP, ha2}, ":"))
+		fields = append(fields, "qop="+c.QOP, "nc="+nc, fmt.Sprintf("cnonce=%q", cnonceHex))
+	} else {
+		response = digest(ha1 + ":" + c.Nonce + ":" + ha2)
+	}
+
+	fields = append(fields, fmt.Sprintf("response=%q", response))
+	if c.Opaque != "" {
+		fields = append(fields, fmt.Sprintf("opaque=%q", c.Opaque))
+	}
+	if c.Algorithm != "" {
+		fields = append(fields, "algorithm="+c.Algorithm)
+	}
+
+	req.Header.Set("Authorization", "Digest "+strings.Join(fields, ", "))
+	return nil
+}
+
+// LoadDigestState reads a stored challenge; a missing file returns nil without error
+func LoadDigestState(path string) (*DigestChallenge, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read digest state: %w", err)
+	}
+
+	var challenge DigestChallenge
+	if err := json.Unmarshal(data, &challenge); err != nil {
+		return nil, fmt.Errorf("failed to parse digest state %s: %w", path, err)
+	}
+	return &challenge, nil
+}
+
+// SaveDigestState stores the challenge and its nonce count for the next run
+func SaveDigestState(path string, challenge *DigestChallenge) error {
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return fmt.Errorf("failed to encode digest state: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write digest state: %w", err)
+	}
+	return nil
+}
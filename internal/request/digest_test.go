@@ -0,0 +1,175 @@
+package request
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aleister1102/purl/internal/cli"
+)
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// newDigestServer verifies Digest credentials for alice:secret and counts 401 challenges
+func newDigestServer(t *testing.T, challenges *int) *httptest.Server {
+	t.Helper()
+
+	const realm, nonce = "test", "abc123"
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if strings.HasPrefix(auth, "Digest ") {
+			params := map[string]string{}
+			for _, param := range splitDigestParams(strings.TrimPrefix(auth, "Digest ")) {
+				key, value, _ := strings.Cut(param, "=")
+				params[strings.TrimSpace(key)] = strings.Trim(value, `"`)
+			}
+			ha1 := md5Hex("alice:" + realm + ":secret")
+			ha2 := md5Hex(r.Method + ":" + params["uri"])
+			expected := md5Hex(strings.Join([]string{ha1, nonce, params["nc"], params["cnonce"], "auth", ha2}, ":"))
+			if params["response"] == expected {
+				w.Write([]byte("authorized"))
+				return
+			}
+		}
+
+		*challenges++
+		w.Header().Set("WWW-Authenticate", `Digest realm="test", nonce="abc123", qop="auth,auth-int", opaque="xyz"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+}
+
+func TestParseDigestChallenge(t *testing.T) {
+	challenge, err := ParseDigestChallenge(`Digest realm="a, b", nonce="n1", qop="auth-int,auth", algorithm=SHA-256, opaque="o"`)
+	if err != nil {
+		t.Fatalf("ParseDigestChallenge failed: %v", err)
+	}
+
+	expected := DigestChallenge{Realm: "a, b", Nonce: "n1", QOP: "auth", Algorithm: "SHA-256", Opaque: "o"}
+	if *challenge != expected {
+		t.Errorf("got %+v, want %+v", *challenge, expected)
+	}
+
+	if _, err := ParseDigestChallenge(`Basic realm="test"`); err == nil {
+		t.Error("Expected error for a Basic challenge")
+	}
+}
+
+func TestDoDigest_PreemptiveSecondRun(t *testing.T) {
+	var challenges int
+	server := newDigestServer(t, &challenges)
+	defer server.Close()
+
+	opts := &cli.Options{
+		User:           "alice:secret",
+		Digest:         true,
+		DigestState:    filepath.Join(t.TempDir(), "digest.json"),
+		PreemptiveAuth: true,
+	}
+
+	var sent []string
+	send := func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req.Header.Get("Authorization"))
+		return http.DefaultClient.Do(req)
+	}
+
+	for run := 1; run <= 2; run++ {
+		sent = nil
+		req, _ := http.NewRequest("GET", server.URL+"/protected", nil)
+		resp, err := DoDigest(req, opts, send)
+		if err != nil {
+			t.Fatalf("run %d: DoDigest failed: %v", run, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("run %d: expected 200, got %d", run, resp.StatusCode)
+		}
+		if run == 2 {
+			if len(sent) != 1 || !strings.HasPrefix(sent[0], "Digest ") {
+				t.Errorf("run 2: expected one preemptively authorized request, got %q", sent)
+			}
+			if !strings.Contains(sent[0], "nc=00000002") {
+				t.Errorf("run 2: expected the nonce count to advance, got %q", sent[0])
+			}
+		}
+	}
+
+	if challenges != 1 {
+		t.Errorf("Expected a single 401 challenge across both runs, got %d", challenges)
+	}
+}
+
+func TestDoDigest_WithoutPreemptiveAuth(t *testing.T) {
+	var challenges int
+	server := newDigestServer(t, &challenges)
+	defer server.Close()
+
+	opts := &cli.Options{
+		User:        "alice:secret",
+		Digest:      true,
+		DigestState: filepath.Join(t.TempDir(), "digest.json"),
+	}
+
+	for run := 1; run <= 2; run++ {
+		req, _ := http.NewRequest("GET", server.URL+"/protected", nil)
+		resp, err := DoDigest(req, opts, http.DefaultClient.Do)
+		if err != nil {
+			t.Fatalf("run %d: DoDigest failed: %v", run, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("run %d: expected 200, got %d", run, resp.StatusCode)
+		}
+	}
+
+	if challenges != 2 {
+		t.Errorf("Expected a 401 challenge on each run, got %d", challenges)
+	}
+}
+
+func TestDoDigest_StateForAnotherHost(t *testing.T) {
+	var challenges int
+	server := newDigestServer(t, &challenges)
+	defer server.Close()
+
+	// A challenge stored for a different host must not be answered preemptively
+	state := filepath.Join(t.TempDir(), "digest.json")
+	stored := &DigestChallenge{Host: "other.example:443", Realm: "test", Nonce: "abc123", QOP: "auth"}
+	if err := SaveDigestState(state, stored); err != nil {
+		t.Fatalf("SaveDigestState failed: %v", err)
+	}
+
+	opts := &cli.Options{User: "alice:secret", Digest: true, DigestState: state, PreemptiveAuth: true}
+
+	var sent []string
+	send := func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req.Header.Get("Authorization"))
+		return http.DefaultClient.Do(req)
+	}
+
+	req, _ := http.NewRequest("GET", server.URL+"/protected", nil)
+	resp, err := DoDigest(req, opts, send)
+	if err != nil {
+		t.Fatalf("DoDigest failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(sent) != 2 || sent[0] != "" {
+		t.Errorf("Expected an unauthenticated request then the answer to the challenge, got %q", sent)
+	}
+
+	saved, err := LoadDigestState(state)
+	if err != nil {
+		t.Fatalf("LoadDigestState failed: %v", err)
+	}
+	if want := strings.TrimPrefix(server.URL, "http://"); saved.Host != want || saved.Realm != "test" {
+		t.Errorf("Expected the state to record host %s and realm test, got %+v", want, saved)
+	}
+}
@@ -0,0 +1,157 @@
+package request
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/aleister1102/purl/internal/cli"
+)
+
+// defaultRetryDelay is the wait before the first retry when --retry-delay is not set
+var defaultRetryDelay = time.Second
+
+// maxRetryDelay caps the exponential backoff between attempts
+const maxRetryDelay = 10 * time.Minute
+
+// DefaultRetryMethods are the methods retried without --retry-methods or --retry-all-errors
+// Retrying POST, PUT or PATCH could apply a write twice
+var DefaultRetryMethods = []string{"GET", "HEAD", "OPTIONS", "TRACE"}
+
+// DefaultConnResetRetries is how often --retry-connreset retries a reset without --retry
+const DefaultConnResetRetries = 3
+
+// RetryBudget caps the total number of retries across all requests of one invocation
+// A nil budget allows unlimited retries
+type RetryBudget struct {
+	remaining atomic.Int64
+}
+
+// NewRetryBudget creates a budget allowing max retries in total
+// Returns nil (unlimited) when max is not positive
+func NewRetryBudget(max int) *RetryBudget {
+	if max <= 0 {
+		return nil
+	}
+	budget := &RetryBudget{}
+	budget.remaining.Store(int64(max))
+	return budget
+}
+
+// take consumes one retry, reporting whether the budget allowed it
+func (b *RetryBudget) take() bool {
+	if b == nil {
+		return true
+	}
+	return b.remaining.Add(-1) >= 0
+}
+
+// Execute sends the request, retrying transient failures up to opts.Retry times
+// The wait doubles after each attempt, starting at --retry-delay; no retry is
+// started once --retry-max-time would be exceeded
+// Retries are drawn from budget, which may be shared by concurrent callers
+func Execute(client *http.Client, req *http.Request, opts *cli.Options, budget *RetryBudget) (*http.Response, error) {
+	start := time.Now()
+	for attempt := 0; ; attempt++ {
+		resp, err := client.Do(req)
+		if attempt >= retryLimit(opts, err) || !shouldRetry(req, resp, err, opts) {
+			return resp, err
+		}
+
+		// Give up once the next wait would run past --retry-max-time
+		delay := retryDelay(opts, attempt)
+		if opts.RetryMaxTime > 0 && time.Since(start)+delay > opts.RetryMaxTime || !budget.take() {
+			return resp, err
+		}
+
+		// Discard the failed response so the connection can be reused
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		// Rewind the request body for the next attempt
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewind request body: %w", err)
+			}
+			req.Body = body
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// retryDelay returns the wait after the given attempt (0 for the first):
+// --retry-delay (or defaultRetryDelay) doubled per attempt, capped at maxRetryDelay
+func retryDelay(opts *cli.Options, attempt int) time.Duration {
+	delay := opts.RetryDelay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+	for i := 0; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+// retryLimit returns how many retries the failure allows
+// --retry-connreset retries connection resets even when --retry is not set
+func retryLimit(opts *cli.Options, err error) int {
+	if opts.RetryConnReset && isConnectionReset(err) && opts.Retry < DefaultConnResetRetries {
+		return DefaultConnResetRetries
+	}
+	return opts.Retry
+}
+
+// isConnectionReset reports whether err was caused by the peer resetting the connection
+func isConnectionReset(err error) bool {
+	return err != nil && stderrors.Is(err, syscall.ECONNRESET)
+}
+
+// shouldRetry reports whether a failed attempt is transient and worth retrying
+// Matches curl: connection errors, timeouts, 408, 429, and 5xx responses
+// Only methods in the retry allowlist are retried; --retry-all-errors lifts
+// that and also retries 4xx responses
+func shouldRetry(req *http.Request, resp *http.Response, err error, opts *cli.Options) bool {
+	// The overall deadline or an interrupt ends the request for good
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	if !opts.RetryAllErrors && !retryMethodAllowed(req.Method, opts) {
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+
+	switch {
+	case opts.RetryAllErrors && resp.StatusCode >= 400:
+		return true
+	case resp.StatusCode == http.StatusRequestTimeout:
+		return true
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return true
+	case resp.StatusCode >= 500:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryMethodAllowed reports whether method is in DefaultRetryMethods or --retry-methods
+func retryMethodAllowed(method string, opts *cli.Options) bool {
+	return slices.Contains(DefaultRetryMethods, method) || slices.Contains(opts.RetryMethods, method)
+}
@@ -0,0 +1,353 @@
+package request
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/target"
+)
+
+func init() {
+	// Keep retry tests fast
+	defaultRetryDelay = time.Millisecond
+}
+
+func newTestTarget(t *testing.T, rawURL string) *target.ParsedTarget {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("invalid URL %q: %v", rawURL, err)
+	}
+	return &target.ParsedTarget{URL: u}
+}
+
+func TestExecute_RetriesUntilSuccess(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("attempt %d: body = %q, want %q", hits.Load()+1, string(body), "payload")
+		}
+		if hits.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{Retry: 3, Data: "payload", RetryMethods: []string{"POST"}}
+	req, err := BuildRequest(context.Background(), newTestTarget(t, server.URL), opts)
+	if err != nil {
+		t.Fatalf("BuildRequest failed: %v", err)
+	}
+
+	resp, err := Execute(server.Client(), req, opts, nil)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if hits.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", hits.Load())
+	}
+}
+
+func TestExecute_NoRetryOnClientError(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{Retry: 3}
+	req, _ := BuildRequest(context.Background(), newTestTarget(t, server.URL), opts)
+
+	resp, err := Execute(server.Client(), req, opts, nil)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if hits.Load() != 1 {
+		t.Errorf("Expected 1 attempt for 404, got %d", hits.Load())
+	}
+}
+
+func TestExecute_RetryBudgetSharedAcrossTargets(t *testing.T) {
+	const targets = 5
+	const budget = 3
+
+	var hits atomic.Int32
+	var servers []*httptest.Server
+	for i := 0; i < targets; i++ {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+		servers = append(servers, server)
+	}
+
+	opts := &cli.Options{Retry: 4}
+	retryBudget := NewRetryBudget(budget)
+
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func(server *httptest.Server) {
+			defer wg.Done()
+			req, _ := BuildRequest(context.Background(), newTestTarget(t, server.URL), opts)
+			resp, err := Execute(server.Client(), req, opts, retryBudget)
+			if err != nil {
+				t.Errorf("Execute failed: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}(server)
+	}
+	wg.Wait()
+
+	// One initial attempt per target plus at most the budgeted retries
+	if retries := int(hits.Load()) - targets; retries != budget {
+		t.Errorf("Expected exactly %d retries in total, got %d", budget, retries)
+	}
+}
+
+func TestNewRetryBudget_Unlimited(t *testing.T) {
+	budget := NewRetryBudget(0)
+	for i := 0; i < 100; i++ {
+		if !budget.take() {
+			t.Fatalf("unlimited budget refused retry %d", i)
+		}
+	}
+}
+
+// newResetServer accepts connections, resetting the first resets of them and
+// answering the rest with 200 OK
+func newResetServer(t *testing.T, resets int) (string, *atomic.Int32) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var connections atomic.Int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			n := connections.Add(1)
+			go func(conn net.Conn) {
+				defer conn.Close()
+				if _, err := http.ReadRequest(bufio.NewReader(conn)); err != nil {
+					return
+				}
+				if int(n) <= resets {
+					// Linger 0 makes Close send RST instead of FIN
+					conn.(*net.TCPConn).SetLinger(0)
+					return
+				}
+				io.WriteString(conn, "HTTP/1.1 200 OK\r\nContent-Length: 2\r\nConnection: close\r\n\r\nok")
+			}(conn)
+		}
+	}()
+
+	return "http://" + listener.Addr().String(), &connections
+}
+
+func TestExecute_RetryConnReset(t *testing.T) {
+	tests := []struct {
+		name            string
+		opts            *cli.Options
+		wantErr         bool
+		wantConnections int32
+	}{
+		{
+			name:            "retry-connreset retries without --retry",
+			opts:            &cli.Options{RetryConnReset: true},
+			wantConnections: 2,
+		},
+		{
+			name:            "--retry retries a reset",
+			opts:            &cli.Options{Retry: 1},
+			wantConnections: 2,
+		},
+		{
+			name:            "no retry flags surface the reset",
+			opts:            &cli.Options{},
+			wantErr:         true,
+			wantConnections: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverURL, connections := newResetServer(t, 1)
+
+			req, _ := BuildRequest(context.Background(), newTestTarget(t, serverURL), tt.opts)
+			client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+			resp, err := Execute(client, req, tt.opts, nil)
+			if tt.wantErr {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatal("Expected connection reset error")
+				}
+				if !isConnectionReset(err) {
+					t.Errorf("Expected a connection reset, got: %v", err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Execute failed: %v", err)
+				}
+				resp.Body.Close()
+			}
+
+			if got := connections.Load(); got != tt.wantConnections {
+				t.Errorf("Expected %d connections, got %d", tt.wantConnections, got)
+			}
+		})
+	}
+}
+
+func TestExecute_RetryMethodAllowlist(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     *cli.Options
+		status   int
+		wantHits int32
+	}{
+		{"GET retried by default", &cli.Options{Method: "GET", Retry: 2}, http.StatusServiceUnavailable, 3},
+		{"POST not retried by default", &cli.Options{Method: "POST", Data: "x", Retry: 2}, http.StatusServiceUnavailable, 1},
+		{"PATCH not retried by default", &cli.Options{Method: "PATCH", Data: "x", Retry: 2}, http.StatusServiceUnavailable, 1},
+		{"POST retried when allowlisted", &cli.Options{Method: "POST", Data: "x", Retry: 2, RetryMethods: []string{"POST", "PUT"}}, http.StatusServiceUnavailable, 3},
+		{"POST retried with retry-all-errors", &cli.Options{Method: "POST", Data: "x", Retry: 2, RetryAllErrors: true}, http.StatusServiceUnavailable, 3},
+		{"4xx retried with retry-all-errors", &cli.Options{Retry: 1, RetryAllErrors: true}, http.StatusNotFound, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			req, err := BuildRequest(context.Background(), newTestTarget(t, server.URL), tt.opts)
+			if err != nil {
+				t.Fatalf("BuildRequest failed: %v", err)
+			}
+			resp, err := Execute(server.Client(), req, tt.opts, nil)
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			resp.Body.Close()
+
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("Expected %d attempts, got %d", tt.wantHits, got)
+			}
+		})
+	}
+}
+
+func TestRetryDelay_Backoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		delay   time.Duration
+		attempt int
+		want    time.Duration
+	}{
+		{"first retry waits the base delay", 200 * time.Millisecond, 0, 200 * time.Millisecond},
+		{"second retry doubles", 200 * time.Millisecond, 1, 400 * time.Millisecond},
+		{"third retry doubles again", 200 * time.Millisecond, 2, 800 * time.Millisecond},
+		{"unset delay uses the default", 0, 1, 2 * defaultRetryDelay},
+		{"capped at the maximum", time.Minute, 20, maxRetryDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryDelay(&cli.Options{RetryDelay: tt.delay}, tt.attempt); got != tt.want {
+				t.Errorf("retryDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecute_BackoffFailsTwiceThenSucceeds(t *testing.T) {
+	var hits atomic.Int32
+	var attempts []time.Time
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts = append(attempts, time.Now())
+		mu.Unlock()
+		if hits.Add(1) <= 2 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{Retry: 3, RetryDelay: 20 * time.Millisecond}
+	req, _ := BuildRequest(context.Background(), newTestTarget(t, server.URL), opts)
+
+	resp, err := Execute(server.Client(), req, opts, nil)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || hits.Load() != 3 {
+		t.Fatalf("Expected success on the 3rd attempt, got status %d after %d attempts", resp.StatusCode, hits.Load())
+	}
+	if first, second := attempts[1].Sub(attempts[0]), attempts[2].Sub(attempts[1]); first < 20*time.Millisecond || second < 40*time.Millisecond {
+		t.Errorf("Expected waits of at least 20ms then 40ms, got %v then %v", first, second)
+	}
+}
+
+func TestExecute_RetryMaxTime(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	// The second wait (100ms) would run past the 150ms limit
+	opts := &cli.Options{Retry: 5, RetryDelay: 50 * time.Millisecond, RetryMaxTime: 150 * time.Millisecond}
+	req, _ := BuildRequest(context.Background(), newTestTarget(t, server.URL), opts)
+
+	resp, err := Execute(server.Client(), req, opts, nil)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected the last 503 to be returned, got %d", resp.StatusCode)
+	}
+	if got := hits.Load(); got != 2 {
+		t.Errorf("Expected 2 attempts within --retry-max-time, got %d", got)
+	}
+}
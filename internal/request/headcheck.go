@@ -0,0 +1,49 @@
+package request
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+// HeadContentLength sends a HEAD for req's URL and headers and returns the advertised
+// Content-Length, or -1 when the server does not send one
+func HeadContentLength(client *http.Client, req *http.Request) (int64, error) {
+	head := req.Clone(req.Context())
+	head.Method = http.MethodHead
+	head.Body = nil
+	head.GetBody = nil
+	head.ContentLength = 0
+	head.Header.Del("Content-Type")
+	head.Header.Del("Content-Encoding")
+
+	resp, err := client.Do(head)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.ContentLength, nil
+}
+
+// CountingBody counts the bytes read through a response body
+type CountingBody struct {
+	io.ReadCloser
+	N int64
+}
+
+// Read reads from the wrapped body, adding to the count
+func (b *CountingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.N += int64(n)
+	return n, err
+}
+
+// CheckContentLength compares the HEAD Content-Length with the GET body size
+// An unknown HEAD length (-1) cannot disagree
+func CheckContentLength(head, actual int64) error {
+	if head >= 0 && head != actual {
+		return &errors.ContentLengthMismatchError{Head: head, Actual: actual}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package request
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+func TestHeadContentLength(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.Method == http.MethodHead {
+			// Advertise more than the GET body actually carries
+			w.Header().Set("Content-Length", "100")
+			return
+		}
+		w.Write([]byte("short body"))
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest("POST", server.URL, strings.NewReader("payload"))
+	length, err := HeadContentLength(http.DefaultClient, req)
+	if err != nil {
+		t.Fatalf("HeadContentLength failed: %v", err)
+	}
+	if length != 100 {
+		t.Errorf("Expected HEAD length 100, got %d", length)
+	}
+	if len(methods) != 1 || methods[0] != http.MethodHead {
+		t.Errorf("Expected a single HEAD request, got %v", methods)
+	}
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	counter := &CountingBody{ReadCloser: resp.Body}
+	io.Copy(io.Discard, counter)
+	counter.Close()
+
+	err = CheckContentLength(length, counter.N)
+	if err == nil || !strings.Contains(err.Error(), "HEAD Content-Length 100 does not match GET body size 10") {
+		t.Fatalf("Expected a length discrepancy, got %v", err)
+	}
+	if code := errors.MapErrorToExitCode(err); code != errors.ExitAssertionFailed {
+		t.Errorf("Expected exit code %d, got %d", errors.ExitAssertionFailed, code)
+	}
+}
+
+func TestCheckContentLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		head    int64
+		actual  int64
+		wantErr bool
+	}{
+		{"lengths agree", 10, 10, false},
+		{"HEAD without length", -1, 10, false},
+		{"lengths differ", 100, 10, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckContentLength(tt.head, tt.actual); (err != nil) != tt.wantErr {
+				t.Errorf("CheckContentLength() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package request
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// newUUID returns a random (version 4) UUID
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// newTraceparent returns a W3C trace context header for a new sampled trace:
+// version 00, a random trace ID and parent span ID, and flags 01
+func newTraceparent() string {
+	var traceID [16]byte
+	var spanID [8]byte
+	rand.Read(traceID[:])
+	rand.Read(spanID[:])
+	return "00-" + hex.EncodeToString(traceID[:]) + "-" + hex.EncodeToString(spanID[:]) + "-01"
+}
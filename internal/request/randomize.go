@@ -0,0 +1,104 @@
+package request
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// userAgents and acceptValues are the pools --randomize-headers draws from
+var (
+	userAgents = []string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15",
+		"Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:125.0) Gecko/20100101 Firefox/125.0",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+		"curl/8.7.1",
+	}
+	acceptValues = []string{
+		"*/*",
+		"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+		"application/json, text/plain, */*",
+		"text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8",
+	}
+)
+
+// fixedCaseHeaders are looked up by their canonical key in net/http, the cookie jar,
+// Digest auth and the output, and would be duplicated or missed if re-cased
+var fixedCaseHeaders = map[string]bool{
+	"Host":                true,
+	"User-Agent":          true,
+	"Content-Length":      true,
+	"Content-Type":        true,
+	"Content-Encoding":    true,
+	"Transfer-Encoding":   true,
+	"Trailer":             true,
+	"Connection":          true,
+	"Accept-Encoding":     true,
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+}
+
+// Randomizer varies request fingerprints for --randomize-headers
+// It is seeded once per invocation so a fixed --randomize-seed reproduces every request
+type Randomizer struct {
+	mu  sync.Mutex // --parallel targets share one randomizer
+	rng *rand.Rand
+}
+
+// NewRandomizer creates a randomizer; a zero seed uses the current time
+func NewRandomizer(seed int64) *Randomizer {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Randomizer{rng: rand.New(rand.NewPCG(uint64(seed), uint64(seed)))}
+}
+
+// Apply picks a User-Agent and Accept value unless already set and re-cases header names
+// net/http writes headers sorted by key, so varying the case shuffles their order on the wire
+func (r *Randomizer) Apply(req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", userAgents[r.rng.IntN(len(userAgents))])
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", acceptValues[r.rng.IntN(len(acceptValues))])
+	}
+
+	// Visit keys in sorted order so the RNG sequence does not depend on map iteration
+	keys := make([]string, 0, len(req.Header))
+	for key := range req.Header {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if fixedCaseHeaders[http.CanonicalHeaderKey(key)] {
+			continue
+		}
+		recased := randomCase(r.rng, key)
+		if recased != key {
+			req.Header[recased] = req.Header[key]
+			delete(req.Header, key)
+		}
+	}
+}
+
+// randomCase returns name as canonical, lower or upper case
+func randomCase(rng *rand.Rand, name string) string {
+	switch rng.IntN(3) {
+	case 1:
+		return strings.ToLower(name)
+	case 2:
+		return strings.ToUpper(name)
+	default:
+		return http.CanonicalHeaderKey(name)
+	}
+}
@@ -0,0 +1,116 @@
+package request
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// wireOrder returns header names in the order net/http writes them
+func wireOrder(header http.Header) string {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, ",")
+}
+
+// randomizedOrders applies a randomizer seeded with seed to n identical requests
+func randomizedOrders(t *testing.T, seed int64, n int) []string {
+	t.Helper()
+
+	randomizer := NewRandomizer(seed)
+	var orders []string
+	for i := 0; i < n; i++ {
+		req, err := http.NewRequest("GET", "http://example.com/", nil)
+		if err != nil {
+			t.Fatalf("NewRequest failed: %v", err)
+		}
+		req.Header.Set("X-Request-Id", "1")
+		req.Header.Set("Cache-Control", "no-cache")
+		req.Header.Set("Referer", "http://example.com/")
+		req.Header.Set("Accept-Language", "en")
+
+		randomizer.Apply(req)
+		orders = append(orders, wireOrder(req.Header))
+	}
+	return orders
+}
+
+func TestRandomizer_ReproducibleOrderings(t *testing.T) {
+	first := randomizedOrders(t, 42, 5)
+	second := randomizedOrders(t, 42, 5)
+
+	if !slices.Equal(first, second) {
+		t.Errorf("Same seed produced different orderings:\n%v\n%v", first, second)
+	}
+
+	distinct := map[string]bool{}
+	for _, order := range first {
+		distinct[order] = true
+	}
+	if len(distinct) < 2 {
+		t.Errorf("Expected repeats to produce different header orderings, got %v", first)
+	}
+}
+
+func TestRandomizer_KeepsExplicitValues(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	req.Header.Set("User-Agent", "custom-agent")
+	req.Header.Set("Accept", "application/json")
+
+	NewRandomizer(7).Apply(req)
+
+	if got := req.Header.Get("User-Agent"); got != "custom-agent" {
+		t.Errorf("User-Agent = %q, want custom-agent", got)
+	}
+	if _, ok := req.Header["User-Agent"]; !ok {
+		t.Error("User-Agent must keep its canonical key")
+	}
+
+	// Accept may be re-cased, so look it up case-insensitively
+	for key, values := range req.Header {
+		if strings.EqualFold(key, "Accept") && values[0] != "application/json" {
+			t.Errorf("Accept = %q, want application/json", values[0])
+		}
+	}
+}
+
+func TestRandomizer_SetsUserAgentAndAccept(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	NewRandomizer(1).Apply(req)
+
+	if !slices.Contains(userAgents, req.Header.Get("User-Agent")) {
+		t.Errorf("User-Agent %q not from the pool", req.Header.Get("User-Agent"))
+	}
+
+	var accept string
+	for key, values := range req.Header {
+		if strings.EqualFold(key, "Accept") {
+			accept = values[0]
+		}
+	}
+	if !slices.Contains(acceptValues, accept) {
+		t.Errorf("Accept %q not from the pool", accept)
+	}
+}
+
+func TestRandomizer_KeepsCredentialAndContentKeys(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		req, _ := http.NewRequest("POST", "http://example.com/", nil)
+		req.Header.Set("Authorization", "Basic YTpi")
+		req.Header.Set("Proxy-Authorization", "Basic YzpkI")
+		req.Header.Set("Cookie", "a=1")
+		req.Header.Set("Content-Type", "application/json")
+
+		NewRandomizer(seed).Apply(req)
+
+		for _, key := range []string{"Authorization", "Proxy-Authorization", "Cookie", "Content-Type"} {
+			if _, ok := req.Header[key]; !ok {
+				t.Errorf("seed %d: %s lost its canonical key: %v", seed, key, req.Header)
+			}
+		}
+	}
+}
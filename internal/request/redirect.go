@@ -0,0 +1,53 @@
+package request
+
+import (
+	"net/http"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+// DefaultMaxRedirs is the redirect limit used when --max-redirs is not set
+const DefaultMaxRedirs = 10
+
+// CheckRedirect returns an http.Client redirect policy honoring --max-redirs
+// A chain that revisits a URL already seen is aborted as a redirect loop
+// With --fail-if-redirect the 3xx response is returned unfollowed
+func CheckRedirect(opts *cli.Options) func(req *http.Request, via []*http.Request) error {
+	if opts.FailIfRedirect {
+		return func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	maxRedirs := opts.MaxRedirs
+	if maxRedirs <= 0 {
+		maxRedirs = DefaultMaxRedirs
+	}
+
+	return func(req *http.Request, via []*http.Request) error {
+		next := req.URL.String()
+		for _, previous := range via {
+			if previous.URL.String() == next {
+				return &errors.RedirectLoopError{URL: next}
+			}
+		}
+
+		if len(via) > maxRedirs {
+			return &errors.TooManyRedirectsError{Max: maxRedirs}
+		}
+
+		return nil
+	}
+}
+
+// CheckRedirectStatus rejects a 3xx response when --fail-if-redirect is set
+func CheckRedirectStatus(resp *http.Response, opts *cli.Options) error {
+	if !opts.FailIfRedirect || resp.StatusCode < 300 || resp.StatusCode > 399 {
+		return nil
+	}
+	return &errors.UnexpectedRedirectError{
+		StatusCode: resp.StatusCode,
+		Location:   resp.Header.Get("Location"),
+	}
+}
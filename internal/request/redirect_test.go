@@ -0,0 +1,139 @@
+package request
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+func TestCheckRedirect_DetectsLoop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			http.Redirect(w, r, "/b", http.StatusFound)
+		case "/b":
+			http.Redirect(w, r, "/a", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{}
+	client := &http.Client{CheckRedirect: CheckRedirect(opts)}
+
+	req, _ := BuildRequest(context.Background(), newTestTarget(t, server.URL+"/a"), opts)
+	_, err := client.Do(req)
+	if err == nil {
+		t.Fatal("Expected redirect loop error")
+	}
+
+	if !strings.Contains(err.Error(), "redirect loop detected") {
+		t.Errorf("Expected loop to be reported, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), server.URL+"/a") {
+		t.Errorf("Expected revisited URL in error, got: %v", err)
+	}
+	if code := errors.MapErrorToExitCode(err); code != errors.ExitTooManyRedirects {
+		t.Errorf("Expected exit code %d, got %d", errors.ExitTooManyRedirects, code)
+	}
+}
+
+func TestCheckRedirect_MaxRedirs(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		// Every hop goes to a new URL so only the limit stops the chain
+		http.Redirect(w, r, "/hop"+strings.Repeat("x", hits), http.StatusFound)
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{MaxRedirs: 3}
+	client := &http.Client{CheckRedirect: CheckRedirect(opts)}
+
+	req, _ := BuildRequest(context.Background(), newTestTarget(t, server.URL), opts)
+	_, err := client.Do(req)
+	if err == nil {
+		t.Fatal("Expected too many redirects error")
+	}
+
+	if !strings.Contains(err.Error(), "maximum (3) redirects followed") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if hits != 4 {
+		t.Errorf("Expected 4 requests (initial + 3 redirects), got %d", hits)
+	}
+	if code := errors.MapErrorToExitCode(err); code != errors.ExitTooManyRedirects {
+		t.Errorf("Expected exit code %d, got %d", errors.ExitTooManyRedirects, code)
+	}
+}
+
+func TestCheckRedirect_FollowsChain(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/start" {
+			http.Redirect(w, r, "/end", http.StatusMovedPermanently)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{}
+	client := &http.Client{CheckRedirect: CheckRedirect(opts)}
+
+	req, _ := BuildRequest(context.Background(), newTestTarget(t, server.URL+"/start"), opts)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.Request.URL.Path != "/end" {
+		t.Errorf("Expected to end at /end, got %s", resp.Request.URL.Path)
+	}
+}
+
+func TestCheckRedirect_FailIfRedirect(t *testing.T) {
+	var followed bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/moved" {
+			followed = true
+			return
+		}
+		http.Redirect(w, r, "/moved", http.StatusMovedPermanently)
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{FailIfRedirect: true}
+	client := &http.Client{CheckRedirect: CheckRedirect(opts)}
+
+	req, _ := BuildRequest(context.Background(), newTestTarget(t, server.URL), opts)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if followed {
+		t.Error("Redirect was followed despite --fail-if-redirect")
+	}
+
+	err = CheckRedirectStatus(resp, opts)
+	if err == nil {
+		t.Fatal("Expected 301 to be rejected")
+	}
+	if !strings.Contains(err.Error(), "301") || !strings.Contains(err.Error(), "/moved") {
+		t.Errorf("Expected status and location in error, got: %v", err)
+	}
+	if code := errors.MapErrorToExitCode(err); code != errors.ExitTooManyRedirects {
+		t.Errorf("Expected exit code %d, got %d", errors.ExitTooManyRedirects, code)
+	}
+
+	// Without the flag a 3xx is not an error
+	if err := CheckRedirectStatus(resp, &cli.Options{}); err != nil {
+		t.Errorf("Unexpected error without --fail-if-redirect: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package request
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+var (
+	// metaRefreshPattern matches <meta http-equiv="refresh" ...> tags
+	metaRefreshPattern = regexp.MustCompile(`(?is)<meta\s[^>]*http-equiv\s*=\s*["']?refresh["']?[^>]*>`)
+
+	// metaContentPattern extracts the content attribute of a meta tag
+	metaContentPattern = regexp.MustCompile(`(?is)content\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+// FollowRefresh follows Refresh-header and HTML meta-refresh redirects from a 200 response
+// Hops count against --max-redirs and the refresh delay is not waited for
+func FollowRefresh(client *http.Client, resp *http.Response, opts *cli.Options) (*http.Response, error) {
+	maxRedirs := opts.MaxRedirs
+	if maxRedirs <= 0 {
+		maxRedirs = DefaultMaxRedirs
+	}
+	visited := map[string]bool{resp.Request.URL.String(): true}
+
+	for hops := 0; ; hops++ {
+		if resp.StatusCode != http.StatusOK {
+			return resp, nil
+		}
+
+		target, err := refreshTarget(resp)
+		if err != nil || target == "" {
+			return resp, err
+		}
+
+		next, err := resp.Request.URL.Parse(target)
+		if err != nil {
+			return resp, nil
+		}
+
+		resp.Body.Close()
+		if visited[next.String()] {
+			return nil, &errors.RedirectLoopError{URL: next.String()}
+		}
+		if hops >= maxRedirs {
+			return nil, &errors.TooManyRedirectsError{Max: maxRedirs}
+		}
+		visited[next.String()] = true
+
+		// Refresh navigates like a browser: a bodiless GET with the original headers
+		req, err := http.NewRequestWithContext(resp.Request.Context(), http.MethodGet, next.String(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create refresh request: %w", err)
+		}
+		req.Header = resp.Request.Header.Clone()
+		for _, name := range []string{"Content-Type", "Content-Length", "Content-Encoding"} {
+			req.Header.Del(name)
+		}
+
+		if resp, err = client.Do(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// refreshTarget returns the URL named by the Refresh header or, for HTML, a meta-refresh tag
+// The body is buffered and restored so it can still be written out
+func refreshTarget(resp *http.Response) (string, error) {
+	if target, ok := parseRefresh(resp.Header.Get("Refresh")); ok {
+		return target, nil
+	}
+
+	if !strings.Contains(strings.ToLower(resp.Header.Get("Content-Type")), "html") {
+		return "", nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+
+	tag := metaRefreshPattern.Find(body)
+	if tag == nil {
+		return "", nil
+	}
+	content := metaContentPattern.FindSubmatch(tag)
+	if content == nil {
+		return "", nil
+	}
+	target, _ := parseRefresh(string(content[1]) + string(content[2]))
+	return target, nil
+}
+
+// parseRefresh parses a refresh value such as "0; url=/next" and returns the URL
+func parseRefresh(value string) (string, bool) {
+	_, rest, found := strings.Cut(value, ";")
+	if !found {
+		_, rest, found = strings.Cut(value, ",")
+	}
+	if !found {
+		return "", false
+	}
+
+	rest = strings.TrimSpace(rest)
+	if len(rest) >= 4 && strings.EqualFold(rest[:4], "url=") {
+		rest = strings.TrimSpace(rest[4:])
+	}
+	rest = strings.Trim(rest, `"'`)
+
+	return rest, rest != ""
+}
@@ -0,0 +1,139 @@
+package request
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aleister1102/purl/internal/cli"
+)
+
+func TestParseRefresh(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   string
+		wantOK bool
+	}{
+		{"0; url=/next", "/next", true},
+		{"5;URL='http://example.com/'", "http://example.com/", true},
+		{"0, url=/comma", "/comma", true},
+		{"3; /bare", "/bare", true},
+		{"10", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRefresh(tt.value)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRefresh(%q) = (%q, %v), want (%q, %v)", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestFollowRefresh_Header(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/start":
+			w.Header().Set("Refresh", "0; url=/final")
+			w.Write([]byte("redirecting"))
+		case "/final":
+			w.Write([]byte("arrived"))
+		}
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{FollowMetaRefresh: true}
+	req, _ := BuildRequest(context.Background(), newTestTarget(t, server.URL+"/start"), opts)
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	resp, err = FollowRefresh(server.Client(), resp, opts)
+	if err != nil {
+		t.Fatalf("FollowRefresh failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "arrived" {
+		t.Errorf("Expected final body %q, got %q", "arrived", string(body))
+	}
+	if resp.Request.URL.Path != "/final" {
+		t.Errorf("Expected final URL /final, got %s", resp.Request.URL.Path)
+	}
+}
+
+func TestFollowRefresh_MetaTag(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/start":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head><META HTTP-EQUIV="Refresh" CONTENT="0; URL=/final"></head></html>`))
+		case "/final":
+			w.Write([]byte("arrived"))
+		}
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{FollowMetaRefresh: true}
+	req, _ := BuildRequest(context.Background(), newTestTarget(t, server.URL+"/start"), opts)
+	resp, _ := server.Client().Do(req)
+
+	resp, err := FollowRefresh(server.Client(), resp, opts)
+	if err != nil {
+		t.Fatalf("FollowRefresh failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Request.URL.Path != "/final" {
+		t.Errorf("Expected final URL /final, got %s", resp.Request.URL.Path)
+	}
+}
+
+func TestFollowRefresh_KeepsBodyWithoutRefresh(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html>plain</html>"))
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{FollowMetaRefresh: true}
+	req, _ := BuildRequest(context.Background(), newTestTarget(t, server.URL), opts)
+	resp, _ := server.Client().Do(req)
+
+	resp, err := FollowRefresh(server.Client(), resp, opts)
+	if err != nil {
+		t.Fatalf("FollowRefresh failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// The scanned body is still readable for output
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "<html>plain</html>" {
+		t.Errorf("Expected body preserved, got %q", string(body))
+	}
+}
+
+func TestFollowRefresh_Loop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/a" {
+			w.Header().Set("Refresh", "0; url=/b")
+		} else {
+			w.Header().Set("Refresh", "0; url=/a")
+		}
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{FollowMetaRefresh: true}
+	req, _ := BuildRequest(context.Background(), newTestTarget(t, server.URL+"/a"), opts)
+	resp, _ := server.Client().Do(req)
+
+	_, err := FollowRefresh(server.Client(), resp, opts)
+	if err == nil || !strings.Contains(err.Error(), "redirect loop detected") {
+		t.Errorf("Expected redirect loop error, got %v", err)
+	}
+}
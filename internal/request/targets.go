@@ -0,0 +1,62 @@
+package request
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
+	"github.com/aleister1102/purl/internal/target"
+)
+
+// DefaultMaxTotalTargets is the request cap used when --max-total-targets is not set
+const DefaultMaxTotalTargets = 10000
+
+// CheckTargetCount fails before any request fires when the expanded chain
+// of requests, counting --repeat, is longer than --max-total-targets
+// listed holds the number of --targets-file targets each request runs against
+func CheckTargetCount(opts *cli.Options, listed map[*cli.Options]int) error {
+	limit := opts.MaxTotalTargets
+	if limit <= 0 {
+		limit = DefaultMaxTotalTargets
+	}
+
+	count := 0
+	for current := opts; current != nil; current = current.Next {
+		count += max(current.Repeat, 1) * max(listed[current], 1)
+	}
+	if count > limit {
+		return &errors.TooManyTargetsError{Count: count, Max: limit}
+	}
+	return nil
+}
+
+// ReadTargetsFile reads the targets of --targets-file, from stdin when the path is "-"
+// With --weighted-sample, that many targets are drawn from the file by weight
+func ReadTargetsFile(opts *cli.Options, stdin io.Reader) ([]*target.ParsedTarget, error) {
+	var data []byte
+	var err error
+	if opts.TargetsFile == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(opts.TargetsFile)
+	}
+	if err != nil {
+		return nil, &errors.FileReadError{Path: opts.TargetsFile, Cause: err}
+	}
+
+	targets, err := target.ReadTargets(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("targets file %s has no targets", opts.TargetsFile)
+	}
+
+	if opts.WeightedSample > 0 {
+		targets = target.WeightedSample(targets, opts.WeightedSample, opts.SampleSeed)
+	}
+	return targets, nil
+}
@@ -0,0 +1,118 @@
+package request
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+// chainOf builds a --next chain of n requests capped at max
+func chainOf(n, max int) *cli.Options {
+	var head *cli.Options
+	for i := 0; i < n; i++ {
+		head = &cli.Options{Target: "localhost:8080", Next: head}
+	}
+	head.MaxTotalTargets = max
+	return head
+}
+
+func TestCheckTargetCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		count   int
+		max     int
+		wantErr bool
+	}{
+		{"single request under default cap", 1, 0, false},
+		{"at the cap", 3, 3, false},
+		{"over the cap", 4, 3, true},
+		{"over the default cap", DefaultMaxTotalTargets + 1, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckTargetCount(chainOf(tt.count, tt.max), nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckTargetCount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && errors.MapErrorToExitCode(err) != errors.ExitUnknownFlag {
+				t.Errorf("Expected exit code %d, got %d", errors.ExitUnknownFlag, errors.MapErrorToExitCode(err))
+			}
+		})
+	}
+}
+
+func TestCheckTargetCount_TargetsFile(t *testing.T) {
+	opts := &cli.Options{MaxTotalTargets: 10, Repeat: 2}
+	if err := CheckTargetCount(opts, map[*cli.Options]int{opts: 5}); err != nil {
+		t.Errorf("Expected 5 targets x 2 repeats to fit a cap of 10, got %v", err)
+	}
+	if err := CheckTargetCount(opts, map[*cli.Options]int{opts: 6}); err == nil {
+		t.Error("Expected 6 targets x 2 repeats to exceed a cap of 10")
+	}
+}
+
+func TestReadTargetsFile(t *testing.T) {
+	list := "# hosts\nexample.com\n\nhttps://secure.example.com 3\nplain.example.com|proto=http\n"
+	path := filepath.Join(t.TempDir(), "targets.txt")
+	if err := os.WriteFile(path, []byte(list), 0644); err != nil {
+		t.Fatalf("failed to write targets file: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name  string
+		path  string
+		stdin string
+	}{
+		{"file", path, ""},
+		{"stdin", "-", list},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, err := ReadTargetsFile(&cli.Options{TargetsFile: tt.path}, strings.NewReader(tt.stdin))
+			if err != nil {
+				t.Fatalf("ReadTargetsFile() error = %v", err)
+			}
+			var got []string
+			for _, parsedTarget := range targets {
+				got = append(got, parsedTarget.OriginalInput+"/"+parsedTarget.Proto)
+			}
+			want := "example.com/,https://secure.example.com/https,plain.example.com/http"
+			if strings.Join(got, ",") != want {
+				t.Errorf("targets = %v, want %s", got, want)
+			}
+		})
+	}
+
+	t.Run("weighted sample", func(t *testing.T) {
+		opts := &cli.Options{TargetsFile: path, WeightedSample: 7, SampleSeed: 1}
+		targets, err := ReadTargetsFile(opts, nil)
+		if err != nil {
+			t.Fatalf("ReadTargetsFile() error = %v", err)
+		}
+		if len(targets) != 7 {
+			t.Errorf("Expected 7 sampled targets, got %d", len(targets))
+		}
+	})
+
+	t.Run("no targets", func(t *testing.T) {
+		if _, err := ReadTargetsFile(&cli.Options{TargetsFile: "-"}, strings.NewReader("# empty\n")); err == nil {
+			t.Error("Expected an error for a list without targets")
+		}
+	})
+
+	for name, path := range map[string]string{
+		"missing file": filepath.Join(t.TempDir(), "missing"),
+		"directory":    t.TempDir(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadTargetsFile(&cli.Options{TargetsFile: path}, nil)
+			if _, ok := err.(*errors.FileReadError); !ok {
+				t.Errorf("Expected FileReadError, got %T: %v", err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package request
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aleister1102/purl/internal/cli"
+)
+
+// Vars holds values extracted from earlier responses, keyed by name
+type Vars map[string]string
+
+// Extract evaluates each spec against a JSON response body and stores the results in vars
+func (v Vars) Extract(body []byte, specs []cli.ExtractSpec) error {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("failed to parse response as JSON: %w", err)
+	}
+
+	for _, spec := range specs {
+		value, err := lookupJSONPath(doc, spec.Path)
+		if err != nil {
+			return fmt.Errorf("failed to extract %s: %w", spec.Name, err)
+		}
+		v[spec.Name] = jsonValueString(value)
+	}
+
+	return nil
+}
+
+// Apply returns a copy of opts with {{name}} placeholders in headers and data
+// replaced by the values named in opts.Use
+func (v Vars) Apply(opts *cli.Options) (*cli.Options, error) {
+	if len(opts.Use) == 0 {
+		return opts, nil
+	}
+
+	var pairs []string
+	for _, name := range opts.Use {
+		value, ok := v[name]
+		if !ok {
+			return nil, fmt.Errorf("variable %q was not extracted by a previous request", name)
+		}
+		pairs = append(pairs, "{{"+name+"}}", value)
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	clone := *opts
+	clone.Headers = make([]string, len(opts.Headers))
+	for i, header := range opts.Headers {
+		clone.Headers[i] = replacer.Replace(header)
+	}
+	clone.Data = replacer.Replace(opts.Data)
+	clone.DataRaw = replacer.Replace(opts.DataRaw)
+
+	return &clone, nil
+}
+
+// lookupJSONPath resolves a simple JSONPath such as $.data.items[0].id
+func lookupJSONPath(doc interface{}, path string) (interface{}, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, fmt.Errorf("JSONPath must start with $: %q", path)
+	}
+
+	current := doc
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			// Object member
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			rest = rest[end:]
+
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: %q is not an object member", path, key)
+			}
+			if current, ok = object[key]; !ok {
+				return nil, fmt.Errorf("%s: key %q not found", path, key)
+			}
+
+		case '[':
+			// Array index
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("%s: unterminated index", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid index %q", path, rest[1:end])
+			}
+			rest = rest[end+1:]
+
+			array, ok := current.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: index %d applied to a non-array", path, index)
+			}
+			if index < 0 || index >= len(array) {
+				return nil, fmt.Errorf("%s: index %d out of range", path, index)
+			}
+			current = array[index]
+
+		default:
+			return nil, fmt.Errorf("%s: unexpected character %q", path, rest[0])
+		}
+	}
+
+	return current, nil
+}
+
+// jsonValueString renders an extracted value: strings as-is, everything else as JSON
+func jsonValueString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
@@ -0,0 +1,80 @@
+package request
+
+import (
+	"testing"
+
+	"github.com/aleister1102/purl/internal/cli"
+)
+
+func TestVarsExtract(t *testing.T) {
+	body := []byte(`{"data":{"token":"abc123","items":[{"id":7},{"id":8}],"ok":true}}`)
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"$.data.token", "abc123", false},
+		{"$.data.items[1].id", "8", false},
+		{"$.data.ok", "true", false},
+		{"$.data.items[0]", `{"id":7}`, false},
+		{"$.data.missing", "", true},
+		{"$.data.items[5].id", "", true},
+		{"$.data.token[0]", "", true},
+	}
+
+	for _, tt := range tests {
+		vars := Vars{}
+		err := vars.Extract(body, []cli.ExtractSpec{{Name: "v", Path: tt.path}})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Extract(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && vars["v"] != tt.want {
+			t.Errorf("Extract(%q) = %q, want %q", tt.path, vars["v"], tt.want)
+		}
+	}
+}
+
+func TestVarsExtract_InvalidJSON(t *testing.T) {
+	vars := Vars{}
+	if err := vars.Extract([]byte("<html>"), []cli.ExtractSpec{{Name: "v", Path: "$.a"}}); err == nil {
+		t.Error("Expected error for non-JSON body")
+	}
+}
+
+func TestVarsApply(t *testing.T) {
+	vars := Vars{"token": "abc123", "id": "7"}
+	opts := &cli.Options{
+		Headers: []string{"Authorization: Bearer {{token}}", "X-Other: {{id}}"},
+		Data:    `{"id":{{id}}}`,
+		Use:     []string{"token", "id"},
+	}
+
+	applied, err := vars.Apply(opts)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	if applied.Headers[0] != "Authorization: Bearer abc123" {
+		t.Errorf("header = %q", applied.Headers[0])
+	}
+	if applied.Headers[1] != "X-Other: 7" {
+		t.Errorf("header = %q", applied.Headers[1])
+	}
+	if applied.Data != `{"id":7}` {
+		t.Errorf("data = %q", applied.Data)
+	}
+
+	// The original options keep their placeholders
+	if opts.Headers[0] != "Authorization: Bearer {{token}}" {
+		t.Errorf("Apply modified the original options: %q", opts.Headers[0])
+	}
+}
+
+func TestVarsApply_UnknownVariable(t *testing.T) {
+	opts := &cli.Options{Use: []string{"token"}}
+	if _, err := (Vars{}).Apply(opts); err == nil {
+		t.Error("Expected error for a variable that was never extracted")
+	}
+}
@@ -1,9 +1,13 @@
 package target
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"net"
+	"net/netip"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/aleister1102/purl/internal/errors"
@@ -15,6 +19,9 @@ type ParsedTarget struct {
 	IsIP             bool
 	HasExplicitProto bool
 	OriginalInput    string
+	Proto            string        // per-target protocol override ("auto", "http", "https"), empty uses --proto
+	Weight           int           // relative weight for --weighted-sample, 1 unless the targets file sets one
+	UserInfo         *url.Userinfo // user:password from the target, sent as Basic auth unless -u is given
 }
 
 // ParseTarget normalizes various input formats to a URL
@@ -22,7 +29,18 @@ type ParsedTarget struct {
 // - IP:PORT/path (e.g., 192.168.1.1:8080/api)
 // - host:PORT (e.g., example.com:443)
 // - full URL (e.g., http://example.com:8080/path)
+// Scheme-less input is given an http:// URL until protocol detection runs
 func ParseTarget(input string) (*ParsedTarget, error) {
+	return ParseTargetWithScheme(input, "http")
+}
+
+// ParseTargetWithScheme is like ParseTarget but uses defaultScheme for scheme-less input
+// An explicit scheme in the input always wins; an empty defaultScheme means http
+func ParseTargetWithScheme(input, defaultScheme string) (*ParsedTarget, error) {
+	if defaultScheme == "" {
+		defaultScheme = "http"
+	}
+
 	if input == "" {
 		return nil, &errors.URLParseError{
 			Input:   input,
@@ -37,7 +55,7 @@ func ParseTarget(input string) (*ParsedTarget, error) {
 	// Check if input already has a scheme (http://, https://, etc.)
 	if strings.Contains(input, "://") {
 		result.HasExplicitProto = true
-		parsedURL, err := url.Parse(input)
+		parsedURL, err := url.Parse(escapeZone(input))
 		if err != nil {
 			return nil, &errors.URLParseError{
 				Input:   input,
@@ -52,6 +70,16 @@ func ParseTarget(input string) (*ParsedTarget, error) {
 				Message: "URL must contain a host",
 			}
 		}
+		if err := validatePort(parsedURL.Port()); err != nil {
+			return nil, &errors.URLParseError{
+				Input:   input,
+				Message: err.Error(),
+			}
+		}
+
+		// Keep credentials out of the URL so they are not printed with it
+		result.UserInfo = parsedURL.User
+		parsedURL.User = nil
 
 		result.URL = parsedURL
 		result.IsIP = isIPAddress(parsedURL.Hostname())
@@ -73,12 +101,44 @@ func ParseTarget(input string) (*ParsedTarget, error) {
 		path = "/"
 	}
 
-	// Parse the host:port part
-	host, port, err := net.SplitHostPort(hostPort)
-	if err != nil {
-		// No port specified, treat entire hostPort as host
-		host = hostPort
-		port = ""
+	// Split off user:password@ credentials
+	if at := strings.LastIndex(hostPort, "@"); at != -1 {
+		userInfo, err := parseUserInfo(hostPort[:at])
+		if err != nil {
+			return nil, &errors.URLParseError{
+				Input:   input,
+				Message: fmt.Sprintf("invalid credentials: %v", err),
+			}
+		}
+		result.UserInfo = userInfo
+		hostPort = hostPort[at+1:]
+	}
+
+	// Parse the host:port part; a bracketed IPv6 literal is split explicitly
+	var host, port string
+	if strings.HasPrefix(hostPort, "[") {
+		var err error
+		host, port, err = splitBracketedHost(hostPort)
+		if err != nil {
+			return nil, &errors.URLParseError{
+				Input:   input,
+				Message: err.Error(),
+			}
+		}
+	} else {
+		var err error
+		host, port, err = net.SplitHostPort(hostPort)
+		if err != nil {
+			// No port specified, treat entire hostPort as host
+			host = hostPort
+			port = ""
+		}
+		if err := validatePort(port); err != nil {
+			return nil, &errors.URLParseError{
+				Input:   input,
+				Message: err.Error(),
+			}
+		}
 	}
 
 	// Validate host is not empty
@@ -92,13 +152,8 @@ func ParseTarget(input string) (*ParsedTarget, error) {
 	// Determine if this is an IP address
 	result.IsIP = isIPAddress(host)
 
-	// Construct the URL with http scheme (protocol detection happens later)
-	var urlStr string
-	if port != "" {
-		urlStr = fmt.Sprintf("http://%s:%s%s", host, port, path)
-	} else {
-		urlStr = fmt.Sprintf("http://%s%s", host, path)
-	}
+	// Construct the URL with the default scheme (protocol detection may replace it later)
+	urlStr := fmt.Sprintf("%s://%s%s", defaultScheme, formatURLHost(host, port), path)
 
 	parsedURL, err := url.Parse(urlStr)
 	if err != nil {
@@ -113,9 +168,195 @@ func ParseTarget(input string) (*ParsedTarget, error) {
 	return result, nil
 }
 
-// isIPAddress checks if a string is a valid IP address (v4 or v6)
+// ParseTargetLine parses a single line from a targets file
+// Supports an inline protocol hint (e.g., example.com|proto=https);
+// a URL with an explicit http:// or https:// scheme forces that protocol
+// A trailing whitespace-separated integer sets the target's weight (e.g., example.com 3)
+func ParseTargetLine(line string) (*ParsedTarget, error) {
+	entry, weight, err := splitWeight(strings.TrimSpace(line))
+	if err != nil {
+		return nil, err
+	}
+
+	input, hint, hasHint := strings.Cut(entry, "|")
+	input = strings.TrimSpace(input)
+
+	result, err := ParseTarget(input)
+	if err != nil {
+		return nil, err
+	}
+	result.Weight = weight
+
+	if result.HasExplicitProto && (result.URL.Scheme == "http" || result.URL.Scheme == "https") {
+		result.Proto = result.URL.Scheme
+	}
+
+	if hasHint {
+		key, value, _ := strings.Cut(strings.TrimSpace(hint), "=")
+		if strings.TrimSpace(key) != "proto" {
+			return nil, &errors.URLParseError{
+				Input:   line,
+				Message: fmt.Sprintf("unknown target hint: %s", hint),
+			}
+		}
+
+		proto := strings.ToLower(strings.TrimSpace(value))
+		if proto != "auto" && proto != "http" && proto != "https" {
+			return nil, &errors.URLParseError{
+				Input:   line,
+				Message: fmt.Sprintf("invalid protocol hint: %s (must be auto, http, or https)", value),
+			}
+		}
+		result.Proto = proto
+	}
+
+	return result, nil
+}
+
+// splitWeight separates an optional trailing weight from a targets file line
+// Lines without a trailing number have weight 1
+func splitWeight(line string) (string, int, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return line, 1, nil
+	}
+
+	last := fields[len(fields)-1]
+	weight, err := strconv.Atoi(last)
+	if err != nil {
+		return line, 1, nil
+	}
+	if weight < 1 {
+		return "", 0, &errors.URLParseError{
+			Input:   line,
+			Message: fmt.Sprintf("invalid target weight: %s (must be a positive integer)", last),
+		}
+	}
+	return strings.TrimSpace(strings.TrimSuffix(line, last)), weight, nil
+}
+
+// ReplacePath overrides the target's path (--path), keeping its host and query
+func ReplacePath(parsedTarget *ParsedTarget, path string) {
+	parsedTarget.URL.Path = path
+	parsedTarget.URL.RawPath = ""
+}
+
+// ReadTargets reads one target per line from r
+// Blank lines and lines starting with # are skipped
+func ReadTargets(r io.Reader) ([]*ParsedTarget, error) {
+	var targets []*ParsedTarget
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parsedTarget, err := ParseTargetLine(line)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, parsedTarget)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read targets: %w", err)
+	}
+
+	return targets, nil
+}
+
+// isIPAddress checks if a string is a valid IP address (v4 or v6, with an optional zone)
 func isIPAddress(host string) bool {
 	// Remove brackets for IPv6 addresses
 	testHost := strings.TrimPrefix(strings.TrimSuffix(host, "]"), "[")
-	return net.ParseIP(testHost) != nil
+	_, err := netip.ParseAddr(testHost)
+	return err == nil
+}
+
+// formatURLHost builds the host part of a URL, bracketing IPv6 addresses
+// and percent-encoding a zone identifier (fe80::1%eth0 → [fe80::1%25eth0])
+func formatURLHost(host, port string) string {
+	host = strings.TrimPrefix(strings.TrimSuffix(host, "]"), "[")
+	if strings.Contains(host, ":") {
+		host = "[" + strings.Replace(host, "%", "%25", 1) + "]"
+	}
+	if port != "" {
+		return host + ":" + port
+	}
+	return host
+}
+
+// splitBracketedHost splits "[v6]" or "[v6]:port" into the address and port
+// The brackets must enclose an IPv6 address and be followed by nothing or a port
+func splitBracketedHost(hostPort string) (host, port string, err error) {
+	end := strings.Index(hostPort, "]")
+	if end == -1 {
+		return "", "", fmt.Errorf("missing ']' in IPv6 address")
+	}
+
+	host = hostPort[1:end]
+	addr, parseErr := netip.ParseAddr(host)
+	if parseErr != nil || !addr.Is6() {
+		return "", "", fmt.Errorf("invalid IPv6 address in brackets: %s", host)
+	}
+
+	rest := hostPort[end+1:]
+	if rest == "" {
+		return host, "", nil
+	}
+	port, ok := strings.CutPrefix(rest, ":")
+	if !ok {
+		return "", "", fmt.Errorf("unexpected %q after IPv6 address", rest)
+	}
+	if err := validatePort(port); err != nil {
+		return "", "", err
+	}
+	return host, port, nil
+}
+
+// validatePort checks that a port, when present, is a decimal number in 1-65535
+func validatePort(port string) error {
+	if port == "" {
+		return nil
+	}
+	if n, err := strconv.ParseUint(port, 10, 16); err != nil || n == 0 {
+		return fmt.Errorf("invalid port: %q (must be 1-65535)", port)
+	}
+	return nil
+}
+
+// escapeZone percent-encodes a raw zone separator inside a bracketed IPv6 host
+// so url.Parse accepts it ([fe80::1%eth0] → [fe80::1%25eth0])
+func escapeZone(input string) string {
+	start := strings.Index(input, "[")
+	end := strings.Index(input, "]")
+	if start < 0 || end < start {
+		return input
+	}
+
+	zone := strings.Index(input[start:end], "%")
+	if zone < 0 || strings.HasPrefix(input[start+zone:end], "%25") {
+		return input
+	}
+	zone += start
+	return input[:zone] + "%25" + input[zone+1:]
+}
+
+// parseUserInfo decodes a percent-encoded "user[:password]" prefix of a scheme-less target
+func parseUserInfo(raw string) (*url.Userinfo, error) {
+	name, password, hasPassword := strings.Cut(raw, ":")
+	name, err := url.PathUnescape(name)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPassword {
+		return url.User(name), nil
+	}
+	password, err = url.PathUnescape(password)
+	if err != nil {
+		return nil, err
+	}
+	return url.UserPassword(name, password), nil
 }
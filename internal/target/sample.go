@@ -0,0 +1,35 @@
+package target
+
+import (
+	"math/rand/v2"
+	"sort"
+	"time"
+)
+
+// WeightedSample draws n targets with replacement, each with probability
+// proportional to its Weight (values below 1 count as 1)
+// A zero seed uses the current time; the same seed reproduces the same sample
+func WeightedSample(targets []*ParsedTarget, n int, seed int64) []*ParsedTarget {
+	if len(targets) == 0 || n <= 0 {
+		return nil
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewPCG(uint64(seed), uint64(seed)))
+
+	// Cumulative weights turn each draw into a binary search
+	cumulative := make([]int, len(targets))
+	total := 0
+	for i, t := range targets {
+		total += max(t.Weight, 1)
+		cumulative[i] = total
+	}
+
+	sample := make([]*ParsedTarget, n)
+	for i := range sample {
+		pick := rng.IntN(total)
+		sample[i] = targets[sort.SearchInts(cumulative, pick+1)]
+	}
+	return sample
+}
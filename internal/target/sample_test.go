@@ -0,0 +1,76 @@
+package target
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWeightedSample_Proportions(t *testing.T) {
+	targets, err := ReadTargets(strings.NewReader("light.example.com\nheavy.example.com 8\nmedium.example.com|proto=https 3\n"))
+	if err != nil {
+		t.Fatalf("ReadTargets failed: %v", err)
+	}
+
+	counts := map[string]int{}
+	for _, picked := range WeightedSample(targets, 12000, 42) {
+		counts[picked.URL.Hostname()]++
+	}
+
+	light, medium, heavy := counts["light.example.com"], counts["medium.example.com"], counts["heavy.example.com"]
+	if !(heavy > medium && medium > light && light > 0) {
+		t.Errorf("Expected counts ordered by weight, got light=%d medium=%d heavy=%d", light, medium, heavy)
+	}
+	// Expected shares are 1/12, 3/12 and 8/12 of the draws
+	if heavy < 7000 || heavy > 9000 {
+		t.Errorf("Expected about 8000 heavy picks, got %d", heavy)
+	}
+}
+
+func TestWeightedSample_Deterministic(t *testing.T) {
+	targets, _ := ReadTargets(strings.NewReader("a.example.com 2\nb.example.com 5\nc.example.com\n"))
+
+	first := WeightedSample(targets, 50, 7)
+	second := WeightedSample(targets, 50, 7)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("Draw %d differs between runs with the same seed", i)
+		}
+	}
+
+	if got := WeightedSample(targets, 0, 7); got != nil {
+		t.Errorf("Expected no targets for n=0, got %d", len(got))
+	}
+}
+
+func TestParseTargetLine_Weight(t *testing.T) {
+	tests := []struct {
+		line       string
+		wantHost   string
+		wantWeight int
+		wantProto  string
+		wantErr    bool
+	}{
+		{"example.com", "example.com", 1, "", false},
+		{"example.com 3", "example.com", 3, "", false},
+		{"example.com:8443|proto=https  10", "example.com", 10, "https", false},
+		{"https://example.com/health 2", "example.com", 2, "https", false},
+		{"example.com 0", "", 0, "", true},
+		{"example.com heavy", "", 0, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			parsed, err := ParseTargetLine(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTargetLine(%q) error = %v, wantErr %v", tt.line, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if parsed.URL.Hostname() != tt.wantHost || parsed.Weight != tt.wantWeight || parsed.Proto != tt.wantProto {
+				t.Errorf("ParseTargetLine(%q) = host %q weight %d proto %q, want %q %d %q",
+					tt.line, parsed.URL.Hostname(), parsed.Weight, parsed.Proto, tt.wantHost, tt.wantWeight, tt.wantProto)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package transport
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/target"
+)
+
+// Cache reuses one transport per host and configuration so repeated requests
+// share keep-alive connections instead of dialing each time
+type Cache struct {
+	mu         sync.Mutex
+	sessions   tls.ClientSessionCache
+	transports map[cacheKey]*http.Transport
+	wrapConn   func(net.Conn) net.Conn // nil unless WrapConns was called
+}
+
+// cacheKey identifies the options a transport was built from and the host it serves
+// IsIP is part of the key because it changes certificate verification
+type cacheKey struct {
+	settings string
+	host     string
+	isIP     bool
+}
+
+// transportSettings holds the options NewTransport reads, so requests whose options
+// were copied (--use, --next, --targets-file) still share a transport
+// Keep it in step with the fields NewTransport and its dialers use
+type transportSettings struct {
+	Timeout, ConnectTimeout, TLSHandshakeTimeout time.Duration
+	ConnectRetryCount                            int
+	ConnectRetryInterval                         time.Duration
+	HTTPVersion                                  string
+	H2InitialWindow                              int
+	TCPNagle, NoHostHeader                       bool
+	DNSServers                                   []string
+	DNSStrict                                    bool
+	Resolve, ConnectTo                           []string
+	Proxy                                        string
+	ProxyChain                                   []string
+	Insecure, StrictSSL, SkipHostnameVerify      bool
+	CACerts                                      []string
+	Cert, Key                                    string
+	TLSMin, TLSMax, Ciphers                      string
+	FailOnWeakTLS                                bool
+}
+
+// fingerprint encodes the transport settings of opts as a comparable key
+func fingerprint(opts *cli.Options) string {
+	return fmt.Sprintf("%#v", transportSettings{
+		Timeout:              opts.Timeout,
+		ConnectTimeout:       opts.ConnectTimeout,
+		TLSHandshakeTimeout:  opts.TLSHandshakeTimeout,
+		ConnectRetryCount:    opts.ConnectRetryCount,
+		ConnectRetryInterval: opts.ConnectRetryInterval,
+		HTTPVersion:          opts.HTTPVersion,
+		H2InitialWindow:      opts.H2InitialWindow,
+		TCPNagle:             opts.TCPNagle,
+		NoHostHeader:         opts.NoHostHeader,
+		DNSServers:           opts.DNSServers,
+		DNSStrict:            opts.DNSStrict,
+		Resolve:              opts.Resolve,
+		ConnectTo:            opts.ConnectTo,
+		Proxy:                opts.Proxy,
+		ProxyChain:           opts.ProxyChain,
+		Insecure:             opts.Insecure,
+		StrictSSL:            opts.StrictSSL,
+		SkipHostnameVerify:   opts.SkipHostnameVerify,
+		CACerts:              opts.CACerts,
+		Cert:                 opts.Cert,
+		Key:                  opts.Key,
+		TLSMin:               opts.TLSMin,
+		TLSMax:               opts.TLSMax,
+		Ciphers:              opts.Ciphers,
+		FailOnWeakTLS:        opts.FailOnWeakTLS,
+	})
+}
+
+// NewCache creates an empty transport cache
+// sessions, when non-nil, is shared by every transport for TLS session resumption
+func NewCache(sessions tls.ClientSessionCache) *Cache {
+	return &Cache{
+		sessions:   sessions,
+		transports: make(map[cacheKey]*http.Transport),
+	}
+}
+
+// Get returns the transport for parsedTarget under opts, creating it with NewTransport on first use
+// Requests to the same host whose options agree on the transport settings share the transport and its connections
+// --tls-resumption gets a new transport each time, since resuming needs a fresh handshake
+func (c *Cache) Get(opts *cli.Options, parsedTarget *target.ParsedTarget) (*http.Transport, error) {
+	if opts.TLSResumption {
+		return c.newTransport(opts, parsedTarget)
+	}
+
+	key := cacheKey{settings: fingerprint(opts), host: parsedTarget.URL.Host, isIP: parsedTarget.IsIP}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if tr, ok := c.transports[key]; ok {
+		return tr, nil
+	}
+
+	tr, err := c.newTransport(opts, parsedTarget)
+	if err != nil {
+		return nil, err
+	}
+	c.transports[key] = tr
+	return tr, nil
+}
+
+// WrapConns passes the connections of every transport created afterwards through wrap
+// HTTPS connections are wrapped after the TLS handshake, so wrap sees plaintext
+func (c *Cache) WrapConns(wrap func(net.Conn) net.Conn) {
+	c.wrapConn = wrap
+}
+
+// newTransport builds a transport sharing the cache's TLS session cache
+func (c *Cache) newTransport(opts *cli.Options, parsedTarget *target.ParsedTarget) (*http.Transport, error) {
+	tr, err := NewTransport(opts, parsedTarget)
+	if err != nil {
+		return nil, err
+	}
+	if c.sessions != nil {
+		tr.TLSClientConfig.ClientSessionCache = c.sessions
+	}
+	if c.wrapConn != nil {
+		wrapConns(tr, c.wrapConn)
+	}
+	return tr, nil
+}
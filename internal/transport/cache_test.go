@@ -0,0 +1,87 @@
+package transport
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/target"
+)
+
+func TestCache_ReusesConnectionForSameHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	parsedTarget, err := target.ParseTarget(server.URL)
+	if err != nil {
+		t.Fatalf("ParseTarget failed: %v", err)
+	}
+
+	opts := &cli.Options{}
+	cache := NewCache(nil)
+
+	var reused []bool
+	for i := 0; i < 2; i++ {
+		tr, err := cache.Get(opts, parsedTarget)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) { reused = append(reused, info.Reused) },
+		}))
+
+		resp, err := (&http.Client{Transport: tr}).Do(req)
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i+1, err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	if len(reused) != 2 || reused[0] || !reused[1] {
+		t.Errorf("Expected a new connection then a reused one, got reused=%v", reused)
+	}
+}
+
+func TestCache_Keys(t *testing.T) {
+	first, _ := target.ParseTarget("http://127.0.0.1:8080/")
+	second, _ := target.ParseTarget("http://127.0.0.1:9090/")
+	opts := &cli.Options{}
+	cache := NewCache(nil)
+
+	a, _ := cache.Get(opts, first)
+	b, _ := cache.Get(opts, first)
+	if a != b {
+		t.Error("Expected the same transport for the same host and options")
+	}
+
+	if c, _ := cache.Get(opts, second); c == a {
+		t.Error("Expected a different transport for a different host")
+	}
+	if d, _ := cache.Get(&cli.Options{Insecure: true}, first); d == a {
+		t.Error("Expected a different transport for different options")
+	}
+	if d, _ := cache.Get(&cli.Options{Resolve: []string{"127.0.0.1:8080:127.0.0.2"}}, first); d == a {
+		t.Error("Expected a different transport for different --resolve entries")
+	}
+
+	// A copy of the options, as --use makes for every request, shares the transport
+	clone := *opts
+	clone.Headers = []string{"Authorization: Bearer token"}
+	if d, _ := cache.Get(&clone, first); d != a {
+		t.Error("Expected a copy of the options with the same transport settings to share the transport")
+	}
+	resumption := &cli.Options{TLSResumption: true}
+	e, _ := cache.Get(resumption, first)
+	f, _ := cache.Get(resumption, first)
+	if e == f {
+		t.Error("Expected a new transport per request under --tls-resumption")
+	}
+}
@@ -0,0 +1,91 @@
+package transport
+
+import (
+	"crypto/tls"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// cipherSuites maps cipher suite IDs to their IANA names, extended with every
+// suite crypto/tls implements so names resolve the same way in both directions
+var cipherSuites = func() map[uint16]string {
+	names := map[uint16]string{
+		0x0005: "TLS_RSA_WITH_RC4_128_SHA",
+		0x000a: "TLS_RSA_WITH_3DES_EDE_CBC_SHA",
+		0x002f: "TLS_RSA_WITH_AES_128_CBC_SHA",
+		0x0035: "TLS_RSA_WITH_AES_256_CBC_SHA",
+		0x003c: "TLS_RSA_WITH_AES_128_CBC_SHA256",
+		0x003d: "TLS_RSA_WITH_AES_256_CBC_SHA256",
+		0x009c: "TLS_RSA_WITH_AES_128_GCM_SHA256",
+		0x009d: "TLS_RSA_WITH_AES_256_GCM_SHA384",
+		0x1301: "TLS_AES_128_GCM_SHA256",
+		0x1302: "TLS_AES_256_GCM_SHA384",
+		0x1303: "TLS_CHACHA20_POLY1305_SHA256",
+		0xc007: "TLS_ECDHE_ECDSA_WITH_RC4_128_SHA",
+		0xc009: "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA",
+		0xc00a: "TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA",
+		0xc011: "TLS_ECDHE_RSA_WITH_RC4_128_SHA",
+		0xc012: "TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA",
+		0xc013: "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA",
+		0xc014: "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA",
+		0xc023: "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256",
+		0xc027: "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256",
+		0xc02b: "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+		0xc02c: "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+		0xc02f: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+		0xc030: "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+	}
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		names[suite.ID] = suite.Name
+	}
+	return names
+}()
+
+// CipherSuiteName converts a cipher suite ID to its name
+func CipherSuiteName(id uint16) string {
+	if name, ok := cipherSuites[id]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (0x%04x)", id)
+}
+
+// CipherSuiteID converts a cipher suite name to its ID
+func CipherSuiteID(name string) (uint16, bool) {
+	for id, suiteName := range cipherSuites {
+		if suiteName == name {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// parseCiphers converts a colon- or comma-separated list of cipher suite names to IDs
+func parseCiphers(list string) ([]uint16, error) {
+	var ids []uint16
+	for _, name := range strings.FieldsFunc(list, func(r rune) bool { return r == ':' || r == ',' }) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		id, ok := CipherSuiteID(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown cipher %q (valid: %s)", name, strings.Join(cipherSuiteNames(), ", "))
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no ciphers in %q", list)
+	}
+	return ids, nil
+}
+
+// cipherSuiteNames returns the known cipher suite names in sorted order
+func cipherSuiteNames() []string {
+	names := make([]string, 0, len(cipherSuites))
+	for _, name := range cipherSuites {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
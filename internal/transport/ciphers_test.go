@@ -0,0 +1,93 @@
+package transport
+
+import (
+	"crypto/tls"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
+	"github.com/aleister1102/purl/internal/target"
+)
+
+func TestCipherSuiteNameRoundTrip(t *testing.T) {
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		if got := CipherSuiteName(suite.ID); got != suite.Name {
+			t.Errorf("CipherSuiteName(0x%04x) = %q, want %q", suite.ID, got, suite.Name)
+		}
+		if id, ok := CipherSuiteID(suite.Name); !ok || id != suite.ID {
+			t.Errorf("CipherSuiteID(%q) = 0x%04x, %v; want 0x%04x", suite.Name, id, ok, suite.ID)
+		}
+	}
+	if got := CipherSuiteName(0xffff); got != "Unknown (0xffff)" {
+		t.Errorf("CipherSuiteName(0xffff) = %q", got)
+	}
+}
+
+func TestParseCiphers(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    string
+		want    []uint16
+		wantErr bool
+	}{
+		{"colon separated", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256:TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384", []uint16{0xc02f, 0xc030}, false},
+		{"comma separated", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_RSA_WITH_AES_128_CBC_SHA", []uint16{0xc02f, 0x002f}, false},
+		{"unknown cipher", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256:NOPE", nil, true},
+		{"empty list", ":,", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCiphers(tt.list)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCiphers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseCiphers() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTransport_UnknownCipherListsValidNames(t *testing.T) {
+	_, err := NewTransport(&cli.Options{Ciphers: "RC5"}, &target.ParsedTarget{})
+	var tlsErr *errors.TLSError
+	if !stderrors.As(err, &tlsErr) {
+		t.Fatalf("Expected TLSError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256") {
+		t.Errorf("Expected valid cipher names in error, got %v", err)
+	}
+}
+
+func TestNewTransport_CiphersRestrictHandshake(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	parsedTarget, err := target.ParseTarget(server.URL)
+	if err != nil {
+		t.Fatalf("ParseTarget failed: %v", err)
+	}
+
+	const cipher = "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"
+	transport, err := NewTransport(&cli.Options{Ciphers: cipher}, parsedTarget)
+	if err != nil {
+		t.Fatalf("NewTransport failed: %v", err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if got := CipherSuiteName(resp.TLS.CipherSuite); got != cipher {
+		t.Errorf("negotiated %s, want %s", got, cipher)
+	}
+}
@@ -0,0 +1,77 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
+	"github.com/aleister1102/purl/internal/target"
+)
+
+// DefaultConnectRetryInterval is the wait between --connect-retry-count attempts when no interval is set
+const DefaultConnectRetryInterval = time.Second
+
+// Connect opens the TCP connection (and TLS session for https) to the target, then closes it
+// Failed attempts are retried --connect-retry-count times, --connect-retry-interval apart
+func Connect(ctx context.Context, opts *cli.Options, parsedTarget *target.ParsedTarget, scheme string) error {
+	tr, err := NewTransport(opts, parsedTarget)
+	if err != nil {
+		return err
+	}
+
+	host := parsedTarget.URL.Hostname()
+	port := parsedTarget.URL.Port()
+	if port == "" {
+		port = "80"
+		if scheme == "https" {
+			port = "443"
+		}
+	}
+
+	interval := opts.ConnectRetryInterval
+	if interval <= 0 {
+		interval = DefaultConnectRetryInterval
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = connectOnce(ctx, tr, host, port, scheme == "https")
+		if err == nil || attempt >= opts.ConnectRetryCount || ctx.Err() != nil {
+			return err
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// connectOnce dials host:port and completes a TLS handshake when useTLS is set
+func connectOnce(ctx context.Context, tr *http.Transport, host, port string, useTLS bool) error {
+	conn, err := tr.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return &errors.ConnectionError{Host: host, Port: port, Cause: err}
+	}
+	defer conn.Close()
+
+	if !useTLS {
+		return nil
+	}
+
+	config := tr.TLSClientConfig.Clone()
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+
+	handshakeCtx, cancel := context.WithTimeout(ctx, tr.TLSHandshakeTimeout)
+	defer cancel()
+	if err := tls.Client(conn, config).HandshakeContext(handshakeCtx); err != nil {
+		return &errors.TLSError{Host: net.JoinHostPort(host, port), Cause: err}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
+	"github.com/aleister1102/purl/internal/target"
+)
+
+// flakyListener closes the first drop accepted connections before serving
+type flakyListener struct {
+	net.Listener
+	mu      sync.Mutex
+	drop    int
+	dropped int
+}
+
+func (l *flakyListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		l.mu.Lock()
+		if l.dropped < l.drop {
+			l.dropped++
+			l.mu.Unlock()
+			conn.Close()
+			continue
+		}
+		l.mu.Unlock()
+		return conn, nil
+	}
+}
+
+func TestConnect_SucceedsAfterRetries(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	listener := &flakyListener{Listener: server.Listener, drop: 2}
+	server.Listener = listener
+	server.StartTLS()
+	defer server.Close()
+
+	parsedTarget, err := target.ParseTarget(server.URL)
+	if err != nil {
+		t.Fatalf("ParseTarget failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		retryCount int
+		wantErr    bool
+	}{
+		{
+			name:       "retries exhausted before the listener accepts",
+			retryCount: 1,
+			wantErr:    true,
+		},
+		{
+			name:       "third attempt succeeds",
+			retryCount: 5,
+			wantErr:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener.mu.Lock()
+			listener.dropped = 0
+			listener.mu.Unlock()
+
+			opts := &cli.Options{
+				ConnectRetryCount:    tt.retryCount,
+				ConnectRetryInterval: 10 * time.Millisecond,
+			}
+
+			err := Connect(context.Background(), opts, parsedTarget, "https")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Connect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			listener.mu.Lock()
+			dropped := listener.dropped
+			listener.mu.Unlock()
+			if dropped != 2 && !tt.wantErr {
+				t.Errorf("Expected 2 dropped attempts before success, got %d", dropped)
+			}
+		})
+	}
+}
+
+func TestConnect_RefusedPort(t *testing.T) {
+	// Reserve a port and release it so nothing is listening
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	parsedTarget, err := target.ParseTarget(addr)
+	if err != nil {
+		t.Fatalf("ParseTarget failed: %v", err)
+	}
+
+	opts := &cli.Options{
+		ConnectRetryCount:    2,
+		ConnectRetryInterval: 10 * time.Millisecond,
+	}
+
+	start := time.Now()
+	err = Connect(context.Background(), opts, parsedTarget, "http")
+	if code := errors.MapErrorToExitCode(err); code != errors.ExitConnectFailed {
+		t.Errorf("Expected exit code %d, got %d (%v)", errors.ExitConnectFailed, code, err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Expected two retry intervals to elapse, took %v", elapsed)
+	}
+}
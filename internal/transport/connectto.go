@@ -0,0 +1,37 @@
+package transport
+
+import (
+	"cmp"
+	"context"
+	"net"
+	"strings"
+
+	"github.com/aleister1102/purl/internal/cli"
+)
+
+// withConnectTo wraps dial so connections matching a --connect-to entry go to its
+// host and port instead; the Host header and TLS SNI keep the request's hostname
+// The first matching entry wins, and --resolve then applies to the new address
+func withConnectTo(dial func(context.Context, string, string) (net.Conn, error), specs []string) (func(context.Context, string, string) (net.Conn, error), error) {
+	entries := make([]cli.ConnectTo, 0, len(specs))
+	for _, spec := range specs {
+		entry, err := cli.ParseConnectTo(spec)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if host, port, err := net.SplitHostPort(addr); err == nil {
+			host = strings.ToLower(host)
+			for _, entry := range entries {
+				if (entry.Host == "" || entry.Host == host) && (entry.Port == "" || entry.Port == port) {
+					addr = net.JoinHostPort(cmp.Or(entry.ToHost, host), cmp.Or(entry.ToPort, port))
+					break
+				}
+			}
+		}
+		return dial(ctx, network, addr)
+	}, nil
+}
@@ -0,0 +1,94 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/target"
+)
+
+func TestWithConnectTo_DialsReplacement(t *testing.T) {
+	var dialed []string
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		return nil, nil
+	}
+
+	wrapped, err := withConnectTo(dial, []string{
+		"example.com:443:backend.internal:8443",
+		"example.com::10.0.0.5:",
+		":8080::9090",
+	})
+	if err != nil {
+		t.Fatalf("withConnectTo failed: %v", err)
+	}
+
+	wrapped(context.Background(), "tcp", "Example.com:443")
+	wrapped(context.Background(), "tcp", "example.com:80")
+	wrapped(context.Background(), "tcp", "other.example:8080")
+	wrapped(context.Background(), "tcp", "other.example:443")
+
+	want := []string{"backend.internal:8443", "10.0.0.5:80", "other.example:9090", "other.example:443"}
+	for i := range want {
+		if dialed[i] != want[i] {
+			t.Errorf("dial %d went to %q, want %q", i, dialed[i], want[i])
+		}
+	}
+}
+
+func TestNewTransport_ConnectToKeepsHostHeader(t *testing.T) {
+	var gotHost string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+	}))
+	defer server.Close()
+
+	opts := &cli.Options{ConnectTo: []string{"example.com:80:" + server.Listener.Addr().String()}}
+	tr, err := NewTransport(opts, &target.ParsedTarget{})
+	if err != nil {
+		t.Fatalf("NewTransport failed: %v", err)
+	}
+
+	client := &http.Client{Transport: tr, Timeout: 5 * time.Second}
+	resp, err := client.Get("http://example.com/")
+	if err != nil {
+		t.Fatalf("request via --connect-to failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if gotHost != "example.com" {
+		t.Errorf("Expected Host header example.com, got %q", gotHost)
+	}
+}
+
+func TestNewTransport_ConnectToKeepsSNI(t *testing.T) {
+	// The certificate names only the original hostname, so verification fails if the new host is used
+	server, caPath := newSelfSignedTLSServer(t, []string{"staging.example.com"}, nil)
+	port := strconv.Itoa(server.Listener.Addr().(*net.TCPAddr).Port)
+
+	opts := &cli.Options{
+		CACerts:   []string{caPath},
+		ConnectTo: []string{"staging.example.com:443:127.0.0.1:" + port},
+	}
+	tr, err := NewTransport(opts, &target.ParsedTarget{})
+	if err != nil {
+		t.Fatalf("NewTransport failed: %v", err)
+	}
+
+	client := &http.Client{Transport: tr, Timeout: 5 * time.Second}
+	resp, err := client.Get("https://staging.example.com/")
+	if err != nil {
+		t.Fatalf("request via --connect-to failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.TLS == nil || resp.TLS.ServerName != "staging.example.com" {
+		t.Errorf("Expected SNI staging.example.com, got %+v", resp.TLS)
+	}
+}
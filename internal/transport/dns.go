@@ -0,0 +1,79 @@
+package transport
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"sync/atomic"
+
+	"github.com/aleister1102/purl/internal/cli"
+)
+
+// newResolver returns a resolver that sends its queries to servers (host:port)
+// instead of the system resolv.conf, rotating through them on each attempt
+func newResolver(servers []string) *net.Resolver {
+	var next atomic.Uint32
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			server := servers[int(next.Add(1)-1)%len(servers)]
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// withSystemDNSFallback wraps dial so a DNS failure from the custom resolver
+// retries the connection with fallback, which resolves through the system
+func withSystemDNSFallback(dial, fallback func(context.Context, string, string) (net.Conn, error)) func(context.Context, string, string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		var dnsErr *net.DNSError
+		if err != nil && stderrors.As(err, &dnsErr) && ctx.Err() == nil {
+			return fallback(ctx, network, addr)
+		}
+		return conn, err
+	}
+}
+
+// LookupIP resolves the addresses a connection to addr (host:port) would use,
+// applying --connect-to and --resolve and resolving through --dns-servers
+// (falling back to the system unless --dns-strict) the way the transport dials
+func LookupIP(ctx context.Context, opts *cli.Options, addr string) ([]net.IP, error) {
+	// Run the dial overrides with a dial that only records where it would connect
+	var dialed string
+	dial := func(_ context.Context, _, addr string) (net.Conn, error) {
+		dialed = addr
+		return nil, nil
+	}
+	var err error
+	if len(opts.Resolve) > 0 {
+		if dial, err = withResolve(dial, opts.Resolve); err != nil {
+			return nil, err
+		}
+	}
+	if len(opts.ConnectTo) > 0 {
+		if dial, err = withConnectTo(dial, opts.ConnectTo); err != nil {
+			return nil, err
+		}
+	}
+	dial(ctx, "tcp", addr)
+
+	host, _, err := net.SplitHostPort(dialed)
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+
+	if len(opts.DNSServers) == 0 {
+		return net.DefaultResolver.LookupIP(ctx, "ip", host)
+	}
+	ips, err := newResolver(opts.DNSServers).LookupIP(ctx, "ip", host)
+	var dnsErr *net.DNSError
+	if err != nil && !opts.DNSStrict && stderrors.As(err, &dnsErr) && ctx.Err() == nil {
+		return net.DefaultResolver.LookupIP(ctx, "ip", host)
+	}
+	return ips, err
+}
@@ -0,0 +1,189 @@
+package transport
+
+import (
+	"context"
+	"encoding/binary"
+	stderrors "errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/target"
+)
+
+// startMockDNS serves A queries over UDP, answering 127.0.0.1 for names in hosts
+// and NXDOMAIN otherwise; it returns the server address and a query counter
+func startMockDNS(t *testing.T, hosts map[string]bool) (string, *atomic.Int32) {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	var queries atomic.Int32
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if reply := mockDNSReply(buf[:n], hosts); reply != nil {
+				queries.Add(1)
+				conn.WriteTo(reply, addr)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String(), &queries
+}
+
+// mockDNSReply builds the answer to a single-question DNS query
+func mockDNSReply(query []byte, hosts map[string]bool) []byte {
+	if len(query) < 12 {
+		return nil
+	}
+
+	// Walk the question name to find where the question ends
+	var labels []string
+	offset := 12
+	for offset < len(query) && query[offset] != 0 {
+		length := int(query[offset])
+		if offset+1+length > len(query) {
+			return nil
+		}
+		labels = append(labels, string(query[offset+1:offset+1+length]))
+		offset += 1 + length
+	}
+	questionEnd := offset + 5
+	if questionEnd > len(query) {
+		return nil
+	}
+	name := strings.ToLower(strings.Join(labels, "."))
+	qtype := binary.BigEndian.Uint16(query[offset+1:])
+
+	reply := make([]byte, 12, 64)
+	copy(reply, query[:2])
+	binary.BigEndian.PutUint16(reply[2:], 0x8180) // response, recursion desired and available
+	binary.BigEndian.PutUint16(reply[4:], 1)
+	reply = append(reply, query[12:questionEnd]...)
+
+	switch {
+	case !hosts[name]:
+		reply[3] |= 3 // NXDOMAIN
+	case qtype == 1:
+		binary.BigEndian.PutUint16(reply[6:], 1)
+		reply = append(reply,
+			0xc0, 0x0c, // pointer to the question name
+			0, 1, 0, 1, // type A, class IN
+			0, 0, 0, 60, // TTL
+			0, 4, 127, 0, 0, 1)
+	}
+	return reply
+}
+
+func TestNewTransport_DNSServers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("resolved"))
+	}))
+	defer server.Close()
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+
+	dnsAddr, queries := startMockDNS(t, map[string]bool{"staging.purl.test": true})
+
+	tr, err := NewTransport(&cli.Options{DNSServers: []string{dnsAddr}, DNSStrict: true}, &target.ParsedTarget{})
+	if err != nil {
+		t.Fatalf("NewTransport failed: %v", err)
+	}
+	client := &http.Client{Transport: tr, Timeout: 5 * time.Second}
+
+	resp, err := client.Get("http://staging.purl.test:" + strconv.Itoa(port) + "/")
+	if err != nil {
+		t.Fatalf("request through the mock resolver failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != "resolved" {
+		t.Errorf("Expected body %q, got %q", "resolved", body)
+	}
+	if queries.Load() == 0 {
+		t.Error("Expected the mock DNS server to be queried")
+	}
+}
+
+func TestNewTransport_DNSStrictFailure(t *testing.T) {
+	dnsAddr, queries := startMockDNS(t, map[string]bool{})
+
+	tr, err := NewTransport(&cli.Options{DNSServers: []string{dnsAddr}, DNSStrict: true}, &target.ParsedTarget{})
+	if err != nil {
+		t.Fatalf("NewTransport failed: %v", err)
+	}
+	client := &http.Client{Transport: tr, Timeout: 5 * time.Second}
+
+	_, err = client.Get("http://missing.purl.test/")
+	if err == nil {
+		t.Fatal("Expected a DNS failure")
+	}
+	var dnsErr *net.DNSError
+	if !stderrors.As(err, &dnsErr) {
+		t.Errorf("Expected a *net.DNSError, got %v", err)
+	}
+	if queries.Load() == 0 {
+		t.Error("Expected the mock DNS server to be queried")
+	}
+}
+
+func TestLookupIP(t *testing.T) {
+	dnsAddr, _ := startMockDNS(t, map[string]bool{"staging.purl.test": true})
+
+	tests := []struct {
+		name string
+		opts *cli.Options
+		addr string
+		want string
+	}{
+		{"dns servers", &cli.Options{DNSServers: []string{dnsAddr}, DNSStrict: true}, "staging.purl.test:80", "127.0.0.1"},
+		{"resolve", &cli.Options{Resolve: []string{"api.purl.test:443:10.0.0.7"}}, "api.purl.test:443", "10.0.0.7"},
+		{"resolve other port", &cli.Options{Resolve: []string{"api.purl.test:443:10.0.0.7"}, DNSServers: []string{dnsAddr}, DNSStrict: true}, "staging.purl.test:80", "127.0.0.1"},
+		{"connect-to then resolve", &cli.Options{ConnectTo: []string{"api.purl.test:443:backend.purl.test:8443"}, Resolve: []string{"backend.purl.test:8443:10.0.0.8"}}, "api.purl.test:443", "10.0.0.8"},
+		{"connect-to through dns servers", &cli.Options{ConnectTo: []string{"api.purl.test::staging.purl.test:"}, DNSServers: []string{dnsAddr}, DNSStrict: true}, "api.purl.test:443", "127.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ips, err := LookupIP(t.Context(), tt.opts, tt.addr)
+			if err != nil {
+				t.Fatalf("LookupIP failed: %v", err)
+			}
+			if len(ips) == 0 || ips[0].String() != tt.want {
+				t.Errorf("LookupIP = %v, want %s", ips, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupIP_Failures(t *testing.T) {
+	dnsAddr, _ := startMockDNS(t, map[string]bool{})
+	strict := &cli.Options{DNSServers: []string{dnsAddr}, DNSStrict: true}
+
+	if _, err := LookupIP(t.Context(), strict, "missing.purl.test:80"); err == nil {
+		t.Error("Expected a lookup failure under --dns-strict")
+	}
+
+	// A cancelled request context stops the lookup
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, err := LookupIP(ctx, &cli.Options{DNSServers: []string{"127.0.0.1:1"}}, "staging.purl.test:80"); err == nil {
+		t.Error("Expected a cancelled lookup to fail")
+	}
+}
@@ -0,0 +1,127 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+)
+
+// hostStripConn removes the Host header from the first request written to the connection
+// Go's HTTP client always writes a Host header, so it has to be stripped on the wire
+type hostStripConn struct {
+	net.Conn
+	buf  []byte
+	done bool
+}
+
+// hostStripTLSConn is a hostStripConn over TLS, exposing the connection's TLS state
+// so net/http still fills in resp.TLS
+type hostStripTLSConn struct {
+	*hostStripConn
+	tlsConn *tls.Conn
+}
+
+func (c *hostStripTLSConn) ConnectionState() tls.ConnectionState {
+	return c.tlsConn.ConnectionState()
+}
+
+// newHostStripConn wraps a connection so the outgoing Host header is dropped
+func newHostStripConn(conn net.Conn) net.Conn {
+	stripped := &hostStripConn{Conn: conn}
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		return &hostStripTLSConn{hostStripConn: stripped, tlsConn: tlsConn}
+	}
+	return stripped
+}
+
+// Write buffers the request head until it is complete, then writes it without the Host line
+func (c *hostStripConn) Write(p []byte) (int, error) {
+	if c.done {
+		return c.Conn.Write(p)
+	}
+
+	c.buf = append(c.buf, p...)
+	end := bytes.Index(c.buf, []byte("\r\n\r\n"))
+	if end == -1 {
+		// Request head not complete yet
+		return len(p), nil
+	}
+
+	head := c.buf[:end+4]
+	rest := c.buf[end+4:]
+
+	var out bytes.Buffer
+	for i, line := range bytes.SplitAfter(head, []byte("\r\n")) {
+		// Keep the request line, drop any Host header line
+		if i > 0 && bytes.HasPrefix(bytes.ToLower(line), []byte("host:")) {
+			continue
+		}
+		out.Write(line)
+	}
+	out.Write(rest)
+
+	c.done = true
+	c.buf = nil
+	if _, err := c.Conn.Write(out.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// stripHostHeader configures the transport to send requests without a Host header
+// Keep-alives are disabled so every request starts on a fresh connection, and
+// proxy environment variables are ignored since a proxied request keeps its Host
+func stripHostHeader(transport *http.Transport, tlsConfig *tls.Config) {
+	transport.DisableKeepAlives = true
+	transport.Proxy = nil
+
+	dial := transport.DialContext
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return newHostStripConn(conn), nil
+	}
+
+	// TLS is terminated here so the Host line can be removed from the plaintext
+	transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConn, err := handshakeTLS(ctx, transport, tlsConfig.Clone(), conn, addr)
+		if err != nil {
+			return nil, err
+		}
+		return newHostStripConn(tlsConn), nil
+	}
+}
+
+// handshakeTLS runs the client side of a TLS handshake over conn, closing it on failure
+// The server name defaults to addr's host, and the transport's handshake timeout applies
+func handshakeTLS(ctx context.Context, transport *http.Transport, config *tls.Config, conn net.Conn, addr string) (*tls.Conn, error) {
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		config.ServerName = host
+	}
+
+	if transport.TLSHandshakeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, transport.TLSHandshakeTimeout)
+		defer cancel()
+	}
+
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
@@ -0,0 +1,134 @@
+package transport
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/target"
+)
+
+func TestNewTransport_NoHostHeader(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create listener: %v", err)
+	}
+	defer listener.Close()
+
+	// Raw TCP server capturing the request head exactly as sent
+	linesCh := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var lines []string
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil || line == "\r\n" {
+				break
+			}
+			lines = append(lines, strings.TrimRight(line, "\r\n"))
+		}
+		conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 0\r\nConnection: close\r\n\r\n"))
+		linesCh <- lines
+	}()
+
+	opts := &cli.Options{
+		NoHostHeader:   true,
+		ConnectTimeout: 5 * time.Second,
+	}
+
+	transport, err := NewTransport(opts, &target.ParsedTarget{IsIP: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+	resp, err := client.Get("http://" + listener.Addr().String() + "/probe")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	lines := <-linesCh
+	if len(lines) == 0 || lines[0] != "GET /probe HTTP/1.1" {
+		t.Fatalf("unexpected request line: %v", lines)
+	}
+	for _, line := range lines {
+		if strings.HasPrefix(strings.ToLower(line), "host:") {
+			t.Errorf("expected no Host header, got %q", line)
+		}
+	}
+}
+
+func TestHostStripConn_PartialWrites(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	conn := newHostStripConn(client)
+	go func() {
+		conn.Write([]byte("GET / HTTP/1.1\r\nHost: exa"))
+		conn.Write([]byte("mple.com\r\nAccept: */*\r\n\r\nbody"))
+		client.Close()
+	}()
+
+	var received strings.Builder
+	buf := make([]byte, 256)
+	for {
+		n, err := server.Read(buf)
+		received.Write(buf[:n])
+		if err != nil {
+			break
+		}
+	}
+
+	expected := "GET / HTTP/1.1\r\nAccept: */*\r\n\r\nbody"
+	if received.String() != expected {
+		t.Errorf("got %q, want %q", received.String(), expected)
+	}
+}
+
+func TestNewTransport_NoHostHeaderIgnoresProxyEnvironment(t *testing.T) {
+	transport, err := NewTransport(&cli.Options{NoHostHeader: true}, &target.ParsedTarget{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Through a proxy the Host header would still reach the target
+	if transport.Proxy != nil {
+		t.Error("expected --no-host-header to connect directly, without a proxy")
+	}
+}
+
+func TestNewTransport_NoHostHeaderKeepsTLSState(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	transport, err := NewTransport(&cli.Options{NoHostHeader: true, Insecure: true, ConnectTimeout: 5 * time.Second}, &target.ParsedTarget{IsIP: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	// --fail-on-weak-tls and --expect-tls-version read the state from resp.TLS
+	if resp.TLS == nil {
+		t.Fatal("expected resp.TLS to be set for an HTTPS request without a Host header")
+	}
+	if resp.TLS.Version == 0 || resp.TLS.CipherSuite == 0 {
+		t.Errorf("expected the negotiated version and cipher, got %+v", resp.TLS)
+	}
+}
@@ -0,0 +1,78 @@
+//go:build linux
+
+package transport
+
+import (
+	"context"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/target"
+)
+
+// tcpNoDelay reads TCP_NODELAY from the socket behind conn
+func tcpNoDelay(t *testing.T, conn net.Conn) bool {
+	t.Helper()
+
+	raw, err := conn.(*net.TCPConn).SyscallConn()
+	if err != nil {
+		t.Fatalf("SyscallConn failed: %v", err)
+	}
+
+	var value int
+	var sockErr error
+	err = raw.Control(func(fd uintptr) {
+		value, sockErr = syscall.GetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_NODELAY)
+	})
+	if err != nil || sockErr != nil {
+		t.Fatalf("getsockopt failed: %v %v", err, sockErr)
+	}
+	return value != 0
+}
+
+func TestNewTransport_TCPNoDelay(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	tests := []struct {
+		name        string
+		nagle       bool
+		wantNoDelay bool
+	}{
+		{"nodelay by default", false, true},
+		{"nagle on request", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := NewTransport(&cli.Options{TCPNagle: tt.nagle}, &target.ParsedTarget{})
+			if err != nil {
+				t.Fatalf("NewTransport failed: %v", err)
+			}
+
+			conn, err := transport.DialContext(context.Background(), "tcp", listener.Addr().String())
+			if err != nil {
+				t.Fatalf("dial failed: %v", err)
+			}
+			defer conn.Close()
+
+			if got := tcpNoDelay(t, conn); got != tt.wantNoDelay {
+				t.Errorf("TCP_NODELAY = %v, want %v", got, tt.wantNoDelay)
+			}
+		})
+	}
+}
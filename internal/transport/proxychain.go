@@ -0,0 +1,314 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aleister1102/purl/internal/errors"
+)
+
+// chainDialer reaches the target by tunnelling through each proxy in order
+// Supports HTTP CONNECT (http://) and SOCKS5 hops; like curl, socks5:// resolves
+// hostnames locally and socks5h:// leaves resolution to the proxy
+type chainDialer struct {
+	dialer  *net.Dialer
+	proxies []*url.URL
+}
+
+// parseProxyChain parses and validates the proxy URLs of a chain
+func parseProxyChain(chain []string) ([]*url.URL, error) {
+	var proxies []*url.URL
+
+	for _, raw := range chain {
+		proxyURL, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, &errors.URLParseError{
+				Input:   raw,
+				Message: fmt.Sprintf("invalid proxy URL: %v", err),
+			}
+		}
+
+		switch proxyURL.Scheme {
+		case "http", "socks5", "socks5h":
+		default:
+			return nil, &errors.URLParseError{
+				Input:   raw,
+				Message: "unsupported proxy scheme (must be http, socks5, or socks5h)",
+			}
+		}
+
+		if proxyURL.Hostname() == "" {
+			return nil, &errors.URLParseError{
+				Input:   raw,
+				Message: "proxy URL must contain a host",
+			}
+		}
+
+		proxies = append(proxies, proxyURL)
+	}
+
+	return proxies, nil
+}
+
+// DialContext connects to the first proxy and asks each hop to connect to the next one
+// The context deadline also bounds the proxy handshakes
+func (d *chainDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := d.dialer.DialContext(ctx, network, proxyAddr(d.proxies[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	// Unblock the handshakes if the context is cancelled mid-negotiation
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	for i, proxyURL := range d.proxies {
+		next := addr
+		if i+1 < len(d.proxies) {
+			next = proxyAddr(d.proxies[i+1])
+		}
+
+		switch proxyURL.Scheme {
+		case "http":
+			conn, err = httpConnect(conn, proxyURL, next)
+		case "socks5":
+			if next, err = d.resolve(ctx, next); err == nil {
+				err = socks5Connect(conn, proxyURL, next)
+			}
+		default:
+			err = socks5Connect(conn, proxyURL, next)
+		}
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("proxy %s: %w", proxyURL.Host, err)
+		}
+	}
+
+	return conn, nil
+}
+
+// resolve replaces the hostname of addr with one of its addresses, preferring IPv4
+// It uses the dialer's resolver, so --dns-servers applies
+func (d *chainDialer) resolve(ctx context.Context, addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	if net.ParseIP(host) != nil {
+		return addr, nil
+	}
+
+	resolver := d.dialer.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	ips, err := resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return "", err
+	}
+	if len(ips) == 0 {
+		return "", fmt.Errorf("no addresses for %s", host)
+	}
+
+	ip := ips[0]
+	for _, candidate := range ips {
+		if candidate.Unmap().Is4() {
+			ip = candidate
+			break
+		}
+	}
+	return net.JoinHostPort(ip.Unmap().String(), port), nil
+}
+
+// proxyAddr returns the host:port of a proxy, applying the scheme's default port
+func proxyAddr(proxyURL *url.URL) string {
+	port := proxyURL.Port()
+	if port == "" {
+		port = "1080"
+		if proxyURL.Scheme == "http" {
+			port = "8080"
+		}
+	}
+	return net.JoinHostPort(proxyURL.Hostname(), port)
+}
+
+// httpConnect opens a tunnel to addr through an HTTP proxy using CONNECT
+func httpConnect(conn net.Conn, proxyURL *url.URL, addr string) (net.Conn, error) {
+	req := fmt.Sprintf("CONNECT %s HTTP/1.1\r\nHost: %s\r\n", addr, addr)
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		req += "Proxy-Authorization: Basic " + credentials + "\r\n"
+	}
+	req += "\r\n"
+
+	if _, err := io.WriteString(conn, req); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CONNECT response: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CONNECT to %s failed: %s", addr, resp.Status)
+	}
+
+	// Keep any bytes the reader consumed past the response
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// socks5Connect asks a SOCKS5 proxy to connect to addr (RFC 1928)
+func socks5Connect(conn net.Conn, proxyURL *url.URL, addr string) error {
+	// Greeting: offer no-auth, plus username/password when credentials are set
+	methods := []byte{0x00}
+	if proxyURL.User != nil {
+		methods = append(methods, 0x02)
+	}
+	greeting := append([]byte{0x05, byte(len(methods))}, methods...)
+	if _, err := conn.Write(greeting); err != nil {
+		return err
+	}
+
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return fmt.Errorf("failed to read SOCKS5 greeting: %w", err)
+	}
+	if reply[0] != 0x05 {
+		return fmt.Errorf("unexpected SOCKS version %d", reply[0])
+	}
+
+	switch reply[1] {
+	case 0x00:
+	case 0x02:
+		if err := socks5Authenticate(conn, proxyURL.User); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("no acceptable SOCKS5 authentication method")
+	}
+
+	// Connect request
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return fmt.Errorf("invalid port: %s", portStr)
+	}
+
+	request := []byte{0x05, 0x01, 0x00}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			request = append(request, 0x01)
+			request = append(request, ip4...)
+		} else {
+			request = append(request, 0x04)
+			request = append(request, ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return fmt.Errorf("hostname too long: %s", host)
+		}
+		request = append(request, 0x03, byte(len(host)))
+		request = append(request, host...)
+	}
+	request = binary.BigEndian.AppendUint16(request, uint16(port))
+
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return fmt.Errorf("failed to read SOCKS5 reply: %w", err)
+	}
+	if header[1] != 0x00 {
+		return fmt.Errorf("SOCKS5 connect to %s failed with code %d", addr, header[1])
+	}
+
+	// Skip the bound address and port
+	var skip int
+	switch header[3] {
+	case 0x01:
+		skip = net.IPv4len + 2
+	case 0x04:
+		skip = net.IPv6len + 2
+	case 0x03:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return err
+		}
+		skip = int(length[0]) + 2
+	default:
+		return fmt.Errorf("unexpected SOCKS5 address type %d", header[3])
+	}
+	if _, err := io.ReadFull(conn, make([]byte, skip)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// socks5Authenticate performs username/password authentication (RFC 1929)
+func socks5Authenticate(conn net.Conn, user *url.Userinfo) error {
+	username := user.Username()
+	password, _ := user.Password()
+	if len(username) > 255 {
+		return fmt.Errorf("SOCKS5 username is longer than 255 bytes")
+	}
+	if len(password) > 255 {
+		return fmt.Errorf("SOCKS5 password is longer than 255 bytes")
+	}
+
+	request := []byte{0x01, byte(len(username))}
+	request = append(request, username...)
+	request = append(request, byte(len(password)))
+	request = append(request, password...)
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return fmt.Errorf("failed to read SOCKS5 auth reply: %w", err)
+	}
+	if reply[1] != 0x00 {
+		return fmt.Errorf("SOCKS5 authentication failed")
+	}
+	return nil
+}
+
+// bufferedConn serves reads from a bufio.Reader that may hold already-received bytes
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
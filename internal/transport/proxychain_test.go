@@ -0,0 +1,292 @@
+package transport
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
+	"github.com/aleister1102/purl/internal/target"
+)
+
+// mockProxy records the addresses it was asked to connect to
+type mockProxy struct {
+	listener net.Listener
+	mu       sync.Mutex
+	targets  []string
+}
+
+func (p *mockProxy) record(addr string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.targets = append(p.targets, addr)
+}
+
+func (p *mockProxy) seen() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.targets...)
+}
+
+func (p *mockProxy) addr() string {
+	return p.listener.Addr().String()
+}
+
+// newMockConnectProxy starts an HTTP CONNECT proxy
+func newMockConnectProxy(t *testing.T) *mockProxy {
+	t.Helper()
+	proxy := startMockProxy(t, func(p *mockProxy, conn net.Conn) {
+		reader := bufio.NewReader(conn)
+		req, err := http.ReadRequest(reader)
+		if err != nil || req.Method != http.MethodConnect {
+			return
+		}
+		p.record(req.Host)
+
+		upstream, err := net.Dial("tcp", req.Host)
+		if err != nil {
+			io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+			return
+		}
+		defer upstream.Close()
+
+		io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		pipe(conn, upstream)
+	})
+	return proxy
+}
+
+// newMockSOCKS5Proxy starts a SOCKS5 proxy without authentication
+func newMockSOCKS5Proxy(t *testing.T) *mockProxy {
+	t.Helper()
+	proxy := startMockProxy(t, func(p *mockProxy, conn net.Conn) {
+		greeting := make([]byte, 2)
+		if _, err := io.ReadFull(conn, greeting); err != nil {
+			return
+		}
+		io.ReadFull(conn, make([]byte, greeting[1]))
+		conn.Write([]byte{0x05, 0x00})
+
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+
+		var host string
+		switch header[3] {
+		case 0x01:
+			ip := make([]byte, net.IPv4len)
+			io.ReadFull(conn, ip)
+			host = net.IP(ip).String()
+		case 0x03:
+			length := make([]byte, 1)
+			io.ReadFull(conn, length)
+			name := make([]byte, length[0])
+			io.ReadFull(conn, name)
+			host = string(name)
+		default:
+			return
+		}
+		portBytes := make([]byte, 2)
+		io.ReadFull(conn, portBytes)
+		addr := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(portBytes))))
+		p.record(addr)
+
+		upstream, err := net.Dial("tcp", addr)
+		if err != nil {
+			conn.Write([]byte{0x05, 0x05, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+			return
+		}
+		defer upstream.Close()
+
+		conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		pipe(conn, upstream)
+	})
+	return proxy
+}
+
+func startMockProxy(t *testing.T, handle func(*mockProxy, net.Conn)) *mockProxy {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create listener: %v", err)
+	}
+	proxy := &mockProxy{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(proxy, conn)
+			}()
+		}
+	}()
+	return proxy
+}
+
+// pipe copies data in both directions until either side closes
+func pipe(a, b net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() { io.Copy(a, b); done <- struct{}{} }()
+	go func() { io.Copy(b, a); done <- struct{}{} }()
+	<-done
+}
+
+func TestNewTransport_ProxyChain(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("origin"))
+	}))
+	defer origin.Close()
+	originAddr := origin.Listener.Addr().String()
+
+	tests := []struct {
+		name   string
+		second func(*testing.T) *mockProxy
+		scheme string
+	}{
+		{name: "http to http", second: newMockConnectProxy, scheme: "http"},
+		{name: "http to socks5", second: newMockSOCKS5Proxy, scheme: "socks5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := newMockConnectProxy(t)
+			second := tt.second(t)
+
+			opts := &cli.Options{
+				ConnectTimeout: 5 * time.Second,
+				ProxyChain: []string{
+					"http://" + first.addr(),
+					tt.scheme + "://" + second.addr(),
+				},
+			}
+
+			transport, err := NewTransport(opts, &target.ParsedTarget{IsIP: true})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+			resp, err := client.Get(origin.URL)
+			if err != nil {
+				t.Fatalf("request through chain failed: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if string(body) != "origin" {
+				t.Errorf("body: got %q, want %q", string(body), "origin")
+			}
+			if seen := first.seen(); len(seen) != 1 || seen[0] != second.addr() {
+				t.Errorf("first proxy targets: got %v, want [%s]", seen, second.addr())
+			}
+			if seen := second.seen(); len(seen) != 1 || seen[0] != originAddr {
+				t.Errorf("second proxy targets: got %v, want [%s]", seen, originAddr)
+			}
+		})
+	}
+}
+
+func TestNewTransport_ProxyChainInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		chain []string
+	}{
+		{name: "unsupported scheme", chain: []string{"ftp://proxy:21"}},
+		{name: "missing host", chain: []string{"http://"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &cli.Options{ProxyChain: tt.chain}
+
+			_, err := NewTransport(opts, &target.ParsedTarget{})
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if errors.MapErrorToExitCode(err) != errors.ExitURLParse {
+				t.Errorf("expected URL parse error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewTransport_SOCKS5Resolution(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("origin"))
+	}))
+	defer origin.Close()
+	port := strconv.Itoa(origin.Listener.Addr().(*net.TCPAddr).Port)
+
+	tests := []struct {
+		scheme string
+		want   string
+	}{
+		// socks5 resolves locally and sends the address; socks5h sends the name
+		{scheme: "socks5", want: net.JoinHostPort("127.0.0.1", port)},
+		{scheme: "socks5h", want: net.JoinHostPort("localhost", port)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scheme, func(t *testing.T) {
+			proxy := newMockSOCKS5Proxy(t)
+			opts := &cli.Options{
+				ConnectTimeout: 5 * time.Second,
+				ProxyChain:     []string{tt.scheme + "://" + proxy.addr()},
+			}
+
+			transport, err := NewTransport(opts, &target.ParsedTarget{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+			resp, err := client.Get("http://localhost:" + port + "/")
+			if err != nil {
+				t.Fatalf("request through proxy failed: %v", err)
+			}
+			resp.Body.Close()
+
+			if seen := proxy.seen(); len(seen) != 1 || seen[0] != tt.want {
+				t.Errorf("proxy targets: got %v, want [%s]", seen, tt.want)
+			}
+		})
+	}
+}
+
+func TestSOCKS5Authenticate_RejectsLongCredentials(t *testing.T) {
+	long := strings.Repeat("a", 256)
+	tests := []struct {
+		name string
+		user *url.Userinfo
+	}{
+		{name: "username", user: url.UserPassword(long, "pw")},
+		{name: "password", user: url.UserPassword("user", long)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+			defer server.Close()
+
+			if err := socks5Authenticate(client, tt.user); err == nil {
+				t.Fatal("expected an error for a credential over 255 bytes")
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/aleister1102/purl/internal/cli"
+)
+
+// withResolve wraps dial so connections to a host:port listed in --resolve go to its
+// override address instead; TLS still uses the request's hostname for SNI and verification
+func withResolve(dial func(context.Context, string, string) (net.Conn, error), specs []string) (func(context.Context, string, string) (net.Conn, error), error) {
+	overrides := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		match, address, err := cli.ParseResolve(spec)
+		if err != nil {
+			return nil, err
+		}
+		overrides[match] = address
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if address, ok := overrides[strings.ToLower(addr)]; ok {
+			addr = address
+		}
+		return dial(ctx, network, addr)
+	}, nil
+}
@@ -0,0 +1,64 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/target"
+)
+
+func TestWithResolve_DialsOverride(t *testing.T) {
+	var dialed []string
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		return nil, nil
+	}
+
+	wrapped, err := withResolve(dial, []string{"example.com:443:127.0.0.1"})
+	if err != nil {
+		t.Fatalf("withResolve failed: %v", err)
+	}
+
+	wrapped(context.Background(), "tcp", "example.com:443")
+	wrapped(context.Background(), "tcp", "example.com:80")
+	wrapped(context.Background(), "tcp", "other.example:443")
+
+	want := []string{"127.0.0.1:443", "example.com:80", "other.example:443"}
+	for i := range want {
+		if dialed[i] != want[i] {
+			t.Errorf("dial %d went to %q, want %q", i, dialed[i], want[i])
+		}
+	}
+}
+
+func TestNewTransport_ResolveKeepsHostnameForTLS(t *testing.T) {
+	// The certificate names only the hostname, so verification fails if the IP is used
+	server, caPath := newSelfSignedTLSServer(t, []string{"staging.example.com"}, nil)
+	port := strconv.Itoa(server.Listener.Addr().(*net.TCPAddr).Port)
+
+	opts := &cli.Options{
+		CACerts: []string{caPath},
+		Resolve: []string{"staging.example.com:" + port + ":127.0.0.1"},
+	}
+	tr, err := NewTransport(opts, &target.ParsedTarget{})
+	if err != nil {
+		t.Fatalf("NewTransport failed: %v", err)
+	}
+
+	client := &http.Client{Transport: tr, Timeout: 5 * time.Second}
+
+	resp, err := client.Get("https://staging.example.com:" + port + "/")
+	if err != nil {
+		t.Fatalf("request via --resolve failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.TLS == nil || resp.TLS.ServerName != "staging.example.com" {
+		t.Errorf("Expected SNI staging.example.com, got %+v", resp.TLS)
+	}
+}
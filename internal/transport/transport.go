@@ -1,12 +1,14 @@
 package transport
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/aleister1102/purl/internal/cli"
@@ -17,14 +19,62 @@ import (
 // NewTransport creates a configured http.Transport with TLS and timeout settings
 // isIP indicates whether the target is an IP address (affects InsecureSkipVerify default)
 func NewTransport(opts *cli.Options, parsedTarget *target.ParsedTarget) (*http.Transport, error) {
+	// TLS handshake timeout falls back to the connect timeout (or its default) when unset
+	tlsHandshakeTimeout := GetConnectTimeout(opts)
+	if opts.TLSHandshakeTimeout > 0 {
+		tlsHandshakeTimeout = opts.TLSHandshakeTimeout
+	}
+
 	// Create base transport
 	transport := &http.Transport{
-		Dial: (&net.Dialer{
-			Timeout: opts.ConnectTimeout,
-		}).Dial,
-		TLSHandshakeTimeout: opts.ConnectTimeout,
+		DialContext:         newDialer(opts).DialContext,
+		TLSHandshakeTimeout: tlsHandshakeTimeout,
+	}
+
+	// Route through -x/--proxy or the proxy environment; a proxy chain dials its own hops
+	if len(opts.ProxyChain) == 0 {
+		proxy, err := newProxyFunc(opts.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = proxy
+	}
+
+	// Tunnel through the proxy chain if configured
+	if len(opts.ProxyChain) > 0 {
+		proxies, err := parseProxyChain(opts.ProxyChain)
+		if err != nil {
+			return nil, err
+		}
+		chain := &chainDialer{dialer: newDialer(opts), proxies: proxies}
+		transport.DialContext = chain.DialContext
+	} else if len(opts.DNSServers) > 0 && !opts.DNSStrict {
+		// Direct connections fall back to the system resolver unless --dns-strict
+		system := &net.Dialer{Timeout: GetConnectTimeout(opts)}
+		transport.DialContext = withSystemDNSFallback(transport.DialContext, system.DialContext)
 	}
 
+	// Dial overridden addresses for --resolve entries
+	if len(opts.Resolve) > 0 {
+		dial, err := withResolve(transport.DialContext, opts.Resolve)
+		if err != nil {
+			return nil, &errors.URLParseError{Input: strings.Join(opts.Resolve, ","), Message: err.Error()}
+		}
+		transport.DialContext = dial
+	}
+
+	// Redirect --connect-to connections; wrapping --resolve lets it map the new host
+	if len(opts.ConnectTo) > 0 {
+		dial, err := withConnectTo(transport.DialContext, opts.ConnectTo)
+		if err != nil {
+			return nil, &errors.URLParseError{Input: strings.Join(opts.ConnectTo, ","), Message: err.Error()}
+		}
+		transport.DialContext = dial
+	}
+
+	// Nagle's algorithm is off (TCP_NODELAY) unless --tcp-nagle asks for it
+	transport.DialContext = withNoDelay(transport.DialContext, !opts.TCPNagle)
+
 	// Configure TLS settings
 	tlsConfig := &tls.Config{}
 
@@ -43,21 +93,23 @@ func NewTransport(opts *cli.Options, parsedTarget *target.ParsedTarget) (*http.T
 		host = parsedTarget.URL.Host
 	}
 
-	// Load CA certificate if provided
-	if opts.CACert != "" {
-		caCert, err := os.ReadFile(opts.CACert)
-		if err != nil {
-			return nil, &errors.TLSError{
-				Host:  host,
-				Cause: fmt.Errorf("failed to read CA certificate: %w", err),
+	// Load CA certificates if provided, trusting all of them through one pool
+	if len(opts.CACerts) > 0 {
+		caCertPool := x509.NewCertPool()
+		for _, path := range opts.CACerts {
+			caCert, err := os.ReadFile(path)
+			if err != nil {
+				return nil, &errors.TLSError{
+					Host:  host,
+					Cause: fmt.Errorf("failed to read CA certificate: %w", err),
+				}
 			}
-		}
 
-		caCertPool := x509.NewCertPool()
-		if !caCertPool.AppendCertsFromPEM(caCert) {
-			return nil, &errors.TLSError{
-				Host:  host,
-				Cause: fmt.Errorf("failed to parse CA certificate"),
+			if !caCertPool.AppendCertsFromPEM(caCert) {
+				return nil, &errors.TLSError{
+					Host:  host,
+					Cause: fmt.Errorf("failed to parse CA certificate %s", path),
+				}
 			}
 		}
 
@@ -87,11 +139,149 @@ func NewTransport(opts *cli.Options, parsedTarget *target.ParsedTarget) (*http.T
 		}
 	}
 
+	// Verify the chain but not the name; --insecure still skips everything
+	if opts.SkipHostnameVerify && !opts.Insecure {
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = verifyChainOnly(tlsConfig.RootCAs)
+	}
+
+	// Let weak versions and ciphers complete the handshake so --fail-on-weak-tls can report them
+	if opts.FailOnWeakTLS {
+		tlsConfig.MinVersion = tls.VersionTLS10
+		for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+			tlsConfig.CipherSuites = append(tlsConfig.CipherSuites, suite.ID)
+		}
+	}
+
+	// Restrict the TLS 1.2 and earlier cipher suites to --ciphers
+	if opts.Ciphers != "" {
+		ids, err := parseCiphers(opts.Ciphers)
+		if err != nil {
+			return nil, &errors.TLSError{Host: host, Cause: err}
+		}
+		tlsConfig.CipherSuites = ids
+	}
+
+	// Bound the negotiated version with --tlsv1.x and --tls-max
+	if err := applyTLSVersions(tlsConfig, opts); err != nil {
+		return nil, &errors.TLSError{Host: host, Cause: err}
+	}
+
 	transport.TLSClientConfig = tlsConfig
 
+	// Tuning HTTP/2 opts in to it; a custom TLS config otherwise keeps the transport on HTTP/1.1
+	// The window is sent as SETTINGS_INITIAL_WINDOW_SIZE in the client's first SETTINGS frame
+	if opts.H2InitialWindow > 0 {
+		transport.ForceAttemptHTTP2 = true
+		transport.HTTP2 = &http.HTTP2Config{MaxReceiveBufferPerStream: opts.H2InitialWindow}
+	}
+
+	// --http2 offers h2 through ALPN; --http1.1 leaves no protocol to upgrade to
+	switch opts.HTTPVersion {
+	case "2":
+		transport.ForceAttemptHTTP2 = true
+	case "1.1":
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	// Strip the Host header on the wire if requested
+	if opts.NoHostHeader {
+		stripHostHeader(transport, tlsConfig)
+	}
+
 	return transport, nil
 }
 
+// tlsVersions maps the version names accepted by --tlsv1.x and --tls-max
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// applyTLSVersions sets the config's MinVersion and MaxVersion from TLSMin and TLSMax
+func applyTLSVersions(tlsConfig *tls.Config, opts *cli.Options) error {
+	if opts.TLSMin != "" {
+		version, ok := tlsVersions[opts.TLSMin]
+		if !ok {
+			return fmt.Errorf("unsupported minimum TLS version %q (must be 1.0, 1.1, 1.2, or 1.3)", opts.TLSMin)
+		}
+		tlsConfig.MinVersion = version
+	}
+	if opts.TLSMax != "" {
+		version, ok := tlsVersions[opts.TLSMax]
+		if !ok {
+			return fmt.Errorf("unsupported maximum TLS version %q (must be 1.0, 1.1, 1.2, or 1.3)", opts.TLSMax)
+		}
+		tlsConfig.MaxVersion = version
+	}
+	if tlsConfig.MaxVersion != 0 && tlsConfig.MinVersion > tlsConfig.MaxVersion {
+		return fmt.Errorf("minimum TLS version %s is above maximum %s", opts.TLSMin, opts.TLSMax)
+	}
+	return nil
+}
+
+// verifyChainOnly returns a VerifyPeerCertificate hook that validates the peer's
+// chain against roots (the system pool when nil) without matching the hostname
+func verifyChainOnly(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("server presented no certificate")
+		}
+
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return fmt.Errorf("failed to parse server certificate: %w", err)
+			}
+			certs[i] = cert
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+
+		_, err := certs[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+		})
+		return err
+	}
+}
+
+// withNoDelay wraps dial so each TCP connection has TCP_NODELAY set to noDelay
+func withNoDelay(dial func(context.Context, string, string) (net.Conn, error), noDelay bool) func(context.Context, string, string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			if err := tcpConn.SetNoDelay(noDelay); err != nil {
+				conn.Close()
+				return nil, err
+			}
+		}
+		return conn, nil
+	}
+}
+
+// newDialer creates the net.Dialer used for outgoing connections
+// An unset connect timeout applies the 10s default rather than no timeout
+// --dns-servers replaces the system resolver for the names it dials
+func newDialer(opts *cli.Options) *net.Dialer {
+	dialer := &net.Dialer{
+		Timeout: GetConnectTimeout(opts),
+	}
+	if len(opts.DNSServers) > 0 {
+		dialer.Resolver = newResolver(opts.DNSServers)
+	}
+	return dialer
+}
+
 // ParseTimeout parses a duration string and returns a time.Duration
 // Supports Go duration format (e.g., "10s", "5m", "100ms")
 func ParseTimeout(durationStr string) (time.Duration, error) {
@@ -1,7 +1,24 @@
 package transport
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	stderrors "errors"
 	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -9,6 +26,7 @@ import (
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
 	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/errors"
 	"github.com/aleister1102/purl/internal/target"
 )
 
@@ -94,8 +112,8 @@ func TestNewTransport_TimeoutSettings(t *testing.T) {
 		{
 			name:                "default connect timeout",
 			connectTimeout:      0,
-			expectedDialTimeout: 0, // Will be set by GetConnectTimeout
-			expectedTLSTimeout:  0,
+			expectedDialTimeout: 10 * time.Second, // Default from GetConnectTimeout
+			expectedTLSTimeout:  10 * time.Second,
 		},
 		{
 			name:                "custom connect timeout",
@@ -125,6 +143,12 @@ func TestNewTransport_TimeoutSettings(t *testing.T) {
 					transport.TLSHandshakeTimeout,
 					tt.expectedTLSTimeout)
 			}
+
+			if dialer := newDialer(opts); dialer.Timeout != tt.expectedDialTimeout {
+				t.Errorf("Dialer timeout: got %v, want %v",
+					dialer.Timeout,
+					tt.expectedDialTimeout)
+			}
 		})
 	}
 }
@@ -530,9 +554,9 @@ func TestNewTransport_DialerConfiguration(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	// Verify that the transport has a Dial function configured
-	if transport.Dial == nil {
-		t.Errorf("expected Dial function to be configured")
+	// Verify that the transport has a DialContext function configured
+	if transport.DialContext == nil {
+		t.Errorf("expected DialContext function to be configured")
 	}
 
 	// Verify TLS handshake timeout is set
@@ -622,16 +646,499 @@ func TestNewTransport_DialerUsable(t *testing.T) {
 	}
 
 	// Verify we can create a dialer from the transport settings
-	if transport.Dial == nil {
-		t.Errorf("expected Dial function to be set")
+	if transport.DialContext == nil {
+		t.Errorf("expected DialContext function to be set")
 	}
 
 	// Try to use the dialer (this will fail to connect but should not panic)
 	// We're just testing that the configuration is valid
-	conn, err := transport.Dial("tcp", "localhost:99999")
+	conn, err := transport.DialContext(context.Background(), "tcp", "localhost:99999")
 	if err == nil {
 		conn.Close()
 	}
 	// We expect an error here since we're connecting to an invalid port
 	// The important thing is that it doesn't panic
 }
+
+func TestNewTransport_DialContextCancellation(t *testing.T) {
+	opts := &cli.Options{
+		ConnectTimeout: 30 * time.Second,
+	}
+
+	transport, err := NewTransport(opts, &target.ParsedTarget{IsIP: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	// 10.255.255.1 is non-routable, so the dial hangs until cancelled
+	start := time.Now()
+	conn, err := transport.DialContext(ctx, "tcp", "10.255.255.1:81")
+	elapsed := time.Since(start)
+
+	if err == nil {
+		conn.Close()
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("dial did not return promptly after cancellation, took %v", elapsed)
+	}
+
+	// A cancelled context stops even a dial that would succeed
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	conn, err = transport.DialContext(cancelled, "tcp", listener.Addr().String())
+	if err == nil {
+		conn.Close()
+		t.Fatal("expected dial with a cancelled context to fail")
+	}
+	if !stderrors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestNewTransport_TLSHandshakeTimeoutIndependent(t *testing.T) {
+	tests := []struct {
+		name                string
+		connectTimeout      time.Duration
+		tlsHandshakeTimeout time.Duration
+		expectedDialTimeout time.Duration
+		expectedTLSTimeout  time.Duration
+	}{
+		{
+			name:                "handshake defaults to connect timeout",
+			connectTimeout:      5 * time.Second,
+			tlsHandshakeTimeout: 0,
+			expectedDialTimeout: 5 * time.Second,
+			expectedTLSTimeout:  5 * time.Second,
+		},
+		{
+			name:                "short handshake with long connect",
+			connectTimeout:      30 * time.Second,
+			tlsHandshakeTimeout: 2 * time.Second,
+			expectedDialTimeout: 30 * time.Second,
+			expectedTLSTimeout:  2 * time.Second,
+		},
+		{
+			name:                "long handshake with short connect",
+			connectTimeout:      1 * time.Second,
+			tlsHandshakeTimeout: 20 * time.Second,
+			expectedDialTimeout: 1 * time.Second,
+			expectedTLSTimeout:  20 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &cli.Options{
+				ConnectTimeout:      tt.connectTimeout,
+				TLSHandshakeTimeout: tt.tlsHandshakeTimeout,
+			}
+
+			parsedTarget := &target.ParsedTarget{
+				IsIP: false,
+			}
+
+			transport, err := NewTransport(opts, parsedTarget)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if transport.TLSHandshakeTimeout != tt.expectedTLSTimeout {
+				t.Errorf("TLSHandshakeTimeout: got %v, want %v",
+					transport.TLSHandshakeTimeout,
+					tt.expectedTLSTimeout)
+			}
+
+			if dialer := newDialer(opts); dialer.Timeout != tt.expectedDialTimeout {
+				t.Errorf("Dialer timeout: got %v, want %v",
+					dialer.Timeout,
+					tt.expectedDialTimeout)
+			}
+		})
+	}
+}
+
+func TestNewTransport_DialsZonedIPv6Address(t *testing.T) {
+	parsedTarget, err := target.ParseTarget("[fe80::1%eth0]:8080/")
+	if err != nil {
+		t.Fatalf("ParseTarget failed: %v", err)
+	}
+
+	transport, err := NewTransport(&cli.Options{}, parsedTarget)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Capture the address handed to the dialer instead of connecting
+	var dialed string
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = addr
+		return nil, fmt.Errorf("dial intercepted")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, parsedTarget.URL.String(), nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	if resp, err := transport.RoundTrip(req); err == nil {
+		resp.Body.Close()
+	}
+
+	if dialed != "[fe80::1%eth0]:8080" {
+		t.Errorf("dialed address: got %q, want %q", dialed, "[fe80::1%eth0]:8080")
+	}
+}
+
+func TestNewTransport_HTTP2Settings(t *testing.T) {
+	t.Run("defaults leave HTTP/2 untouched", func(t *testing.T) {
+		transport, err := NewTransport(&cli.Options{}, &target.ParsedTarget{IsIP: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if transport.HTTP2 != nil || transport.ForceAttemptHTTP2 {
+			t.Errorf("Expected no HTTP/2 tuning, got %+v (force=%v)", transport.HTTP2, transport.ForceAttemptHTTP2)
+		}
+	})
+
+	for _, window := range []int{1 << 16, 1 << 20, 8 << 20} {
+		t.Run(fmt.Sprintf("initial window %d", window), func(t *testing.T) {
+			settings := captureH2Settings(t, &cli.Options{H2InitialWindow: window, Insecure: true, ConnectTimeout: 5 * time.Second})
+			if got := settings[h2SettingInitialWindowSize]; got != uint32(window) {
+				t.Errorf("SETTINGS_INITIAL_WINDOW_SIZE: got %d, want %d", got, window)
+			}
+		})
+	}
+}
+
+// h2SettingInitialWindowSize is the SETTINGS_INITIAL_WINDOW_SIZE identifier (RFC 9113)
+const h2SettingInitialWindowSize = 0x4
+
+// captureH2Settings sends a request through a transport built from opts to a TLS
+// listener that negotiates h2, and returns the settings in the client's first SETTINGS frame
+func captureH2Settings(t *testing.T, opts *cli.Options) map[uint16]uint32 {
+	t.Helper()
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	cert := server.TLS.Certificates[0]
+	server.Close()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}, NextProtos: []string{"h2"}})
+	if err != nil {
+		t.Fatalf("Failed to create listener: %v", err)
+	}
+	defer listener.Close()
+
+	settingsCh := make(chan map[uint16]uint32, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// Client preface, then a SETTINGS frame: 9-byte header and 6-byte entries
+		preface := make([]byte, len("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"))
+		header := make([]byte, 9)
+		if _, err := io.ReadFull(conn, preface); err != nil {
+			settingsCh <- nil
+			return
+		}
+		if _, err := io.ReadFull(conn, header); err != nil || header[3] != 0x4 {
+			settingsCh <- nil
+			return
+		}
+		payload := make([]byte, int(header[0])<<16|int(header[1])<<8|int(header[2]))
+		if _, err := io.ReadFull(conn, payload); err != nil {
+			settingsCh <- nil
+			return
+		}
+		settings := map[uint16]uint32{}
+		for i := 0; i+6 <= len(payload); i += 6 {
+			settings[binary.BigEndian.Uint16(payload[i:])] = binary.BigEndian.Uint32(payload[i+2:])
+		}
+		settingsCh <- settings
+	}()
+
+	transport, err := NewTransport(opts, &target.ParsedTarget{IsIP: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client := &http.Client{Transport: transport, Timeout: 2 * time.Second}
+	// The listener never answers, so only the frames sent matter
+	if resp, err := client.Get("https://" + listener.Addr().String() + "/"); err == nil {
+		resp.Body.Close()
+	}
+
+	settings := <-settingsCh
+	if settings == nil {
+		t.Fatal("expected the client to open an HTTP/2 connection with a SETTINGS frame")
+	}
+	return settings
+}
+
+// newSelfSignedTLSServer starts a TLS server with a fresh self-signed certificate
+// valid for the given names and writes that certificate to a PEM file used as its CA
+func newSelfSignedTLSServer(t *testing.T, dnsNames []string, ips []net.IP) (*httptest.Server, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "purl test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              dnsNames,
+		IPAddresses:           ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+	return server, caPath
+}
+
+func TestNewTransport_MultipleCACerts(t *testing.T) {
+	loopback := []net.IP{net.ParseIP("127.0.0.1")}
+	first, firstCA := newSelfSignedTLSServer(t, nil, loopback)
+	second, secondCA := newSelfSignedTLSServer(t, nil, loopback)
+	_, unrelatedCA := newSelfSignedTLSServer(t, nil, loopback)
+
+	tests := []struct {
+		name    string
+		caCerts []string
+		wantErr bool
+	}{
+		{"both CAs trusted", []string{firstCA, secondCA}, false},
+		{"missing CA rejected", []string{unrelatedCA}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, server := range []*httptest.Server{first, second} {
+				parsedTarget, err := target.ParseTarget(server.URL)
+				if err != nil {
+					t.Fatalf("ParseTarget failed: %v", err)
+				}
+
+				opts := &cli.Options{CACerts: tt.caCerts, StrictSSL: true}
+				transport, err := NewTransport(opts, parsedTarget)
+				if err != nil {
+					t.Fatalf("NewTransport failed: %v", err)
+				}
+
+				resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+				if err == nil {
+					resp.Body.Close()
+				}
+				if (err != nil) != tt.wantErr {
+					t.Errorf("%s: error = %v, wantErr %v", server.URL, err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestNewTransport_CACertErrors(t *testing.T) {
+	invalid := filepath.Join(t.TempDir(), "invalid.pem")
+	os.WriteFile(invalid, []byte("not a certificate"), 0644)
+
+	for _, path := range []string{invalid, filepath.Join(t.TempDir(), "missing.pem")} {
+		opts := &cli.Options{CACerts: []string{path}}
+		if _, err := NewTransport(opts, &target.ParsedTarget{}); err == nil {
+			t.Errorf("expected error for CA file %s", path)
+		}
+	}
+}
+
+func TestNewTransport_SkipHostnameVerify(t *testing.T) {
+	// Valid certificate, but for a name other than the IP being dialed
+	server, caPath := newSelfSignedTLSServer(t, []string{"other.example"}, nil)
+	_, unrelatedCA := newSelfSignedTLSServer(t, []string{"other.example"}, nil)
+
+	tests := []struct {
+		name               string
+		caCert             string
+		skipHostnameVerify bool
+		wantErr            bool
+	}{
+		{"hostname mismatch fails by default", caPath, false, true},
+		{"hostname mismatch allowed with flag", caPath, true, false},
+		{"untrusted chain still fails with flag", unrelatedCA, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsedTarget, err := target.ParseTarget(server.URL)
+			if err != nil {
+				t.Fatalf("ParseTarget failed: %v", err)
+			}
+
+			opts := &cli.Options{
+				CACerts:            []string{tt.caCert},
+				StrictSSL:          true,
+				SkipHostnameVerify: tt.skipHostnameVerify,
+			}
+			transport, err := NewTransport(opts, parsedTarget)
+			if err != nil {
+				t.Fatalf("NewTransport failed: %v", err)
+			}
+
+			resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewTransport_TLSVersionBounds(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *cli.Options
+		wantMin uint16
+		wantMax uint16
+		wantErr bool
+	}{
+		{"defaults", &cli.Options{}, 0, 0, false},
+		{"tlsv1.2", &cli.Options{TLSMin: "1.2"}, tls.VersionTLS12, 0, false},
+		{"tlsv1.3", &cli.Options{TLSMin: "1.3"}, tls.VersionTLS13, 0, false},
+		{"tls-max 1.2", &cli.Options{TLSMax: "1.2"}, 0, tls.VersionTLS12, false},
+		{"both bounds", &cli.Options{TLSMin: "1.1", TLSMax: "1.2"}, tls.VersionTLS11, tls.VersionTLS12, false},
+		{"tlsv1.3 overrides fail-on-weak-tls", &cli.Options{TLSMin: "1.3", FailOnWeakTLS: true}, tls.VersionTLS13, 0, false},
+		{"unsupported minimum", &cli.Options{TLSMin: "1.4"}, 0, 0, true},
+		{"unsupported maximum", &cli.Options{TLSMax: "ssl3"}, 0, 0, true},
+		{"minimum above maximum", &cli.Options{TLSMin: "1.3", TLSMax: "1.2"}, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := NewTransport(tt.opts, &target.ParsedTarget{})
+			if tt.wantErr {
+				var tlsErr *errors.TLSError
+				if !stderrors.As(err, &tlsErr) {
+					t.Fatalf("Expected TLSError, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewTransport failed: %v", err)
+			}
+			if got := transport.TLSClientConfig.MinVersion; got != tt.wantMin {
+				t.Errorf("MinVersion = %x, want %x", got, tt.wantMin)
+			}
+			if got := transport.TLSClientConfig.MaxVersion; got != tt.wantMax {
+				t.Errorf("MaxVersion = %x, want %x", got, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestNewTransport_TLSv13RejectsTLS12Server(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	parsedTarget, err := target.ParseTarget(server.URL)
+	if err != nil {
+		t.Fatalf("ParseTarget failed: %v", err)
+	}
+
+	for _, tt := range []struct {
+		min     string
+		wantErr bool
+	}{{"1.2", false}, {"1.3", true}} {
+		transport, err := NewTransport(&cli.Options{TLSMin: tt.min}, parsedTarget)
+		if err != nil {
+			t.Fatalf("NewTransport failed: %v", err)
+		}
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if (err != nil) != tt.wantErr {
+			t.Errorf("tlsv%s: error = %v, wantErr %v", tt.min, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNewTransport_HTTPVersion(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	parsedTarget, err := target.ParseTarget(server.URL)
+	if err != nil {
+		t.Fatalf("ParseTarget failed: %v", err)
+	}
+
+	tests := []struct {
+		httpVersion string
+		wantProto   string
+	}{
+		{"", "HTTP/1.1"},
+		{"2", "HTTP/2.0"},
+		{"1.1", "HTTP/1.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run("version "+tt.httpVersion, func(t *testing.T) {
+			transport, err := NewTransport(&cli.Options{HTTPVersion: tt.httpVersion}, parsedTarget)
+			if err != nil {
+				t.Fatalf("NewTransport failed: %v", err)
+			}
+			defer transport.CloseIdleConnections()
+
+			resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.Proto != tt.wantProto {
+				t.Errorf("Proto = %s, want %s", resp.Proto, tt.wantProto)
+			}
+		})
+	}
+}
+
+func TestNewTransport_HTTP11DisablesUpgrade(t *testing.T) {
+	transport, err := NewTransport(&cli.Options{HTTPVersion: "1.1"}, &target.ParsedTarget{IsIP: true})
+	if err != nil {
+		t.Fatalf("NewTransport failed: %v", err)
+	}
+	if transport.TLSNextProto == nil || len(transport.TLSNextProto) != 0 {
+		t.Errorf("Expected an empty TLSNextProto map, got %v", transport.TLSNextProto)
+	}
+}
@@ -0,0 +1,52 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"slices"
+)
+
+// wrapConns passes every connection of transport through wrap. HTTPS connections
+// are wrapped after the TLS handshake so wrap sees the HTTP exchange in plaintext;
+// ALPN offers only http/1.1, since net/http runs HTTP/2 only over a bare *tls.Conn
+// Connections tunnelled through a proxy are wrapped as dialed
+func wrapConns(transport *http.Transport, wrap func(net.Conn) net.Conn) {
+	dial := transport.DialContext
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return wrap(conn), nil
+	}
+
+	// --no-host-header already terminates TLS itself
+	if dialTLS := transport.DialTLSContext; dialTLS != nil {
+		transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialTLS(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return wrap(conn), nil
+		}
+		return
+	}
+
+	transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		// Read the config at dial time, after the cache set its session cache
+		config := transport.TLSClientConfig.Clone()
+		config.NextProtos = slices.DeleteFunc(config.NextProtos, func(proto string) bool { return proto == "h2" })
+
+		tlsConn, err := handshakeTLS(ctx, transport, config, conn, addr)
+		if err != nil {
+			return nil, err
+		}
+		return wrap(tlsConn), nil
+	}
+}
@@ -0,0 +1,69 @@
+package transport
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aleister1102/purl/internal/cli"
+	"github.com/aleister1102/purl/internal/target"
+)
+
+// recordingConn copies everything written to the connection
+type recordingConn struct {
+	net.Conn
+	mu      *sync.Mutex
+	written *bytes.Buffer
+}
+
+func (c *recordingConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	c.written.Write(p)
+	c.mu.Unlock()
+	return c.Conn.Write(p)
+}
+
+func TestCache_WrapConnsSeesPlaintextOverTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.EnableHTTP2 = true
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	parsedTarget, err := target.ParseTarget(server.URL)
+	if err != nil {
+		t.Fatalf("ParseTarget failed: %v", err)
+	}
+
+	var mu sync.Mutex
+	var written bytes.Buffer
+	cache := NewCache(nil)
+	cache.WrapConns(func(conn net.Conn) net.Conn {
+		return &recordingConn{Conn: conn, mu: &mu, written: &written}
+	})
+
+	tr, err := cache.Get(&cli.Options{Insecure: true}, parsedTarget)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	resp, err := (&http.Client{Transport: tr}).Get(server.URL + "/traced")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !strings.Contains(written.String(), "GET /traced HTTP/1.1") {
+		t.Errorf("Expected the plaintext request on the wrapped connection, got %q", written.String())
+	}
+	if resp.ProtoMajor != 1 {
+		t.Errorf("Expected HTTP/1.1 on a wrapped connection, got %s", resp.Proto)
+	}
+}